@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// actionsPackName is the file the pack is written to with --github-actions if
+// no output is given, in the temporary directory of the runner.
+const actionsPackName = "git2llm-pack.txt"
+
+// actionsPackPath returns the path of the pack written with --github-actions
+// if no output is given.
+func actionsPackPath() string {
+	dir := os.Getenv("RUNNER_TEMP")
+	if dir == "" {
+		dir = "."
+	}
+	return filepath.Join(dir, actionsPackName)
+}
+
+// actionsBase returns the commit to pack the changes of a pull request
+// against: the merge base of its base branch, which must have been fetched,
+// and GITHUB_SHA in the checkout at dir. Outside pull requests
+// GITHUB_BASE_REF is empty, and so is the result.
+func actionsBase(dir string) (string, error) {
+	base := os.Getenv("GITHUB_BASE_REF")
+	if base == "" {
+		return "", nil
+	}
+	head := os.Getenv("GITHUB_SHA")
+	if head == "" {
+		head = "HEAD"
+	}
+	out, err := gitOutput(dir, "merge-base", "origin/"+base, head)
+	if err != nil {
+		return "", fmt.Errorf("error finding the base of the pull request (check out with fetch-depth: 0): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// writeActionsOutputs appends the outputs of the step, name and value pairs,
+// to the file named by GITHUB_OUTPUT. Nothing is written outside GitHub
+// Actions, where it isn't set.
+func writeActionsOutputs(outputs [][2]string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+	var b strings.Builder
+	for _, o := range outputs {
+		if strings.ContainsAny(o[1], "\r\n") {
+			return fmt.Errorf("invalid value of output %s: contains a newline", o[0])
+		}
+		fmt.Fprintf(&b, "%s=%s\n", o[0], o[1])
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening GITHUB_OUTPUT: %w", err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing GITHUB_OUTPUT: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing GITHUB_OUTPUT: %w", err)
+	}
+	return nil
+}
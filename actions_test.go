@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestActionsBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	upstream := filepath.Join(tempDir, "upstream")
+	if err := os.Mkdir(upstream, 0755); err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	git(upstream, "init", "-q", "-b", "main")
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "base")
+	base := git(upstream, "rev-parse", "HEAD")
+	checkout := filepath.Join(tempDir, "checkout")
+	git(tempDir, "clone", "-q", upstream, checkout)
+	git(checkout, "commit", "-q", "--allow-empty", "-m", "change")
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "later")
+	git(checkout, "fetch", "-q")
+
+	t.Setenv("GITHUB_BASE_REF", "")
+	if ref, err := actionsBase(checkout); err != nil || ref != "" {
+		t.Errorf("Expected no base outside a pull request, got %q (%v)", ref, err)
+	}
+	t.Setenv("GITHUB_BASE_REF", "main")
+	t.Setenv("GITHUB_SHA", git(checkout, "rev-parse", "HEAD"))
+	if ref, err := actionsBase(checkout); err != nil || ref != base {
+		t.Errorf("Expected the merge base %s, got %q (%v)", base, ref, err)
+	}
+	t.Setenv("GITHUB_BASE_REF", "no-such-branch")
+	if _, err := actionsBase(checkout); err == nil {
+		t.Error("Expected an error for a base branch that wasn't fetched")
+	}
+}
+
+func TestWriteActionsOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(path, []byte("earlier=step\n"), 0644); err != nil {
+		t.Fatalf("Failed to write output file: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", path)
+	if err := writeActionsOutputs([][2]string{{"token_count", "1234"}, {"pack_path", "/tmp/pack.txt"}}); err != nil {
+		t.Fatalf("writeActionsOutputs failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if expected := "earlier=step\ntoken_count=1234\npack_path=/tmp/pack.txt\n"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+	if err := writeActionsOutputs([][2]string{{"pack_path", "a\nb"}}); err == nil {
+		t.Error("Expected an error for a value with a newline")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// allowFile lists the files whose content is included even though it looks
+// like a secret or binary data, read from the start path like .llmignore.
+const allowFile = ".llmallow"
+
+// allowHashPrefix marks an allowlist entry matching a file by the SHA-256 of
+// its content rather than by its path.
+const allowHashPrefix = "sha256:"
+
+// WithAllow adds allowlist entries, as in .llmallow: path patterns or
+// sha256:HEX fingerprints of files whose content is included even though it
+// contains a private key or looks binary, such as the dummy keys of test
+// fixtures.
+func WithAllow(entries ...string) Option {
+	return func(g *Git2LLM) {
+		g.allowEntries = append(g.allowEntries, entries...)
+	}
+}
+
+// loadAllowlist reads the .llmallow in the start path, if there is one, and
+// parses it along with the entries of WithAllow.
+func (g *Git2LLM) loadAllowlist() error {
+	entries := g.allowEntries
+	file, err := g.fs.Open(filepath.Join(g.startPath, allowFile))
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if entry := parsePatternLine(scanner.Text()); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("error reading %s: %w", allowFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error opening %s: %w", allowFile, err)
+	}
+
+	for _, entry := range entries {
+		hash, ok := strings.CutPrefix(entry, allowHashPrefix)
+		if !ok {
+			g.allowPatterns = append(g.allowPatterns, entry)
+			continue
+		}
+		hash = strings.ToLower(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+			return fmt.Errorf("invalid allowlist entry %q: expected sha256: and 64 hex digits", entry)
+		}
+		if g.allowHashes == nil {
+			g.allowHashes = make(map[string]bool)
+		}
+		g.allowHashes[hash] = true
+	}
+	return nil
+}
+
+// isAllowed reports whether the secret or binary detection of the file at
+// relPath, relative to the start path, is suppressed by the allowlist.
+// Patterns without a slash match the name of the file in any directory.
+func (g *Git2LLM) isAllowed(filePath, relPath string) bool {
+	for _, pattern := range g.allowPatterns {
+		if matchGlob(pattern, relPath) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+				return true
+			}
+		}
+	}
+	if len(g.allowHashes) == 0 {
+		return false
+	}
+	sum, err := g.hashFile(filePath)
+	return err == nil && g.allowHashes[sum]
+}
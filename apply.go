@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// patchFile is a file found in an LLM response.
+type patchFile struct {
+	path    string
+	content string
+}
+
+var (
+	// fileHeader is the header git2llm writes above every file. Annotated
+	// headers such as "File: x (Values redacted)" don't carry usable content.
+	fileHeader = regexp.MustCompile(`^File: (.+)$`)
+	// scriptHeader is the header of an executable or a script without an
+	// extension, which has content, e.g. "File: bin/deploy (executable sh script)".
+	scriptHeader = regexp.MustCompile(`^(.+) \((?:executable|(?:executable )?\w+ script)\)$`)
+	// fencePath is a line naming the file of the following fenced block, e.g.
+	// "File: x.go", "### x.go", "**x.go**" or "`x.go`:".
+	fencePath = regexp.MustCompile("^(?:(?:File|Path):\\s*|#+\\s*)?[*`]*([\\w./-]+\\.\\w+|[\\w.-]+/[\\w./-]+)[*`]*:?\\s*$")
+	// fenceInfoPath is a path in the info string of a fence, e.g. "```go cmd/main.go".
+	fenceInfoPath = regexp.MustCompile(`^(?:[\w.-]*/)*[\w-]*\.[\w.]+$|/`)
+)
+
+// runApply implements the apply command: it reads an LLM response with files in
+// the format git2llm writes, or in fenced code blocks with a path, and writes them.
+func runApply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	var dir string
+	var dryRun, help bool
+	fs.StringVar(&dir, "dir", ".", "Directory the paths in the response are relative to")
+	fs.BoolVar(&dryRun, "dry-run", false, "Show a diff of the changes without writing any file")
+	fs.BoolVar(&help, "h", false, "Display this help message")
+	fs.BoolVar(&help, "help", false, "Display this help message")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s apply [options] [response_file]\n\n", os.Args[0])
+		fmt.Println("Reads the response from stdin if no file is given.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if help {
+		fs.Usage()
+		return 0
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	logger := newLogger(os.Stderr, logLevel(false), false)
+	var in io.Reader = os.Stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			logger.Error("Error opening response", "error", err)
+			return 1
+		}
+		defer file.Close()
+		in = file
+	}
+	response, err := io.ReadAll(in)
+	if err != nil {
+		logger.Error("Error reading response", "error", err)
+		return 1
+	}
+
+	files := parsePatch(string(response))
+	if len(files) == 0 {
+		logger.Error("No files found in the response")
+		return 1
+	}
+	status := 0
+	for _, f := range files {
+		target, err := patchTarget(dir, f.path)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+			continue
+		}
+		old, err := os.ReadFile(target)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Error("Error reading file", "path", f.path, "error", err)
+			status = 1
+			continue
+		}
+		if string(old) == f.content {
+			logger.Info("Unchanged", "path", f.path)
+			continue
+		}
+		if dryRun {
+			fmt.Print(unifiedDiff(f.path, string(old), f.content))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			logger.Error("Error creating directory", "path", f.path, "error", err)
+			status = 1
+			continue
+		}
+		if err := os.WriteFile(target, []byte(f.content), 0644); err != nil {
+			logger.Error("Error writing file", "path", f.path, "error", err)
+			status = 1
+			continue
+		}
+		logger.Info("Wrote", "path", f.path)
+	}
+	return status
+}
+
+// patchTarget returns the local path of a file in a response, which must stay
+// inside dir also once symlinks are resolved. Paths in a .git directory are
+// refused too, as a hook written there runs on the next commit.
+func patchTarget(dir, name string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(name))
+	if path.IsAbs(clean) || filepath.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("refusing to write %s outside of %s", name, dir)
+	}
+	for _, part := range strings.Split(clean, "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("refusing to write %s into a .git directory", name)
+		}
+	}
+	target := filepath.Join(dir, filepath.FromSlash(clean))
+	realDir, err := resolveExisting(dir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %w", dir, err)
+	}
+	realTarget, err := resolveExisting(target)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %w", name, err)
+	}
+	if rel, err := filepath.Rel(realDir, realTarget); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to write %s outside of %s through a symlink", name, dir)
+	}
+	return target, nil
+}
+
+// resolveExisting returns the absolute path of p with the symlinks of the
+// longest part of it that exists resolved, so a file that is yet to be
+// created in a symlinked directory resolves to where it would be written.
+func resolveExisting(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	var missing []string
+	for {
+		if _, err := os.Lstat(abs); err == nil {
+			real, err := filepath.EvalSymlinks(abs)
+			if err != nil {
+				return "", fmt.Errorf("filepath.EvalSymlinks: %w", err)
+			}
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return filepath.Join(append([]string{abs}, missing...)...), nil
+		}
+		missing = append([]string{filepath.Base(abs)}, missing...)
+		abs = parent
+	}
+}
+
+// parsePatch returns the files in an LLM response. Files in the git2llm format
+// take precedence; without any, fenced code blocks with a path are used.
+func parsePatch(response string) []patchFile {
+	lines := strings.SplitAfter(response, "\n")
+	if files := parseGit2LLMFiles(lines); len(files) > 0 {
+		return files
+	}
+	return parseFencedFiles(lines)
+}
+
+// isFileHeader reports whether lines[i] starts a file in the git2llm format,
+// returning the path, or "" for a header of a file without content.
+func isFileHeader(lines []string, i int) (string, bool) {
+	m := fileHeader.FindStringSubmatch(strings.TrimRight(lines[i], "\r\n"))
+	if m == nil || i+1 >= len(lines) || strings.TrimRight(lines[i+1], "\r\n") != defaultDelimiters.Separator {
+		return "", false
+	}
+	if script := scriptHeader.FindStringSubmatch(m[1]); script != nil {
+		return script[1], true
+	}
+	if strings.HasSuffix(m[1], ")") && strings.Contains(m[1], " (") {
+		return "", true // Skipped, redacted or duplicate
+	}
+	return m[1], true
+}
+
+func parseGit2LLMFiles(lines []string) []patchFile {
+	var files []patchFile
+	for i := 0; i < len(lines); i++ {
+		name, ok := isFileHeader(lines, i)
+		if !ok {
+			continue
+		}
+		i += 2
+		if name == "" || i >= len(lines) || strings.TrimRight(lines[i], "\r\n") != "Content of "+name+":" {
+			continue
+		}
+		var content strings.Builder
+		for i+1 < len(lines) {
+			if _, next := isFileHeader(lines, i+1); next {
+				break
+			}
+			i++
+			line := strings.TrimRight(lines[i], "\r\n")
+			content.WriteString(unescapeSeparator(line, defaultDelimiters.Separator) + lines[i][len(line):])
+		}
+		// git2llm separates files with two empty lines
+		files = append(files, patchFile{path: name, content: strings.TrimSuffix(content.String(), "\n\n")})
+	}
+	return files
+}
+
+func parseFencedFiles(lines []string) []patchFile {
+	var files []patchFile
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "```") && !strings.HasPrefix(trimmed, "~~~") {
+			continue
+		}
+		fence := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+		name := ""
+		for _, field := range strings.Fields(trimmed[len(fence):]) {
+			field = strings.TrimPrefix(strings.Trim(field, `"'`), "title=")
+			if fenceInfoPath.MatchString(field) {
+				name = strings.Trim(field, `"'`)
+			}
+		}
+		if name == "" {
+			// The last non-empty line before the fence
+			for j := i - 1; j >= 0; j-- {
+				prev := strings.TrimSpace(lines[j])
+				if prev == "" {
+					continue
+				}
+				if m := fencePath.FindStringSubmatch(prev); m != nil {
+					name = m[1]
+				}
+				break
+			}
+		}
+		var content strings.Builder
+		closed := false
+		for i++; i < len(lines); i++ {
+			body := strings.TrimRight(lines[i], "\r\n")
+			if t := strings.TrimSpace(body); strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				closed = true
+				break
+			}
+			content.WriteString(lines[i])
+		}
+		if name != "" && closed {
+			files = append(files, patchFile{path: name, content: content.String()})
+		}
+	}
+	return files
+}
+
+// maxDiffTrace limits the memory used to diff a file, in ints. Larger changes are only summarized.
+const maxDiffTrace = 1 << 22
+
+// unifiedDiff returns a unified diff with three lines of context between old and new.
+func unifiedDiff(name, old, new string) string {
+	a, b := splitLines(old), splitLines(new)
+	var out bytes.Buffer
+	from := "a/" + name
+	if old == "" {
+		from = "/dev/null"
+	}
+	fmt.Fprintf(&out, "--- %s\n+++ b/%s\n", from, name)
+	ops, ok := diffLines(a, b)
+	if !ok {
+		fmt.Fprintf(&out, "@@ %d lines replaced by %d lines, too many changes to diff @@\n", len(a), len(b))
+		return out.String()
+	}
+
+	const context = 3
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		first := max(start-context, 0)
+		end := start
+		for gap := 0; end < len(ops) && gap <= 2*context; end++ {
+			if ops[end].kind == ' ' {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+		// Trim the trailing context to three lines
+		last := end
+		for last > start && ops[last-1].kind == ' ' {
+			last--
+		}
+		last = min(last+context, len(ops))
+
+		hunk := ops[first:last]
+		aStart, bStart := ops[first].a+1, ops[first].b+1
+		var aLen, bLen int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range hunk {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = last
+	}
+	return out.String()
+}
+
+// diffOp is a line of a diff: ' ' for an unchanged line, '-' or '+'. a and b
+// are the indexes of the line, or of the next line, in the old and new lines.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b with Myers' algorithm.
+// It reports false if that takes more memory than maxDiffTrace.
+func diffLines(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	var d int
+search:
+	for d = 0; d <= n+m; d++ {
+		if (d+1)*len(v) > maxDiffTrace {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace back from the end, collecting operations in reverse
+	var ops []diffOp
+	x, y := n, m
+	for ; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: ' ', line: a[x], a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{kind: '+', line: b[y], a: x, b: y})
+			} else {
+				x--
+				ops = append(ops, diffOp{kind: '-', line: a[x], a: x, b: y})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops, true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePatchRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":      "package main\n\nfunc main() {}\n",
+		"docs/a.md":    "# A\n\n\n",
+		"no-newline":   "last line",
+		".env":         "KEY=value\n",
+		"pkg/empty.go": "",
+		"bin/run":      "#!/bin/sh\necho run\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithDotfiles(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range parsePatch("Here are the files:\n\n" + output.String()) {
+		files[f.path] = f.content
+	}
+	// The redacted .env is not written back
+	delete(testFiles, ".env")
+	if !reflect.DeepEqual(files, testFiles) {
+		t.Errorf("Expected %q, got %q", testFiles, files)
+	}
+}
+
+func TestParsePatchFenced(t *testing.T) {
+	response := "Change the handler:\n\n```go cmd/api/main.go\npackage main\n```\n\n" +
+		"**internal/util.go**\n```go\npackage util\n\n// ```\n```\n\n" +
+		"An example without a path:\n```sh\ngo test ./...\n```\n"
+	expected := []patchFile{
+		{path: "cmd/api/main.go", content: "package main\n"},
+		{path: "internal/util.go", content: "package util\n\n// ```\n"},
+	}
+	if files := parsePatch(response); !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, files)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	expected := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if diff := unifiedDiff("x.txt", old, new); diff != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, diff)
+	}
+	if diff := unifiedDiff("new.txt", "", "x\n"); diff != "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,1 @@\n+x\n" {
+		t.Errorf("Unexpected diff for a new file:\n%s", diff)
+	}
+}
+
+func TestPatchTarget(t *testing.T) {
+	if target, err := patchTarget("out", "pkg/../main.go"); err != nil || target != filepath.Join("out", "main.go") {
+		t.Errorf("Expected out/main.go, got %s (err: %v)", target, err)
+	}
+	for _, name := range []string{"../main.go", "/etc/passwd", "a/../../b"} {
+		if _, err := patchTarget("out", name); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+}
+
+func TestPatchTargetSymlinks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "repo")
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"link": outside, "inside": filepath.Join(dir, "pkg")} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "file.txt"), filepath.Join(dir, "pkg", "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"link/pwned.txt", "link/new/dir/pwned.txt", "pkg/escape.txt", ".git/hooks/pre-commit", "sub/.GIT/config"} {
+		if _, err := patchTarget(dir, name); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+	for _, name := range []string{"inside/main.go", "pkg/main.go", "new/dir/main.go", ".github/workflows/ci.yml"} {
+		if _, err := patchTarget(dir, name); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// aroundFiles returns the Go files reachable from entry within hops steps,
+// relative to root and slash separated. entry is a file or a package directory
+// relative to root. A step leads from a file to the other files of its package
+// and to the files of the packages of the module it imports; the module is
+// read from the go.mod in root. Test files are only followed from other test files.
+func aroundFiles(fsys FS, root, entry string, hops int) ([]string, error) {
+	entry = path.Clean(filepath.ToSlash(entry))
+	info, err := fsys.Stat(filepath.Join(root, filepath.FromSlash(entry)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading --around %s: %w", entry, err)
+	}
+	var frontier []string
+	if info.IsDir() {
+		if frontier, err = packageFiles(fsys, root, entry, false); err != nil {
+			return nil, err
+		}
+	} else {
+		frontier = []string{entry}
+	}
+	module := goModule(fsys, root)
+
+	seen := make(map[string]bool)
+	for _, f := range frontier {
+		seen[f] = true
+	}
+	for i := 0; i < hops && len(frontier) > 0; i++ {
+		var next []string
+		for _, f := range frontier {
+			neighbors, err := goNeighbors(fsys, root, module, f)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range neighbors {
+				if !seen[n] {
+					seen[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// goNeighbors returns the files one step away from the file at relPath.
+func goNeighbors(fsys FS, root, module, relPath string) ([]string, error) {
+	if !strings.HasSuffix(relPath, ".go") {
+		return nil, nil
+	}
+	tests := strings.HasSuffix(relPath, "_test.go")
+	neighbors, err := packageFiles(fsys, root, path.Dir(relPath), tests)
+	if err != nil {
+		return nil, err
+	}
+	if module == "" {
+		return neighbors, nil
+	}
+	content, err := fsys.ReadFile(filepath.Join(root, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", relPath, err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), relPath, content, parser.ImportsOnly)
+	if err != nil {
+		return neighbors, nil // The imports of a broken file can't be followed
+	}
+	for _, imp := range file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		var dir string
+		switch {
+		case importPath == module:
+			dir = "."
+		case strings.HasPrefix(importPath, module+"/"):
+			dir = strings.TrimPrefix(importPath, module+"/")
+		default:
+			continue // Standard library and other modules
+		}
+		files, err := packageFiles(fsys, root, dir, false)
+		if err != nil {
+			continue // Packages outside the start path or not checked in
+		}
+		neighbors = append(neighbors, files...)
+	}
+	return neighbors, nil
+}
+
+// packageFiles returns the Go files in the directory dir, with test files if tests is set.
+func packageFiles(fsys FS, root, dir string, tests bool) ([]string, error) {
+	entries, err := fsys.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || (!tests && strings.HasSuffix(name, "_test.go")) {
+			continue
+		}
+		files = append(files, path.Join(dir, name))
+	}
+	return files, nil
+}
+
+// goModule returns the module path declared in the go.mod in root, or "" if there is none.
+func goModule(fsys FS, root string) string {
+	content, err := fsys.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return parseGoMod(content).module
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAroundFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"go.mod":                 "module example.com/app\n\ngo 1.22\n",
+		"cmd/api/main.go":        "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/internal/server\"\n)\n",
+		"cmd/api/flags.go":       "package main\n",
+		"cmd/api/main_test.go":   "package main\n",
+		"internal/server/srv.go": "package server\n\nimport \"example.com/app/internal/store\"\n",
+		"internal/store/db.go":   "package store\n",
+		"internal/other/x.go":    "package other\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	testCases := []struct {
+		entry    string
+		hops     int
+		expected []string
+	}{
+		{"cmd/api/main.go", 0, []string{"cmd/api/main.go"}},
+		{"cmd/api/main.go", 1, []string{"cmd/api/flags.go", "cmd/api/main.go", "internal/server/srv.go"}},
+		{"cmd/api/main.go", 2, []string{"cmd/api/flags.go", "cmd/api/main.go", "internal/server/srv.go", "internal/store/db.go"}},
+		{"internal/server", 1, []string{"internal/server/srv.go", "internal/store/db.go"}},
+	}
+	for _, tc := range testCases {
+		files, err := aroundFiles(OSFS{}, tempDir, tc.entry, tc.hops)
+		if err != nil {
+			t.Errorf("aroundFiles(%s, %d) failed: %v", tc.entry, tc.hops, err)
+			continue
+		}
+		if !reflect.DeepEqual(files, tc.expected) {
+			t.Errorf("aroundFiles(%s, %d) = %v, expected %v", tc.entry, tc.hops, files, tc.expected)
+		}
+	}
+	if _, err := aroundFiles(OSFS{}, tempDir, "cmd/missing.go", 1); err == nil {
+		t.Errorf("Expected an error for a missing entry")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/perbu/git2llm/llm"
+)
+
+// runAsk implements the ask command: it packs the repository, prepends a
+// question and streams the answer from an LLM provider to stdout.
+func runAsk(args []string) int {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	var cfg cliConfig
+	cfg.registerFlags(fs)
+
+	var question string
+	fs.StringVar(&question, "question", "", "Question to ask about the repository")
+	fs.StringVar(&question, "q", "", "Question to ask about the repository")
+
+	var provider string
+	fs.StringVar(&provider, "provider", "openai", "LLM provider to send the question to (openai, anthropic or gemini)")
+
+	var llmModel string
+	fs.StringVar(&llmModel, "llm-model", "", "Model to answer the question (default depends on the provider)")
+
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s ask [options] --question <question> <start_path> [start_path...] [file_extensions...]\n\n", os.Args[0])
+		fmt.Println("The API key is read from OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+
+	if cfg.help {
+		fs.Usage()
+		return 0
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		cfg.logger().Error(err.Error())
+		return 1
+	}
+	if question == "" || (fs.NArg() < 1 && cfg.remote() == "" && cfg.fromURLs == "") {
+		fs.Usage()
+		return 1
+	}
+
+	logger := cfg.logger()
+	if cfg.offline {
+		logger.Error("ask can't be used with --offline")
+		return 1
+	}
+	client, err := llm.New(provider, llmModel)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	var pack bytes.Buffer
+	roots, err := cfg.newRoots(fs.Args(), &pack)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if err := ScanRepositories(roots...); err != nil {
+		logger.Error("Scan failed", "error", err)
+		return 1
+	}
+	cfg.warnMissingSymbols()
+
+	logger.Debug("Asking", "provider", provider, "model", client.Model(), "bytes", pack.Len())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := client.Stream(ctx, buildPrompt(question, pack.String()), os.Stdout); err != nil {
+		fmt.Println()
+		logger.Error(err.Error())
+		return 1
+	}
+	fmt.Println()
+	return 0
+}
+
+// buildPrompt prepends the user's question to the packed repository.
+func buildPrompt(question, pack string) string {
+	return question + "\n\nThe repository is included below.\n\n" + pack
+}
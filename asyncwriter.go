@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// asyncChunkSize is the size of the chunks writes are collected in.
+	asyncChunkSize = 64 * 1024
+	// asyncQueueLength is the number of chunks that may wait for the sink.
+	// Once it falls that far behind, Write blocks until it catches up.
+	asyncQueueLength = 16
+)
+
+// asyncWriter decouples producing the output from writing it: writes are
+// collected in chunks, which a goroutine writes to the underlying writer, so
+// reading and sanitizing files goes on while a slow sink such as a pipe or a
+// network file system takes its time. The queue is bounded, so memory use
+// stays flat. The first error of the sink is returned by the next Write and
+// by Close; the chunks after it are dropped.
+type asyncWriter struct {
+	w      io.Writer
+	buf    []byte
+	chunks chan []byte
+	free   chan []byte // Written chunks, to be reused
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func newAsyncWriter(w io.Writer) *asyncWriter {
+	a := &asyncWriter{
+		w:      w,
+		buf:    make([]byte, 0, asyncChunkSize),
+		chunks: make(chan []byte, asyncQueueLength),
+		free:   make(chan []byte, asyncQueueLength+1),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// run writes the queued chunks until the queue is closed.
+func (a *asyncWriter) run() {
+	defer close(a.done)
+	for chunk := range a.chunks {
+		if a.error() == nil {
+			if _, err := a.w.Write(chunk); err != nil {
+				a.mu.Lock()
+				a.err = err
+				a.mu.Unlock()
+			}
+		}
+		select {
+		case a.free <- chunk[:0]:
+		default:
+		}
+	}
+}
+
+func (a *asyncWriter) error() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	if err := a.error(); err != nil {
+		return 0, err
+	}
+	a.buf = append(a.buf, p...)
+	if len(a.buf) >= asyncChunkSize {
+		a.send()
+	}
+	return len(p), nil
+}
+
+// send queues the collected chunk, blocking while the queue is full.
+func (a *asyncWriter) send() {
+	a.chunks <- a.buf
+	select {
+	case a.buf = <-a.free:
+	default:
+		a.buf = make([]byte, 0, asyncChunkSize)
+	}
+}
+
+// Close writes the rest of the output and waits for the sink. It doesn't
+// close the underlying writer.
+func (a *asyncWriter) Close() error {
+	if len(a.buf) > 0 {
+		a.send()
+	}
+	close(a.chunks)
+	<-a.done
+	if err := a.error(); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter is a sink taking its time with every write.
+type slowWriter struct {
+	mu     sync.Mutex
+	out    strings.Builder
+	writes int
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes++
+	return s.out.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	sink := &slowWriter{}
+	a := newAsyncWriter(sink)
+	var expected strings.Builder
+	for i := 0; i < 5000; i++ {
+		line := strings.Repeat("x", i%100) + "\n"
+		expected.WriteString(line)
+		if n, err := a.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("Write returned %d, %v", n, err)
+		}
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if sink.out.String() != expected.String() {
+		t.Errorf("Expected the writes in order, got %d of %d bytes", sink.out.Len(), expected.Len())
+	}
+	if sink.writes > expected.Len()/asyncChunkSize+1 {
+		t.Errorf("Expected the writes to be collected in chunks, got %d writes", sink.writes)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAsyncWriterError(t *testing.T) {
+	a := newAsyncWriter(failingWriter{})
+	chunk := make([]byte, asyncChunkSize)
+	var err error
+	for i := 0; i < 2*asyncQueueLength+2 && err == nil; i++ {
+		_, err = a.Write(chunk)
+		time.Sleep(time.Millisecond)
+	}
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the error of the sink from Write, got %v", err)
+	}
+	if err := a.Close(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the error of the sink from Close, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBenchmarkTree writes a tree of Go files, dirs directories of files
+// files each, and returns its path.
+func writeBenchmarkTree(b *testing.B, dirs, files int) string {
+	b.Helper()
+	root := b.TempDir()
+	content := []byte(strings.Repeat("func f(x int) int {\n\treturn x * 2 // double\n}\n\n", 50))
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", d), "internal", "sub")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatalf("Failed to create directory: %v", err)
+		}
+		for f := 0; f < files; f++ {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.go", f)), content, 0644); err != nil {
+				b.Fatalf("Failed to write file: %v", err)
+			}
+		}
+	}
+	return root
+}
+
+// benchmarkPatterns are exclusion patterns of the kinds found in .llmignore files.
+var benchmarkPatterns = []string{"*.pb.go", "*_mock.go", "testdata/", "/build/", "docs/", "*.min.js", "generated", "/cmd/tool", "*.snap", "fixtures/"}
+
+func BenchmarkIsExcluded(b *testing.B) {
+	g, err := NewGit2LLM(".", nil, nil, io.Discard, false, false, false, benchmarkPatterns, "", false)
+	if err != nil {
+		b.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	paths := []string{"main.go", "internal/server/handler/routes.go", "pkg/a/b/c/d/e/f/deep_file.go", "api/v1/service.pb.go"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			g.isExcluded(p)
+		}
+	}
+}
+
+func BenchmarkCollectFiles(b *testing.B) {
+	root := writeBenchmarkTree(b, 20, 20)
+	g, err := NewGit2LLM(root, []string{".go"}, nil, io.Discard, false, false, false, benchmarkPatterns, "", false)
+	if err != nil {
+		b.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.collectFiles(); err != nil {
+			b.Fatalf("collectFiles failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	root := writeBenchmarkTree(b, 10, 20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g, err := NewGit2LLM(root, nil, nil, io.Discard, false, false, true, benchmarkPatterns, "estimate", false)
+		if err != nil {
+			b.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if _, err := Scan(g); err != nil {
+			b.Fatalf("Scan failed: %v", err)
+		}
+	}
+}
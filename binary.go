@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF for image.DecodeConfig
+	_ "image/jpeg" // Register JPEG for image.DecodeConfig
+	_ "image/png"  // Register PNG for image.DecodeConfig
+	"io"
+	"net/http"
+)
+
+// binaryPreviewBytes is the number of leading bytes shown in hex in a binary metadata stub.
+const binaryPreviewBytes = 16
+
+// WithBinaryMetadata emits a metadata stub for binary files (size, MIME type,
+// image dimensions and the first bytes) instead of only noting that they were skipped.
+func WithBinaryMetadata(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.binaryMetadata = enabled
+	}
+}
+
+// writeBinaryMetadata writes the metadata stub of the binary file at filePath to w.
+func (g *Git2LLM) writeBinaryMetadata(w io.Writer, filePath string) error {
+	var lines []string
+	if info, err := g.fs.Stat(filePath); err == nil {
+		lines = append(lines, fmt.Sprintf("Size: %s (%d bytes)", formatBytes(info.Size()), info.Size()))
+	}
+	head, err := g.readHead(filePath)
+	if err == nil {
+		lines = append(lines, "MIME type: "+http.DetectContentType(head))
+	}
+	if file, err := g.fs.Open(filePath); err == nil {
+		// Only the image header is read, however large the file is
+		if config, format, err := image.DecodeConfig(file); err == nil {
+			lines = append(lines, fmt.Sprintf("Image: %s, %dx%d", format, config.Width, config.Height))
+		}
+		file.Close()
+	}
+	if len(head) > 0 {
+		n := min(len(head), binaryPreviewBytes)
+		lines = append(lines, fmt.Sprintf("First bytes: % x", head[:n]))
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	return nil
+}
+
+// hasBinaryMetadata reports whether a forbidden file gets a metadata stub.
+// Files with private keys never do.
+func (g *Git2LLM) hasBinaryMetadata(reason string) bool {
+	return g.binaryMetadata && reason == "binary"
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	bitbucketAPIURL = "https://api.bitbucket.org/2.0"
+	// bitbucketMaxDepth is the depth of directories listed by a single
+	// listing of the tree, enough for any repository.
+	bitbucketMaxDepth = 100
+)
+
+// bitbucketFS implements FS on top of a Bitbucket Cloud repository, like
+// githubFS. The tree is listed a page at a time; file contents are downloaded
+// on first access and kept in memory.
+type bitbucketFS struct {
+	*treeFS
+	repo, ref string // ref is empty for the main branch
+	commit    string // The commit the tree was listed at
+	token     string
+	apiURL    string
+	client    *http.Client
+}
+
+// newBitbucketFS fetches the tree of a Bitbucket repository given as
+// "workspace/repo[#ref]". token is optional and needed for private
+// repositories: an access token, or username:app-password.
+func newBitbucketFS(spec, token string) (*bitbucketFS, error) {
+	repo, ref, err := parseRemoteSpec(spec, "Bitbucket", false)
+	if err != nil {
+		return nil, err
+	}
+	b := &bitbucketFS{
+		repo:   repo,
+		ref:    ref,
+		token:  token,
+		apiURL: bitbucketAPIURL,
+		client: &http.Client{Timeout: time.Minute},
+	}
+	return b, b.loadTree()
+}
+
+func (b *bitbucketFS) loadTree() error {
+	ref := b.ref
+	if ref == "" {
+		body, err := b.get(fmt.Sprintf("%s/repositories/%s", b.apiURL, b.repo))
+		if err != nil {
+			return fmt.Errorf("error fetching repository: %w", err)
+		}
+		var repo struct {
+			MainBranch struct {
+				Name string `json:"name"`
+			} `json:"mainbranch"`
+		}
+		if err := json.Unmarshal(body, &repo); err != nil {
+			return fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		ref = repo.MainBranch.Name
+	}
+
+	var entries []*treeEntry
+	seen := make(map[string]bool)
+	add := func(e *treeEntry) {
+		if seen[e.path] {
+			return
+		}
+		seen[e.path] = true
+		entries = append(entries, e)
+		// Add the parent directories, in case the listing doesn't have them
+		for dir := path.Dir(e.path); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			entries = append(entries, &treeEntry{path: dir, isDir: true})
+		}
+	}
+	next := fmt.Sprintf("%s/repositories/%s/src/%s/?max_depth=%d&pagelen=100", b.apiURL, b.repo, url.PathEscape(ref), bitbucketMaxDepth)
+	for next != "" {
+		body, err := b.get(next)
+		if err != nil {
+			return fmt.Errorf("error fetching tree: %w", err)
+		}
+		var page struct {
+			Values []struct {
+				Path       string   `json:"path"`
+				Type       string   `json:"type"`
+				Size       int64    `json:"size"`
+				Attributes []string `json:"attributes"`
+				Commit     struct {
+					Hash string `json:"hash"`
+				} `json:"commit"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		for _, item := range page.Values {
+			if b.commit == "" {
+				b.commit = item.Commit.Hash
+			}
+			mode := "100644"
+			switch {
+			case item.Type == "commit_directory":
+				add(&treeEntry{path: item.Path, isDir: true})
+				continue
+			case item.Type != "commit_file" || slices.Contains(item.Attributes, "subrepository"):
+				continue // Submodules have no content in this repository
+			case slices.Contains(item.Attributes, "link"):
+				mode = "120000"
+			case slices.Contains(item.Attributes, "executable"):
+				mode = "100755"
+			}
+			add(&treeEntry{path: item.Path, mode: mode, size: item.Size})
+		}
+		next = page.Next
+	}
+	if b.commit == "" {
+		b.commit = ref // An empty repository
+	}
+	b.treeFS = newTreeFS(entries, b.fetch)
+	return nil
+}
+
+// get performs a GET request and returns the body.
+func (b *bitbucketFS) get(u string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	if user, password, ok := strings.Cut(b.token, ":"); ok {
+		req.SetBasicAuth(user, password)
+	} else if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http.Do: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("Bitbucket API rate limit exceeded")
+	case resp.StatusCode == http.StatusNotFound && b.token == "":
+		return nil, fmt.Errorf("GET %s: %s (set BITBUCKET_TOKEN for private repositories)", u, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fetch downloads the content of a file, from the commit the tree was listed at.
+func (b *bitbucketFS) fetch(e *treeEntry) ([]byte, error) {
+	return b.get(fmt.Sprintf("%s/repositories/%s/src/%s/%s", b.apiURL, b.repo, b.commit, escapePath(e.path)))
+}
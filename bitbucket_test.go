@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBitbucketFSScan(t *testing.T) {
+	files := map[string]string{
+		"README.md":       "# Remote",
+		"cmd/app/main.go": "package main",
+		"run.sh":          "#!/bin/sh",
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repositories/octo/demo":
+			fmt.Fprint(w, `{"mainbranch": {"name": "main"}}`)
+		case r.URL.Path == "/repositories/octo/demo/src/main/" && r.URL.Query().Get("page") == "":
+			fmt.Fprintf(w, `{"values": [
+				{"path": "README.md", "type": "commit_file", "size": 8, "attributes": [], "commit": {"hash": "abc123"}},
+				{"path": "cmd/app/main.go", "type": "commit_file", "size": 12, "attributes": [], "commit": {"hash": "abc123"}}
+			], "next": "%s/repositories/octo/demo/src/main/?page=2"}`, server.URL)
+		case r.URL.Path == "/repositories/octo/demo/src/main/":
+			fmt.Fprint(w, `{"values": [
+				{"path": "run.sh", "type": "commit_file", "size": 9, "attributes": ["executable"], "commit": {"hash": "abc123"}},
+				{"path": "vendored", "type": "commit_file", "size": 0, "attributes": ["subrepository"], "commit": {"hash": "abc123"}}
+			]}`)
+		case strings.HasPrefix(r.URL.Path, "/repositories/octo/demo/src/abc123/"):
+			content, ok := files[strings.TrimPrefix(r.URL.Path, "/repositories/octo/demo/src/abc123/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fsys := &bitbucketFS{repo: "octo/demo", apiURL: server.URL, client: server.Client()}
+	if err := fsys.loadTree(); err != nil {
+		t.Fatalf("loadTree failed: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	for _, expected := range []string{"cmd/\n", "app/\n", "Content of README.md:\n# Remote", "Content of cmd/app/main.go:\npackage main", "Content of run.sh:\n#!/bin/sh"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "vendored") {
+		t.Errorf("Did not expect submodule in output. Result:\n%s", result)
+	}
+}
+
+func TestBitbucketFSAuth(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	for _, token := range []string{"", "token", "user:password"} {
+		fsys := &bitbucketFS{repo: "octo/demo", ref: "main", token: token, apiURL: server.URL, client: server.Client()}
+		err := fsys.loadTree()
+		if err == nil {
+			t.Fatalf("Expected an error for token %q", token)
+		}
+		if mentions := strings.Contains(err.Error(), "BITBUCKET_TOKEN"); mentions != (token == "") {
+			t.Errorf("For token %q, unexpected error: %v", token, err)
+		}
+	}
+	expected := []string{"", "Bearer token", "Basic dXNlcjpwYXNzd29yZA=="}
+	if strings.Join(auth, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected Authorization headers %q, got %q", expected, auth)
+	}
+}
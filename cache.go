@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tokenCacheFile = "tokens.json"
+
+// tokenCache persists per-file token counts between runs so unchanged files
+// don't have to be tokenized again. Entries are keyed by model, variant and
+// absolute path and are only valid while the file size and modification time
+// are unchanged. The variant describes the options that change the content
+// written for a file, so a count is only reused for the same content.
+// It is safe for concurrent use, counts are stored from the tokenizer workers.
+type tokenCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]tokenCacheEntry
+	dirty   bool
+}
+
+type tokenCacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Tokens  int       `json:"tokens"`
+}
+
+// defaultTokenCachePath returns the location of the token cache in the user's cache directory.
+func defaultTokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("os.UserCacheDir: %w", err)
+	}
+	return filepath.Join(dir, "git2llm", tokenCacheFile), nil
+}
+
+// loadTokenCache reads the cache at path. A missing or unreadable cache yields an empty one.
+func loadTokenCache(path string) *tokenCache {
+	c := &tokenCache{path: path, entries: make(map[string]tokenCacheEntry)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]tokenCacheEntry)
+	}
+	return c
+}
+
+// tokenCacheKey returns the key of a file's entry. The variant is left out when
+// it is empty, so entries of the default options stay valid across versions.
+func tokenCacheKey(model, variant, filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+	if variant != "" {
+		model += "[" + variant + "]"
+	}
+	return model + ":" + filePath
+}
+
+// get returns the cached token count for a file if its size and modification time still match.
+func (c *tokenCache) get(model, variant, filePath string, info os.FileInfo) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[tokenCacheKey(model, variant, filePath)]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return 0, false
+	}
+	return entry.Tokens, true
+}
+
+func (c *tokenCache) put(model, variant, filePath string, info os.FileInfo, tokens int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[tokenCacheKey(model, variant, filePath)] = tokenCacheEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Tokens:  tokens,
+	}
+	c.dirty = true
+}
+
+// cacheVariant describes the options that change the content written for a
+// file, which its cached token count is only valid for. It is empty for the
+// default options.
+func (g *Git2LLM) cacheVariant() string {
+	var options []string
+	if g.maxLineLength > 0 {
+		options = append(options, fmt.Sprintf("max-line-length=%d", g.maxLineLength))
+	}
+	if !g.sanitize {
+		options = append(options, "no-sanitize")
+	}
+	if g.keepCRLF {
+		options = append(options, "keep-crlf")
+	}
+	if g.sampleRows > 0 {
+		options = append(options, fmt.Sprintf("sample-data=%d", g.sampleRows))
+	}
+	return strings.Join(options, ",")
+}
+
+// save writes the cache back to disk if it has changed.
+func (c *tokenCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	// Write to a temporary file first so an interrupted run can't leave a truncated cache behind.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "git2llm", tokenCacheFile)
+	info := mockSizedFileInfo{size: 42, modTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	cache := loadTokenCache(cachePath)
+	if _, ok := cache.get("cl100k_base", "", "main.go", info); ok {
+		t.Fatal("Expected empty cache to miss")
+	}
+	cache.put("cl100k_base", "", "main.go", info, 17)
+	if err := cache.save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	reloaded := loadTokenCache(cachePath)
+	if tokens, ok := reloaded.get("cl100k_base", "", "main.go", info); !ok || tokens != 17 {
+		t.Errorf("Expected cached count 17, got %d (hit: %v)", tokens, ok)
+	}
+	if _, ok := reloaded.get("o200k_base", "", "main.go", info); ok {
+		t.Error("Expected a miss for a different model")
+	}
+	modified := info
+	modified.modTime = info.modTime.Add(time.Second)
+	if _, ok := reloaded.get("cl100k_base", "", "main.go", modified); ok {
+		t.Error("Expected a miss for a modified file")
+	}
+	resized := info
+	resized.size = 43
+	if _, ok := reloaded.get("cl100k_base", "", "main.go", resized); ok {
+		t.Error("Expected a miss for a resized file")
+	}
+}
+
+func TestTokenCacheCorrupt(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), tokenCacheFile)
+	if err := os.WriteFile(cachePath, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write cache file: %v", err)
+	}
+	cache := loadTokenCache(cachePath)
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected corrupt cache to be discarded, got %d entries", len(cache.entries))
+	}
+}
+
+type mockSizedFileInfo struct {
+	mockFileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (m mockSizedFileInfo) Size() int64        { return m.size }
+func (m mockSizedFileInfo) ModTime() time.Time { return m.modTime }
+
+// scanTokens scans dir counting tokens with the cache at cachePath and returns
+// the count of file.
+func scanTokens(t *testing.T, dir, cachePath, file string, opts ...Option) int {
+	t.Helper()
+	opts = append(opts, withTokenCache(loadTokenCache(cachePath)))
+	git2llm, err := NewGit2LLM(dir, nil, OSFS{}, io.Discard, false, false, true, nil, "estimate", false, opts...)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	for _, f := range result.Files {
+		if f.Path == file {
+			return f.Tokens
+		}
+	}
+	t.Fatalf("%s not in the output", file)
+	return 0
+}
+
+func TestTokenCacheOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "long.txt"), []byte(strings.Repeat("x", 5000)+"\n"+strings.Repeat("\x1b[31mred\x1b[0m\r\n", 200)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cachePath := filepath.Join(t.TempDir(), tokenCacheFile)
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{"max line length", []Option{WithMaxLineLength(100)}},
+		{"no sanitize", []Option{WithSanitize(false)}},
+		// The estimate doesn't count line endings, so only the key tells these apart
+		{"keep CRLF", []Option{WithNormalizeNewlines(false)}},
+		{"keep CRLF, no sanitize", []Option{WithNormalizeNewlines(false), WithSanitize(false)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fresh := scanTokens(t, dir, filepath.Join(t.TempDir(), tokenCacheFile), "long.txt", tc.opts...)
+			full := scanTokens(t, dir, cachePath, "long.txt")
+			if got := scanTokens(t, dir, cachePath, "long.txt", tc.opts...); got != fresh {
+				t.Errorf("Expected %d tokens as without a cache, got %d", fresh, got)
+			}
+			if got := scanTokens(t, dir, cachePath, "long.txt"); got != full {
+				t.Errorf("Expected %d tokens with the default options, got %d", full, got)
+			}
+			g := &Git2LLM{sanitize: true}
+			for _, opt := range tc.opts {
+				opt(g)
+			}
+			if g.cacheVariant() == "" {
+				t.Errorf("Expected the options to change the cache key")
+			}
+		})
+	}
+}
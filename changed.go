@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithOnlyPaths restricts the file contents to the given paths, relative to the
+// start path and slash separated. The directory tree still shows everything.
+func WithOnlyPaths(paths []string) Option {
+	return func(g *Git2LLM) {
+		g.onlyPaths = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			g.onlyPaths[filepath.ToSlash(p)] = true
+		}
+	}
+}
+
+// filterOnlyPaths drops the files not selected with WithOnlyPaths.
+func (g *Git2LLM) filterOnlyPaths(files []manifestEntry) []manifestEntry {
+	if g.onlyPaths == nil {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if g.onlyPaths[f.relPath] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// changedFiles returns the files below dir that differ from ref, including new
+// files that are neither tracked nor ignored by git. Deleted files are left out.
+// Paths are relative to dir.
+func changedFiles(dir, ref string) ([]string, error) {
+	diff, err := runGit(dir, "diff", "--name-only", "--relative", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runGit(dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range append(diff, untracked...) {
+		if !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// withTests adds the tests of files that exist below dir, see testFiles.
+func withTests(dir string, files []string) []string {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f] = true
+	}
+	for _, f := range files {
+		for _, test := range testFiles(f) {
+			if seen[test] {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(test))); err == nil && !info.IsDir() {
+				seen[test] = true
+				files = append(files, test)
+			}
+		}
+	}
+	return files
+}
+
+// runGit runs git in dir and returns the non-empty lines of its output.
+func runGit(dir string, args ...string) ([]string, error) {
+	out, err := gitOutput(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
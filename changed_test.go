@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "-q")
+	write("main.go", "package main\n")
+	write("old.go", "package main\n")
+	write("deleted.go", "package main\n")
+	write(".gitignore", "*.log\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("pkg/new.go", "package pkg\n")
+	write("debug.log", "ignored\n")
+	if err := os.Remove(filepath.Join(tempDir, "deleted.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	files, err := changedFiles(tempDir, "HEAD")
+	if err != nil {
+		t.Fatalf("changedFiles failed: %v", err)
+	}
+	sort.Strings(files)
+	if expected := []string{"main.go", "pkg/new.go"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithOnlyPaths(files))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, name := range []string{"main.go", "pkg/new.go"} {
+		if !strings.Contains(result, "File: "+name+"\n") {
+			t.Errorf("Expected contents of %s in the output", name)
+		}
+	}
+	if strings.Contains(result, "File: old.go") {
+		t.Errorf("Expected unchanged old.go to be left out of the contents")
+	}
+}
+
+func TestWithTests(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"pkg/parser.go", "pkg/parser_test.go", "pkg/lexer.go", "app.py", "test_app.py", "web/__tests__/button.test.js"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	got := withTests(tempDir, []string{"pkg/parser.go", "pkg/lexer.go", "test_app.py", "app.py", "web/button.js"})
+	expected := []string{"pkg/parser.go", "pkg/lexer.go", "test_app.py", "app.py", "web/button.js", "pkg/parser_test.go", "web/__tests__/button.test.js"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
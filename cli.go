@@ -0,0 +1,837 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/perbu/git2llm/llm"
+	"github.com/perbu/git2llm/tokens"
+)
+
+// cliConfig holds the command line flags shared by all commands.
+type cliConfig struct {
+	excludeTests    bool
+	onlyTests       bool
+	withTested      bool
+	withTests       bool
+	testPatterns    stringSliceFlag
+	testLanguages   string
+	verbose         bool
+	countTokens     bool
+	excludePatterns stringSliceFlag
+	ignoreFile      string
+	noDefaultExcl   bool
+	defaultExclFile string
+	noCache         bool
+	offline         bool
+	model           string
+	noRecurse       bool
+	maxDepth        int
+	noProgress      bool
+	github          string
+	gitlab          string
+	gitlabURL       string
+	bitbucket       string
+	stdinName       string
+	skipReport      string
+	redactPatterns  stringSliceFlag
+	noRedact        bool
+	noSanitize      bool
+	keepCRLF        bool
+	asciiTree       bool
+	treeStats       bool
+	execFilters     stringSliceFlag
+	symbols         stringSliceFlag
+	symbolExtractor *symbolExtractor
+	condenseLocks   bool
+	sampleData      int
+	changed         string
+	since           string
+	sinceGit        bool
+	around          string
+	hops            int
+	gitignore       bool
+	gitIndex        bool
+	dedup           bool
+	fileHashes      bool
+	fileGitInfo     bool
+	toc             bool
+	overview        bool
+	codeMap         bool
+	dependencies    bool
+	workingDiff     bool
+	ref             string
+	binaryMetadata  bool
+	noPlaceholders  bool
+	forceText       stringSliceFlag
+	allow           stringSliceFlag
+	dataSchemas     bool
+	includeDotfiles bool
+	includeVendored bool
+	noGitAttrs      bool
+	includes        stringSliceFlag
+	quiet           bool
+	debug           bool
+	logJSON         bool
+	log             *slog.Logger
+	summarizeOver   int
+	summarizeWith   string
+	summarizeModel  string
+	pathPrefix      string
+	rootLabel       string
+	noTree          bool
+	order           string
+	docsFirst       bool
+	emitters        []emitter
+	maxLineLength   int
+	policies        stringSliceFlag
+	maxFileSize     int64
+	skipOverTokens  int
+	maxBytes        int64
+	maskPII         bool
+	languages       stringSliceFlag
+	focus           stringSliceFlag
+	fromURLs        string
+	noDotDefaults   bool
+	frontMatter     bool
+	images          bool
+	describeImages  bool
+	fileHeader      string
+	separator       string
+	contentHeader   string
+	options         []string // The flags set, as name=value, for the front matter
+	profile         string
+	configFile      string
+	profileTypes    []string
+	help            bool
+}
+
+// registerFlags defines the shared flags on fs.
+func (c *cliConfig) registerFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.excludeTests, "t", false, "Exclude test files from known languages")
+	fs.BoolVar(&c.excludeTests, "exclude-tests", false, "Exclude test files from known languages")
+	fs.BoolVar(&c.onlyTests, "only-tests", false, "Only include the contents of test files from known languages, the inverse of -t")
+	fs.Var(&c.testPatterns, "test-pattern", "Add a pattern of test files for -t and --only-tests, as LANGUAGE=PATTERN, e.g. python=*_check.py (comma separated or repeated)")
+	fs.StringVar(&c.testLanguages, "test-languages", "", "Only apply the test patterns of these languages, comma separated (e.g. go,python; default all)")
+	fs.BoolVar(&c.withTested, "with-tested", false, "With --only-tests, also include the files the tests are named after, e.g. parser.go for parser_test.go")
+
+	fs.BoolVar(&c.verbose, "v", false, "Enable verbose output")
+	fs.BoolVar(&c.verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(&c.quiet, "quiet", false, "Only log warnings and errors")
+	fs.BoolVar(&c.debug, "debug", false, "Log everything, including per-path decisions (implies -v)")
+	fs.BoolVar(&c.logJSON, "log-json", false, "Write log messages to stderr as JSON")
+
+	fs.BoolVar(&c.countTokens, "c", false, "Count tokens in the output")
+
+	fs.Var(&c.excludePatterns, "e", "Add pattern to exclude (e.g., vendor or content:DO NOT EDIT)")
+
+	fs.StringVar(&c.ignoreFile, "ignore-file", "", "Read exclusion patterns from this file instead of the .llmignore in the start path")
+	fs.BoolVar(&c.noDefaultExcl, "no-default-excludes", false, "Do not apply the default exclusion patterns (.git, .svn, .idea, .vscode and go.sum)")
+	fs.StringVar(&c.defaultExclFile, "default-excludes-file", "", "Read the default exclusion patterns from this file instead of using the built-in ones")
+
+	fs.BoolVar(&c.noCache, "no-cache", false, "Do not cache token counts between runs")
+	fs.BoolVar(&c.offline, "offline", false, "Never download anything: fail if the tokenizer of the model is not cached yet and reject remote repositories, --from-urls and LLM calls")
+
+	fs.StringVar(&c.model, "m", "cl100k_base", "Model to count tokens for (e.g. gpt-4o, claude-sonnet-4-20250514, gemini-2.0-flash, llama3, an encoding such as cl100k_base, file:PATH to a tokenizer.json, or estimate)")
+
+	fs.BoolVar(&c.noRecurse, "R", false, "Do not recurse into subdirectories")
+
+	fs.IntVar(&c.maxDepth, "max-depth", 0, "Limit the scan to N directory levels (1 = start directory only, 0 = unlimited)")
+
+	fs.BoolVar(&c.noProgress, "no-progress", false, "Do not show a progress line on stderr")
+
+	fs.StringVar(&c.github, "github", "", "Scan a GitHub repository (owner/repo[#ref]) instead of a local directory; uses GITHUB_TOKEN if set")
+	fs.StringVar(&c.gitlab, "gitlab", "", "Scan a GitLab project (group[/subgroup]/project[#ref]) instead of a local directory; uses GITLAB_TOKEN if set")
+	fs.StringVar(&c.gitlabURL, "gitlab-url", gitlabURL, "URL of the GitLab instance of --gitlab, for self-hosted instances")
+	fs.StringVar(&c.bitbucket, "bitbucket", "", "Scan a Bitbucket Cloud repository (workspace/repo[#ref]) instead of a local directory; uses BITBUCKET_TOKEN if set")
+	fs.StringVar(&c.fromURLs, "from-urls", "", "Download the URLs listed in this file (one per line) and include them under urls/, named by their URL path")
+	fs.StringVar(&c.stdinName, "stdin-name", "stdin", "Name of the file read from stdin when the start path is -")
+
+	fs.StringVar(&c.skipReport, "skip-report", "", "Write a JSON list of skipped files and the reasons to this file")
+
+	fs.Var(&c.redactPatterns, "redact", "Add pattern of files whose values are redacted (default .env*, *.properties, secrets.yaml, secrets.yml)")
+	fs.BoolVar(&c.noRedact, "no-redact", false, "Do not redact values in configuration files")
+	fs.BoolVar(&c.noSanitize, "no-sanitize", false, "Keep invalid UTF-8, ANSI escape sequences and control characters in file contents")
+	fs.BoolVar(&c.keepCRLF, "keep-crlf", false, "Keep CRLF line endings in file contents instead of converting them to LF")
+	fs.BoolVar(&c.asciiTree, "ascii-tree", false, "Draw the directory tree with ASCII characters instead of Unicode box drawing")
+	fs.BoolVar(&c.treeStats, "tree-stats", false, "Annotate the directories of the tree with their number of files and lines, and the root with the share of each language")
+
+	fs.BoolVar(&c.gitignore, "gitignore", false, "Leave out files ignored by git (.gitignore, .git/info/exclude and the global excludes file)")
+	fs.BoolVar(&c.gitIndex, "git-index", false, "List the files from the git index instead of walking the working tree, leaving out untracked files; faster on large trees and network file systems")
+	fs.BoolVar(&c.noTree, "no-tree", false, "Leave out the directory structure, only write the file contents")
+	fs.StringVar(&c.order, "order", OrderPath, "Order of the file contents: path, or grouped to put tests right after the files they test")
+	fs.Var(&c.languages, "lang", "Set the language of files for Markdown fences and the token breakdown, as .EXT=LANGUAGE or NAME=LANGUAGE (comma separated or repeated)")
+	fs.Var(&c.focus, "focus", "Include the files of this directory in full and only an outline of the exported symbols of the others (can be repeated)")
+	fs.BoolVar(&c.docsFirst, "docs-first", false, "Put README*, ARCHITECTURE*, CONTRIBUTING* and docs/ before the other files")
+	fs.BoolVar(&c.toc, "toc", false, "Write a table of contents with the line every file starts at")
+	fs.StringVar(&c.fileHeader, "file-header", defaultDelimiters.FileHeader, "Template of the line naming every file, {path} is replaced by its path")
+	fs.StringVar(&c.separator, "separator", defaultDelimiters.Separator, "Line below the file header (empty to leave it out)")
+	fs.StringVar(&c.contentHeader, "content-header", defaultDelimiters.ContentHeader, "Template of the line above the content of every file, {path} is replaced by its path")
+	fs.BoolVar(&c.frontMatter, "front-matter", false, "Start the output with a YAML block recording the version, time, start paths, options, model and the number of files and tokens")
+	fs.BoolVar(&c.overview, "overview", false, "Start with a project overview: languages, build systems, frameworks and entrypoints detected from marker files")
+	fs.BoolVar(&c.codeMap, "code-map", false, "Write an index of the exported functions, types and classes of every file before the file contents")
+	fs.BoolVar(&c.dependencies, "dependencies", false, "List the direct dependencies declared in go.mod, package.json, pyproject.toml, Cargo.toml and other manifests before the tree")
+	fs.BoolVar(&c.dedup, "dedup", false, "Emit the content of identical files once and reference it from the other paths")
+	fs.BoolVar(&c.fileHashes, "file-hashes", false, "Add the SHA-256 of every file to its header and to its record in the JSON output")
+	fs.BoolVar(&c.fileGitInfo, "file-git-info", false, "Add the hash, author and date of the last commit of every file to its header and to its record in the JSON output")
+	fs.StringVar(&c.around, "around", "", "Only include the Go files reachable from this file or package directory through imports")
+	fs.IntVar(&c.hops, "hops", 1, "Number of import steps followed from --around")
+	fs.BoolVar(&c.workingDiff, "working-diff", false, "Append the uncommitted changes, staged and unstaged, as a diff against HEAD after the file contents")
+	fs.StringVar(&c.changed, "changed", "", "Only include the contents of files changed since this git ref, plus new untracked files (e.g. HEAD or main)")
+	fs.BoolVar(&c.withTests, "with-tests", false, "With --changed, also include the tests of the changed files, e.g. parser_test.go for parser.go")
+	fs.StringVar(&c.since, "since", "", "Only include the contents of files modified after this time: a date such as 2024-05-01 or a duration such as 7d, 2w or 36h")
+	fs.BoolVar(&c.sinceGit, "since-git", false, "With --since, select the files changed by the commits since then, plus uncommitted changes, instead of by modification time")
+
+	fs.StringVar(&c.ref, "ref", "", "Read files from this git ref (branch, tag or commit) instead of the working tree; works with bare repositories")
+
+	fs.BoolVar(&c.includeDotfiles, "include-dotfiles", false, "Include dotfiles and dotfolders (.git, .idea and other default exclusions still apply)")
+	fs.BoolVar(&c.includeVendored, "include-vendored", false, "Include vendored and third-party code, dependency directories and build output (vendor, node_modules, dist, *.min.js, ...)")
+	fs.BoolVar(&c.noGitAttrs, "no-gitattributes", false, "Include the paths marked linguist-generated or export-ignore in .gitattributes, which are excluded by default")
+	fs.BoolVar(&c.noDotDefaults, "no-default-dotfiles", false, "Do not include .github/workflows, .gitlab-ci.yml, .golangci.yml, .dockerignore, .editorconfig and .env.example by default")
+	fs.Var(&c.includes, "include", "Include hidden paths matching this pattern, ** matches any directories (e.g. .github/workflows/**)")
+
+	fs.BoolVar(&c.binaryMetadata, "binary-metadata", false, "Describe binary files (size, MIME type, image dimensions, first bytes) instead of only skipping them")
+	fs.BoolVar(&c.noPlaceholders, "no-skip-placeholders", false, "Leave out the stanzas of skipped files, such as (Binary - skipped content), instead of writing a placeholder")
+	fs.Var(&c.allow, "allow", "Include files matching this pattern, or with this sha256:HEX fingerprint, even if they contain a private key or look binary, as in .llmallow (can be repeated)")
+	fs.Var(&c.forceText, "force-text", "Include the content of files matching this pattern even if they look binary, ** matches any directories (can be repeated)")
+	fs.BoolVar(&c.images, "images", false, "Include images with their format and dimensions, and SVG images with their text instead of their markup")
+	fs.BoolVar(&c.describeImages, "describe-images", false, "Like --images, with a one-line description of every raster image by the model of --summarize-provider and --summarize-model")
+	fs.BoolVar(&c.dataSchemas, "data-schemas", false, "Include the schema and row counts of SQLite databases and Parquet files instead of skipping them")
+
+	fs.BoolVar(&c.condenseLocks, "condense-lockfiles", false, "Replace dependency lockfiles (package-lock.json, yarn.lock, Cargo.lock, ...) by a list of package names and versions")
+	fs.Var(&c.policies, "policy", "Action for files found by a category, as CATEGORY=ACTION (secrets, binaries, oversized, pii; skip, redact, warn, fail) or an ACTION for all, comma separated or repeated")
+	fs.BoolVar(&c.maskPII, "mask-pii", false, "Replace email addresses, phone numbers, IP addresses and national IDs by placeholders such as <EMAIL_1> (--policy pii=redact)")
+	fs.Int64Var(&c.maxFileSize, "max-file-size", 0, "Treat files larger than N bytes as oversized, see --policy (0 = unlimited)")
+	fs.IntVar(&c.skipOverTokens, "skip-over-tokens", 0, "Leave out the content of files with more than N tokens and list them in the skip summary (0 = unlimited)")
+	fs.Int64Var(&c.maxBytes, "max-bytes", 0, "Stop writing file contents before the output grows over N bytes and list the files left out in the skip summary (0 = unlimited)")
+	fs.IntVar(&c.maxLineLength, "max-line-length", 0, "Truncate lines longer than N characters, with a marker, e.g. to neutralize embedded base64 data (0 = unlimited)")
+	fs.IntVar(&c.sampleData, "sample-data", 0, "Only include the header and the first N rows of CSV and TSV files")
+
+	fs.IntVar(&c.summarizeOver, "summarize-over", 0, "Replace files with more than N tokens by a summary written by an LLM")
+	fs.StringVar(&c.summarizeWith, "summarize-provider", "openai", "LLM provider writing the summaries (openai, anthropic or gemini)")
+	fs.StringVar(&c.summarizeModel, "summarize-model", "", "Model writing the summaries (default is a small model of the provider)")
+
+	fs.Var(&c.symbols, "symbol", "Only include the declaration of this Go symbol, e.g. pkg/server.Handler or pkg/server.Server.Start (can be repeated)")
+	fs.Var(&c.execFilters, "exec-filter", "Pipe every file through this shell command, its output replaces the content (path in $GIT2LLM_PATH, no output drops the file)")
+
+	fs.StringVar(&c.pathPrefix, "path-prefix", "", "Prefix all emitted paths with this directory, e.g. backend (with several start paths, it is put before their names)")
+	fs.StringVar(&c.rootLabel, "root-label", "", "Label the root of the directory tree with this name instead of /, or with the name of the start directory if the label is .")
+
+	fs.StringVar(&c.profile, "profile", "", "Apply the flags of this profile from the config file (built in: review, onboarding)")
+	fs.StringVar(&c.configFile, "config", "", "Read defaults and profiles from this file instead of ./.git2llm or the user config directory")
+
+	fs.BoolVar(&c.help, "h", false, "Display this help message")
+	fs.BoolVar(&c.help, "help", false, "Display this help message")
+}
+
+// logger returns the logger configured by the logging flags.
+func (c *cliConfig) logger() *slog.Logger {
+	if c.log != nil {
+		return c.log
+	}
+	level := slog.LevelInfo
+	switch {
+	case c.debug:
+		level = levelTrace
+	case c.verbose:
+		level = slog.LevelDebug
+	case c.quiet:
+		level = slog.LevelWarn
+	}
+	c.log = newLogger(os.Stderr, level, c.logJSON)
+	return c.log
+}
+
+// newRoots validates the flags and creates a Git2LLM instance for every start path in args.
+func (c *cliConfig) newRoots(args []string, w io.Writer) ([]*Git2LLM, error) {
+	var fsys FS
+	var startPaths, fileTypes []string
+	args, scopeArgs := cutScope(args)
+	scope, err := cleanScope(scopeArgs)
+	if err != nil {
+		return nil, err
+	}
+	if c.offline {
+		switch {
+		case c.remote() != "":
+			return nil, fmt.Errorf("%s can't be used with --offline", c.remote())
+		case c.fromURLs != "":
+			return nil, fmt.Errorf("--from-urls can't be used with --offline")
+		case c.summarizeOver > 0 || c.describeImages:
+			return nil, fmt.Errorf("--summarize-over and --describe-images can't be used with --offline")
+		}
+	}
+	if remote := c.remote(); remote != "" {
+		if c.github != "" && c.gitlab != "" || c.github != "" && c.bitbucket != "" || c.gitlab != "" && c.bitbucket != "" {
+			return nil, fmt.Errorf("only one of --github, --gitlab and --bitbucket can be given")
+		}
+		var err error
+		switch remote {
+		case "--github":
+			fsys, err = newGitHubFS(c.github, os.Getenv("GITHUB_TOKEN"), c.logger())
+		case "--gitlab":
+			fsys, err = newGitLabFS(c.gitlab, c.gitlabURL, os.Getenv("GITLAB_TOKEN"))
+		case "--bitbucket":
+			fsys, err = newBitbucketFS(c.bitbucket, os.Getenv("BITBUCKET_TOKEN"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		// All arguments are file types when scanning a remote repository
+		startPaths, fileTypes = []string{"."}, args
+		c.noCache = true // Remote files have no modification time to validate cache entries
+	} else if len(args) > 0 {
+		startPaths, fileTypes = splitArgs(args)
+		for _, fileType := range fileTypes {
+			if info, err := os.Stat(fileType); err == nil && (info.IsDir() || isZipArchive(fileType)) {
+				c.logger().Warn("Argument is used as a file type, write it with a path separator to scan it as a start path", "argument", fileType, "start_path", "./"+fileType)
+			}
+		}
+	} else if c.fromURLs == "" {
+		return nil, fmt.Errorf("missing start path")
+	}
+	if len(fileTypes) == 0 {
+		fileTypes = c.profileTypes
+	}
+
+	// The downloaded files are scanned as an additional root
+	var urlFS *treeFS
+	if c.fromURLs != "" {
+		if len(startPaths) > 0 && startPaths[0] == stdinArg {
+			return nil, fmt.Errorf("--from-urls can't be combined with reading from stdin")
+		}
+		urls, err := readURLList(c.fromURLs)
+		if err != nil {
+			return nil, err
+		}
+		if urlFS, err = newURLFS(urls, &http.Client{Timeout: urlTimeout}, urlMaxSize, c.logger()); err != nil {
+			return nil, err
+		}
+		startPaths = append(startPaths, urlRootName)
+		c.noCache = true // Downloaded files have no modification time to validate cache entries
+	}
+
+	if startPaths[0] == stdinArg {
+		if len(startPaths) > 1 || c.remote() != "" || c.ref != "" || c.changed != "" || c.since != "" || c.gitignore || c.gitIndex || c.workingDiff || c.fileGitInfo {
+			return nil, fmt.Errorf("reading from stdin can't be combined with other start paths, a remote repository, --ref, --changed, --since, --gitignore, --git-index, --working-diff or --file-git-info")
+		}
+		stdinFS, err := newStdinFS(c.stdinName, os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		fsys, startPaths = stdinFS, []string{"."}
+		// The name may be a dotfile such as .env
+		name, _ := stdinPath(c.stdinName)
+		c.includes = append(c.includes, name)
+		c.noCache = true // Piped content has no modification time to validate cache entries
+	}
+
+	if c.ref != "" {
+		if remote := c.remote(); remote != "" {
+			return nil, fmt.Errorf("--ref can't be combined with %s, add the ref to the repository as in owner/repo#ref", remote)
+		}
+		if c.changed != "" {
+			return nil, fmt.Errorf("--ref can't be combined with --changed")
+		}
+		if c.workingDiff {
+			return nil, fmt.Errorf("--ref can't be combined with --working-diff")
+		}
+		if c.gitIndex {
+			return nil, fmt.Errorf("--ref can't be combined with --git-index, it lists the files from git already")
+		}
+		c.noCache = true // Files read from git objects have no modification time to validate cache entries
+	}
+
+	if c.around != "" && c.changed != "" {
+		return nil, fmt.Errorf("--around can't be combined with --changed")
+	}
+	var since time.Time
+	if c.since != "" {
+		var err error
+		if since, err = parseSince(c.since, time.Now()); err != nil {
+			return nil, err
+		}
+		if remote := c.remote(); remote != "" {
+			return nil, fmt.Errorf("--since can't be combined with %s", remote)
+		}
+		if c.ref != "" && !c.sinceGit {
+			return nil, fmt.Errorf("--since with --ref requires --since-git, files read from git have no modification time")
+		}
+		if c.sinceGit && (c.changed != "" || c.around != "") {
+			return nil, fmt.Errorf("--since-git can't be combined with --changed or --around")
+		}
+	} else if c.sinceGit {
+		return nil, fmt.Errorf("--since-git requires --since")
+	}
+	if c.onlyTests && c.excludeTests {
+		return nil, fmt.Errorf("--only-tests can't be combined with -t")
+	}
+	if c.withTested && !c.onlyTests {
+		return nil, fmt.Errorf("--with-tested requires --only-tests")
+	}
+	if c.withTests && c.changed == "" {
+		return nil, fmt.Errorf("--with-tests requires --changed")
+	}
+	if c.withTests && c.excludeTests {
+		return nil, fmt.Errorf("--with-tests can't be combined with -t")
+	}
+	if c.noDefaultExcl && c.defaultExclFile != "" {
+		return nil, fmt.Errorf("--no-default-excludes can't be combined with --default-excludes-file")
+	}
+	if c.hops < 0 {
+		return nil, fmt.Errorf("invalid --hops %d: must be zero or positive", c.hops)
+	}
+
+	delimiters := Delimiters{FileHeader: c.fileHeader, Separator: c.separator, ContentHeader: c.contentHeader}
+	if delimiters == (Delimiters{}) {
+		delimiters = defaultDelimiters // Flags not registered
+	}
+	if err := delimiters.check(); err != nil {
+		return nil, err
+	}
+
+	if c.order != "" && c.order != OrderPath && c.order != OrderGrouped {
+		return nil, fmt.Errorf("invalid --order %s: must be %s or %s", c.order, OrderPath, OrderGrouped)
+	}
+
+	if c.maxLineLength < 0 {
+		return nil, fmt.Errorf("invalid --max-line-length %d: must be zero or positive", c.maxLineLength)
+	}
+	if c.maxFileSize < 0 {
+		return nil, fmt.Errorf("invalid --max-file-size %d: must be zero or positive", c.maxFileSize)
+	}
+	if c.skipOverTokens < 0 {
+		return nil, fmt.Errorf("invalid --skip-over-tokens %d: must be zero or positive", c.skipOverTokens)
+	}
+	if c.maxBytes < 0 {
+		return nil, fmt.Errorf("invalid --max-bytes %d: must be zero or positive", c.maxBytes)
+	}
+	policy, err := parsePolicy(c.policies)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := policy[CategoryPII]; c.maskPII && !ok {
+		policy[CategoryPII] = PolicyRedact
+	}
+	testPatterns, err := parseTestPatternFlags(c.testPatterns)
+	if err != nil {
+		return nil, err
+	}
+	var testLanguages []string
+	for _, language := range strings.Split(c.testLanguages, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			testLanguages = append(testLanguages, language)
+		}
+	}
+	languages, err := parseLanguages(c.languages)
+	if err != nil {
+		return nil, err
+	}
+	focus, err := cleanFocus(c.focus)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.maxDepth < 0 {
+		return nil, fmt.Errorf("invalid -max-depth %d: must be zero or positive", c.maxDepth)
+	}
+
+	logger := c.logger()
+	logger.Debug("Version", "version", embeddedVersion)
+	if fileTypes != nil {
+		logger.Debug("Scanning for file types", "types", fileTypes)
+	} else {
+		logger.Debug("No file types specified. Scanning all files.")
+	}
+
+	opts := []Option{
+		WithLogger(logger),
+		WithMaxDepth(c.maxDepth),
+		WithIgnoreFile(c.ignoreFile),
+		WithDefaultExcludes(!c.noDefaultExcl),
+		WithDefaultExcludesFile(c.defaultExclFile),
+		WithBinaryMetadata(c.binaryMetadata),
+		WithSkipPlaceholders(!c.noPlaceholders),
+		WithDataSchemas(c.dataSchemas),
+		WithForceText(c.forceText...),
+		WithAllow(c.allow...),
+		WithDedup(c.dedup),
+		WithOnlyTests(c.onlyTests, c.withTested),
+		WithTestPatterns(testPatterns),
+		WithTestLanguages(testLanguages...),
+		WithFileHashes(c.fileHashes),
+		WithTableOfContents(c.toc),
+		WithOverview(c.overview),
+		WithCodeMap(c.codeMap),
+		WithDependencies(c.dependencies),
+		WithDotfiles(c.includeDotfiles),
+		WithDotfileIncludes(c.includes...),
+		WithDefaultDotfiles(!c.noDotDefaults),
+		WithVendored(c.includeVendored),
+		WithGitAttributes(!c.noGitAttrs),
+		WithTree(!c.noTree),
+		WithOrder(c.order),
+		WithDocsFirst(c.docsFirst),
+		WithScope(scope...),
+		WithLanguages(languages),
+		withEmitters(c.emitters...),
+		WithMaxLineLength(c.maxLineLength),
+		WithPolicy(policy),
+		WithMaxFileSize(c.maxFileSize),
+		WithMaxFileTokens(c.skipOverTokens),
+		WithMaxBytes(c.maxBytes),
+		WithOffline(c.offline),
+		WithDelimiters(delimiters),
+		WithSanitize(!c.noSanitize),
+		WithNormalizeNewlines(!c.keepCRLF),
+		WithASCIITree(c.asciiTree),
+		WithTreeStats(c.treeStats),
+	}
+	if c.frontMatter {
+		opts = append(opts, WithFrontMatter(c.options...))
+	}
+	switch {
+	case c.noRedact:
+		opts = append(opts, WithRedactPatterns(nil))
+	case len(c.redactPatterns) > 0:
+		opts = append(opts, WithRedactPatterns(append(append([]string{}, defaultRedactPatterns...), c.redactPatterns...)))
+	}
+	if len(c.symbols) > 0 {
+		extractor, err := newSymbolExtractor(c.symbols)
+		if err != nil {
+			return nil, err
+		}
+		c.symbolExtractor = extractor
+		opts = append(opts, WithTransformers(extractor))
+	}
+	if c.condenseLocks {
+		opts = append(opts, WithTransformers(lockfileCondenser{}))
+	}
+	if c.sampleData > 0 {
+		opts = append(opts, WithSampleData(c.sampleData))
+	}
+	for _, command := range c.execFilters {
+		opts = append(opts, WithTransformers(newExecFilter(command)))
+	}
+	if c.images || c.describeImages {
+		var describer imageStreamer
+		if c.describeImages {
+			client, err := c.summarizeClient()
+			if err != nil {
+				return nil, fmt.Errorf("error creating image describer: %w", err)
+			}
+			describer = client
+		}
+		opts = append(opts, WithImages(true, describer))
+	}
+	if c.summarizeOver > 0 {
+		client, err := c.summarizeClient()
+		if err != nil {
+			return nil, fmt.Errorf("error creating summarizer: %w", err)
+		}
+		counter, err := tokens.New(c.model)
+		if err != nil {
+			return nil, fmt.Errorf("tokens.New(): %w", err)
+		}
+		opts = append(opts, WithTransformers(newSummarizer(client, *counter, c.summarizeOver)))
+	}
+	// Verbose output already reports every file, so only show progress without it
+	if !c.noProgress && !c.verbose && !c.debug && !c.quiet && !c.logJSON && isTerminal(os.Stderr) {
+		opts = append(opts, WithProgress(os.Stderr))
+	}
+	for _, startPath := range startPaths {
+		if isZipArchive(startPath) {
+			c.noCache = true // Paths in an archive are relative, so they would share cache entries with the working directory
+		}
+	}
+	if c.countTokens && !c.noCache {
+		if cachePath, err := defaultTokenCachePath(); err == nil {
+			opts = append(opts, withTokenCache(loadTokenCache(cachePath)))
+		} else {
+			logger.Debug("Token cache disabled", "error", err)
+		}
+	}
+
+	// With several roots, emitted paths are prefixed with the name of their root
+	prefixes := make([]string, len(startPaths))
+	if len(startPaths) > 1 {
+		var err error
+		prefixes, err = rootPrefixes(startPaths)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.pathPrefix != "" {
+		for i, prefix := range prefixes {
+			prefixes[i] = path.Join(filepath.ToSlash(c.pathPrefix), prefix)
+		}
+	}
+	if c.rootLabel != "" && c.rootLabel != "." && len(startPaths) > 1 {
+		return nil, fmt.Errorf("--root-label %s can't be used with several start paths, use . to label every root with its name", c.rootLabel)
+	}
+
+	// Create a Git2LLM instance per root
+	roots := make([]*Git2LLM, 0, len(startPaths))
+	aroundFound := false
+	for i, startPath := range startPaths {
+		rootOpts := []Option{WithPathPrefix(prefixes[i])}
+		if len(focus) > 0 {
+			// Transformers see the paths as shown, so every root needs its own prefix
+			rootOpts = append(rootOpts, WithTransformers(newFocusOutliner(focus, prefixes[i], languages)))
+		}
+		rootOpts = append(rootOpts, opts...)
+		if label := c.rootLabel; label != "" {
+			if label == "." {
+				label = c.rootName(startPath)
+			}
+			rootOpts = append(rootOpts, WithRootLabel(label))
+		}
+		rootFS, rootPath := fsys, startPath
+		// Git options only apply to local roots
+		local := true
+		if urlFS != nil && i == len(startPaths)-1 {
+			rootFS, rootPath, local = urlFS, ".", false
+		}
+		if local && isZipArchive(startPath) {
+			zipFS, err := newZipFS(startPath)
+			if err != nil {
+				return nil, err
+			}
+			rootFS, rootPath, local = zipFS, ".", false
+		}
+		if c.ref != "" && local {
+			refFS, err := newGitRefFS(startPath, c.ref)
+			if err != nil {
+				return nil, err
+			}
+			rootFS, rootPath = refFS, "."
+		}
+		if c.gitIndex && local {
+			if remote := c.remote(); remote != "" {
+				return nil, fmt.Errorf("--git-index can't be combined with %s", remote)
+			}
+			indexFS, err := newGitIndexFS(startPath)
+			if err != nil {
+				return nil, err
+			}
+			rootFS = indexFS
+		}
+		if c.changed != "" && local {
+			if remote := c.remote(); remote != "" {
+				return nil, fmt.Errorf("--changed can't be combined with %s", remote)
+			}
+			changed, err := changedFiles(startPath, c.changed)
+			if err != nil {
+				return nil, fmt.Errorf("error listing changed files: %w", err)
+			}
+			if c.withTests {
+				changed = withTests(startPath, changed)
+			}
+			logger.Debug("Changed files", "ref", c.changed, "path", startPath, "files", len(changed))
+			rootOpts = append(rootOpts, WithOnlyPaths(changed))
+		}
+		switch {
+		case c.since == "":
+		case c.sinceGit && local:
+			files, err := filesCommittedSince(startPath, c.ref, since)
+			if err != nil {
+				return nil, fmt.Errorf("error listing files changed since %s: %w", c.since, err)
+			}
+			logger.Debug("Files changed since", "since", since, "path", startPath, "files", len(files))
+			rootOpts = append(rootOpts, WithOnlyPaths(files))
+		default:
+			rootOpts = append(rootOpts, WithSince(since))
+		}
+		if c.around != "" && local {
+			var aroundFS FS = OSFS{}
+			if rootFS != nil {
+				aroundFS = rootFS
+			}
+			files, err := aroundFiles(aroundFS, rootPath, c.around, c.hops)
+			// With several roots, the entry only has to be in one of them
+			if err != nil && len(startPaths) == 1 {
+				return nil, err
+			}
+			aroundFound = aroundFound || err == nil
+			logger.Debug("Files around entry", "entry", c.around, "path", startPath, "files", len(files))
+			rootOpts = append(rootOpts, WithOnlyPaths(files))
+		}
+		// Files read from a ref are tracked, so nothing there is ignored
+		if c.gitignore && c.ref == "" && local {
+			if remote := c.remote(); remote != "" {
+				return nil, fmt.Errorf("--gitignore can't be combined with %s", remote)
+			}
+			ignored, err := gitIgnoredFiles(startPath)
+			if err != nil {
+				return nil, fmt.Errorf("error listing files ignored by git: %w", err)
+			}
+			logger.Debug("Files ignored by git", "path", startPath, "paths", len(ignored))
+			rootOpts = append(rootOpts, WithGitIgnored(ignored))
+		}
+		if c.workingDiff && local {
+			if remote := c.remote(); remote != "" {
+				return nil, fmt.Errorf("--working-diff can't be combined with %s", remote)
+			}
+			rootOpts = append(rootOpts, WithWorkingDiff(true))
+		}
+		if c.fileGitInfo && local {
+			if remote := c.remote(); remote != "" {
+				return nil, fmt.Errorf("--file-git-info can't be combined with %s", remote)
+			}
+			commits, err := lastCommits(startPath, c.ref)
+			if err != nil {
+				return nil, fmt.Errorf("error reading the git history: %w", err)
+			}
+			logger.Debug("Last commits", "path", startPath, "files", len(commits))
+			rootOpts = append(rootOpts, WithFileGitInfo(commits))
+		}
+		git2llm, err := NewGit2LLM(rootPath, fileTypes, rootFS, w, c.verbose || c.debug, c.excludeTests, c.countTokens, c.excludePatterns, c.model, c.noRecurse, rootOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing git2llm: %w", err)
+		}
+		roots = append(roots, git2llm)
+	}
+
+	if c.around != "" && !aroundFound {
+		return nil, fmt.Errorf("--around %s is not in any start path", c.around)
+	}
+	for _, p := range scope {
+		if !existsInRoots(roots, p) {
+			return nil, fmt.Errorf("path %s after -- is not in any start path", p)
+		}
+	}
+	for _, p := range focus {
+		if !existsInRoots(roots, p) {
+			return nil, fmt.Errorf("--focus %s is not in any start path", p)
+		}
+	}
+
+	// Add patterns from -e flags
+	if len(c.excludePatterns) > 0 {
+		logger.Debug("Added custom exclusion patterns", "patterns", len(c.excludePatterns))
+	}
+
+	if !c.excludeTests {
+		logger.Debug("Including all files.")
+	}
+	return roots, nil
+}
+
+// existsInRoots reports whether relPath, slash separated, exists in one of the start paths.
+func existsInRoots(roots []*Git2LLM, relPath string) bool {
+	for _, g := range roots {
+		if _, err := g.fs.Stat(filepath.Join(g.startPath, filepath.FromSlash(relPath))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func printUsage() {
+	fmt.Printf("Usage: %s [options] <start_path> [start_path...] [file_extensions...] [-- path...]\n", os.Args[0])
+	fmt.Printf("       %s ask [options] --question <question> <start_path> [start_path...] [file_extensions...]\n", os.Args[0])
+	fmt.Printf("       %s apply [--dry-run] [--dir DIR] [response_file]\n", os.Args[0])
+	fmt.Printf("       %s explain [options] <path> [path...]\n", os.Args[0])
+	fmt.Printf("       %s count [options] [start_path...] [file_extensions...]\n", os.Args[0])
+	fmt.Printf("       %s version\n\n", os.Args[0])
+	fmt.Println("Options:")
+	flag.PrintDefaults()
+	fmt.Println("\nArguments:")
+	fmt.Println("  start_path             Path to the directory to scan; further ones need a path separator, e.g. ./docs")
+	fmt.Println("  file_extensions        Optional file extensions to include (e.g., .go .js)")
+	fmt.Println("  path                   Only scan these files and directories, relative to the start path")
+	fmt.Println("\nEvery option can also be set by an environment variable, e.g. GIT2LLM_MAX_DEPTH or GIT2LLM_MODEL for -m.")
+	fmt.Println("The git options (--ref, --changed, --gitignore, --git-index, --file-git-info, --since-git, --working-diff)")
+	fmt.Println("run the git command, which must be installed.")
+}
+
+// summarizeClient returns a client for the model of --summarize-provider and
+// --summarize-model, by default a small model of the provider.
+func (c *cliConfig) summarizeClient() (*llm.Client, error) {
+	model := c.summarizeModel
+	if model == "" {
+		model = llm.SmallModel(c.summarizeWith)
+	}
+	return llm.New(c.summarizeWith, model)
+}
+
+// splitArgs separates the positional arguments into start paths and file types.
+// The first argument is always a start path; later arguments are start paths
+// if they contain a path separator, such as ./docs or ../api.zip, and file
+// types otherwise. What exists in the working directory doesn't matter, so
+// "git2llm . .go build" means the same wherever it is run.
+func splitArgs(args []string) (startPaths []string, fileTypes []string) {
+	for i, arg := range args {
+		if i == 0 || strings.ContainsRune(arg, '/') || strings.ContainsRune(arg, filepath.Separator) {
+			startPaths = append(startPaths, arg)
+			continue
+		}
+		fileTypes = append(fileTypes, arg)
+	}
+	return startPaths, fileTypes
+}
+
+// remote returns the flag of the remote repository scanned instead of local
+// directories, e.g. "--github", or "" if there is none.
+func (c *cliConfig) remote() string {
+	switch {
+	case c.github != "":
+		return "--github"
+	case c.gitlab != "":
+		return "--gitlab"
+	case c.bitbucket != "":
+		return "--bitbucket"
+	}
+	return ""
+}
+
+// rootName returns the name of the directory or repository scanned from startPath.
+func (c *cliConfig) rootName(startPath string) string {
+	if spec := c.github + c.gitlab + c.bitbucket; spec != "" {
+		spec, _, _ = strings.Cut(spec, "#")
+		return path.Base(spec)
+	}
+	abs, err := filepath.Abs(startPath)
+	if err != nil {
+		return filepath.Base(startPath)
+	}
+	return filepath.Base(abs)
+}
+
+// rootPrefixes returns the path prefix used for each start path when scanning
+// several roots: the name of the directory, which must be unique.
+func rootPrefixes(startPaths []string) ([]string, error) {
+	prefixes := make([]string, len(startPaths))
+	seen := make(map[string]string)
+	for i, startPath := range startPaths {
+		abs, err := filepath.Abs(startPath)
+		if err != nil {
+			return nil, fmt.Errorf("filepath.Abs: %w", err)
+		}
+		name := filepath.Base(abs)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("start paths %s and %s have the same name %q", other, startPath, name)
+		}
+		seen[name] = startPath
+		prefixes[i] = name
+	}
+	return prefixes, nil
+}
+
+// warnMissingSymbols logs the symbols given with --symbol that no file declares.
+func (c *cliConfig) warnMissingSymbols() {
+	if c.symbolExtractor == nil {
+		return
+	}
+	for _, spec := range c.symbolExtractor.missing() {
+		c.logger().Warn("Symbol not found", "symbol", spec)
+	}
+}
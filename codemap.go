@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithCodeMap emits a code map before the file contents: an index listing the
+// exported symbols of every included file, such as the signatures of the
+// exported functions and types of a Go file, so a file can be looked up by
+// the symbols it declares. Go files are parsed; other languages are matched
+// by the patterns of the outlines of --focus. Files without any are left out.
+func WithCodeMap(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.codeMap = enabled
+	}
+}
+
+// writeCodeMap writes the code map of all roots to w. Nothing is written if no
+// file has exported symbols.
+func writeCodeMap(w io.Writer, roots []*Git2LLM) error {
+	var out strings.Builder
+	for _, g := range roots {
+		files, err := g.contentFiles()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			content, err := g.fs.ReadFile(f.path)
+			if err != nil {
+				continue // Reported when the content is written
+			}
+			lines := outline(f.relPath, content, g.languages)
+			if len(lines) == 0 {
+				continue
+			}
+			out.WriteString(g.displayPath(f.relPath) + "\n")
+			for _, line := range lines {
+				out.WriteString("  " + strings.TrimSpace(line) + "\n")
+			}
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "\n\nCode Map:\n---------\n%s", out.String()); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGit2LLMCodeMap(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		"store/store.go": {Data: []byte("package store\n\ntype Store struct{}\n\nfunc New() *Store { return nil }\n\nfunc helper() {}\n")},
+		"tools/gen.py":   {Data: []byte("class Gen:\n    def run(self):\n        pass\n")},
+		"README.md":      {Data: []byte("# Demo\n")},
+	}}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false, WithCodeMap(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	expected := "Code Map:\n---------\n" +
+		"store/store.go\n  package store\n  type Store struct\n  func New() *Store\n" +
+		"tools/gen.py\n  class Gen\n  def run(self)\n" +
+		"\n\nFile Contents:"
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected %q in output. Result:\n%s", expected, result)
+	}
+	if strings.Index(result, "Directory Structure:") > strings.Index(result, "Code Map:") {
+		t.Errorf("Expected the code map after the tree. Result:\n%s", result)
+	}
+}
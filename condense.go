@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// lockfileParsers extract name and version pairs from dependency lockfiles, by file name.
+var lockfileParsers = map[string]func(content []byte) ([][2]string, error){
+	"package-lock.json":   parseNpmLock,
+	"npm-shrinkwrap.json": parseNpmLock,
+	"composer.lock":       parseComposerLock,
+	"yarn.lock":           parseYarnLock,
+	"pnpm-lock.yaml":      parsePnpmLock,
+	"Cargo.lock":          parseTomlPackages,
+	"poetry.lock":         parseTomlPackages,
+	"uv.lock":             parseTomlPackages,
+	"Gemfile.lock":        parseGemfileLock,
+}
+
+// lockfileCondenser is a Transformer replacing dependency lockfiles with a sorted
+// list of the packages and versions they pin. It only applies to lockfiles, and
+// lockfiles that can't be parsed pass unchanged.
+type lockfileCondenser struct{}
+
+func (lockfileCondenser) appliesTo(filePath string) bool {
+	_, ok := lockfileParsers[path.Base(filePath)]
+	return ok
+}
+
+func (lockfileCondenser) Transform(filePath string, content []byte) ([]byte, bool, error) {
+	parse, ok := lockfileParsers[path.Base(filePath)]
+	if !ok {
+		return content, true, nil
+	}
+	packages, err := parse(content)
+	if err != nil || len(packages) == 0 {
+		return content, true, nil
+	}
+	seen := make(map[[2]string]bool)
+	lines := make([]string, 0, len(packages))
+	for _, p := range packages {
+		if !seen[p] {
+			seen[p] = true
+			lines = append(lines, p[0]+" "+p[1])
+		}
+	}
+	sort.Strings(lines)
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[Condensed lockfile: %d packages as name and version; the original content is not included]\n", len(lines))
+	for _, line := range lines {
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes(), true, nil
+}
+
+func parseNpmLock(content []byte) ([][2]string, error) {
+	type dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]dependency      `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	var packages [][2]string
+	// Lockfile version 2 and 3 list every installed package by its node_modules path
+	for key, dep := range lock.Packages {
+		i := strings.LastIndex(key, "node_modules/")
+		if i < 0 || dep.Version == "" {
+			continue // The root package
+		}
+		packages = append(packages, [2]string{key[i+len("node_modules/"):], dep.Version})
+	}
+	if len(packages) > 0 {
+		return packages, nil
+	}
+	// Version 1 nests dependencies
+	var walk func(deps map[string]json.RawMessage)
+	walk = func(deps map[string]json.RawMessage) {
+		for name, raw := range deps {
+			var dep dependency
+			if json.Unmarshal(raw, &dep) != nil {
+				continue
+			}
+			if dep.Version != "" {
+				packages = append(packages, [2]string{name, dep.Version})
+			}
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return packages, nil
+}
+
+func parseComposerLock(content []byte) ([][2]string, error) {
+	type pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var lock struct {
+		Packages    []pkg `json:"packages"`
+		PackagesDev []pkg `json:"packages-dev"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	var packages [][2]string
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		packages = append(packages, [2]string{p.Name, p.Version})
+	}
+	return packages, nil
+}
+
+// yarnVersion matches the version line of a yarn.lock entry, in the classic and the berry format.
+var yarnVersion = regexp.MustCompile(`^\s+version:?\s+"?([^"\s]+)"?`)
+
+func parseYarnLock(content []byte) ([][2]string, error) {
+	var packages [][2]string
+	var name string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			// An entry such as `"@babel/core@^7.0.0", "@babel/core@^7.1.0":`
+			spec := strings.Trim(strings.SplitN(line, ",", 2)[0], `":`)
+			if i := strings.LastIndex(spec, "@"); i > 0 {
+				name = spec[:i]
+			} else {
+				name = spec
+			}
+			continue
+		}
+		if m := yarnVersion.FindStringSubmatch(line); m != nil && name != "" {
+			packages = append(packages, [2]string{name, m[1]})
+			name = ""
+		}
+	}
+	return packages, scanner.Err()
+}
+
+// pnpmPackage matches a package key in pnpm-lock.yaml: "  /name@1.0.0:", "  name@1.0.0:" or "  /name/1.0.0:".
+var pnpmPackage = regexp.MustCompile(`^  '?/?((?:@[^/@]+/)?[^/@(]+)[@/]([^:'(/]+)`)
+
+func parsePnpmLock(content []byte) ([][2]string, error) {
+	var packages [][2]string
+	inPackages := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") && line != "" {
+			inPackages = line == "packages:"
+			continue
+		}
+		if !inPackages || strings.HasPrefix(line, "   ") {
+			continue
+		}
+		if m := pnpmPackage.FindStringSubmatch(line); m != nil {
+			packages = append(packages, [2]string{m[1], m[2]})
+		}
+	}
+	return packages, scanner.Err()
+}
+
+// parseTomlPackages reads the [[package]] tables of Cargo.lock, poetry.lock and uv.lock.
+func parseTomlPackages(content []byte) ([][2]string, error) {
+	var packages [][2]string
+	var name, version string
+	inPackage := false
+	flush := func() {
+		if inPackage && name != "" && version != "" {
+			packages = append(packages, [2]string{name, version})
+		}
+		name, version = "", ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			flush()
+			inPackage = line == "[[package]]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !inPackage {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "name":
+			name = strings.Trim(strings.TrimSpace(value), `"`)
+		case "version":
+			version = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// gemSpec matches a gem in the specs of Gemfile.lock: "    rails (7.1.0)".
+var gemSpec = regexp.MustCompile(`^    ([^\s(]+) \(([^)]+)\)$`)
+
+func parseGemfileLock(content []byte) ([][2]string, error) {
+	var packages [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if m := gemSpec.FindStringSubmatch(scanner.Text()); m != nil {
+			packages = append(packages, [2]string{m[1], m[2]})
+		}
+	}
+	return packages, scanner.Err()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLockfileCondenser(t *testing.T) {
+	testCases := []struct {
+		path     string
+		content  string
+		expected []string
+	}{
+		{
+			path: "web/package-lock.json",
+			content: `{"lockfileVersion": 3, "packages": {
+				"": {"name": "web", "version": "1.0.0"},
+				"node_modules/react": {"version": "18.2.0"},
+				"node_modules/@babel/core": {"version": "7.23.0"},
+				"node_modules/a/node_modules/react": {"version": "17.0.2"}
+			}}`,
+			expected: []string{"@babel/core 7.23.0", "react 17.0.2", "react 18.2.0"},
+		},
+		{
+			path:     "package-lock.json",
+			content:  `{"lockfileVersion": 1, "dependencies": {"left-pad": {"version": "1.3.0", "dependencies": {"pad": {"version": "0.1.0"}}}}}`,
+			expected: []string{"left-pad 1.3.0", "pad 0.1.0"},
+		},
+		{
+			path: "yarn.lock",
+			content: `# yarn lockfile v1
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.23.0"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.23.0.tgz"
+
+lodash@^4.17.21:
+  version "4.17.21"
+`,
+			expected: []string{"@babel/core 7.23.0", "lodash 4.17.21"},
+		},
+		{
+			path: "pnpm-lock.yaml",
+			content: `lockfileVersion: '6.0'
+
+packages:
+
+  /react@18.2.0:
+    resolution: {integrity: sha512-x}
+    dependencies:
+      loose-envify: 1.4.0
+
+  /@types/node@20.8.0:
+    resolution: {integrity: sha512-y}
+`,
+			expected: []string{"@types/node 20.8.0", "react 18.2.0"},
+		},
+		{
+			path: "Cargo.lock",
+			content: `version = 3
+
+[[package]]
+name = "serde"
+version = "1.0.188"
+dependencies = [
+ "serde_derive",
+]
+
+[[package]]
+name = "libc"
+version = "0.2.148"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`,
+			expected: []string{"libc 0.2.148", "serde 1.0.188"},
+		},
+		{
+			path: "Gemfile.lock",
+			content: `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.8)
+    rails (7.1.0)
+      rack (>= 2.2.4)
+
+PLATFORMS
+  ruby
+`,
+			expected: []string{"rack 3.0.8", "rails 7.1.0"},
+		},
+		{
+			path:     "composer.lock",
+			content:  `{"packages": [{"name": "monolog/monolog", "version": "3.4.0"}], "packages-dev": [{"name": "phpunit/phpunit", "version": "10.4.1"}]}`,
+			expected: []string{"monolog/monolog 3.4.0", "phpunit/phpunit 10.4.1"},
+		},
+	}
+	for _, tc := range testCases {
+		content, keep, err := lockfileCondenser{}.Transform(tc.path, []byte(tc.content))
+		if err != nil || !keep {
+			t.Errorf("%s: unexpected result keep %v, err %v", tc.path, keep, err)
+			continue
+		}
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		if !strings.HasPrefix(lines[0], "[Condensed lockfile: ") {
+			t.Errorf("%s: expected a marker line, got %q", tc.path, lines[0])
+			continue
+		}
+		if strings.Join(lines[1:], "\n") != strings.Join(tc.expected, "\n") {
+			t.Errorf("%s: expected %q, got %q", tc.path, tc.expected, lines[1:])
+		}
+	}
+
+	// Other files and lockfiles that can't be parsed pass unchanged
+	for _, path := range []string{"main.go", "package-lock.json"} {
+		content, keep, err := lockfileCondenser{}.Transform(path, []byte("not json"))
+		if err != nil || !keep || string(content) != "not json" {
+			t.Errorf("%s: expected the content to pass unchanged, got %q (keep %v, err %v)", path, content, keep, err)
+		}
+	}
+}
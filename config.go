@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// configFileName is the config file looked for in the current directory.
+const configFileName = ".git2llm"
+
+// configSetting is a flag set by a config file, e.g. "changed = main" or "toc".
+type configSetting struct {
+	name  string
+	value string // "" for a flag without a value
+	line  int
+}
+
+// config holds the settings of a config file: defaults applied to every run,
+// and named profiles selected with --profile.
+type config struct {
+	path      string
+	untrusted bool // Found in the current directory, see repoConfigFlags
+	defaults  []configSetting
+	profiles  map[string][]configSetting
+}
+
+// repoConfigFlags are the flags a .git2llm found in the current directory may
+// set: filters, the format and budgets. It comes with the checkout, so it must
+// not run commands, write files, send the code anywhere or weaken redaction;
+// flags that do need --config or the config file in the user config directory.
+var repoConfigFlags = map[string]bool{
+	// Filters
+	"t": true, "exclude-tests": true, "only-tests": true, "test-pattern": true, "test-languages": true,
+	"with-tested": true, "with-tests": true, "e": true, "max-depth": true, "R": true, "gitignore": true,
+	"git-index": true, "include-vendored": true, "no-gitattributes": true, "no-default-dotfiles": true,
+	"include": true, "focus": true, "around": true, "hops": true, "changed": true, "since": true,
+	"since-git": true, "ref": true, "symbol": true,
+	// Format
+	"m": true, "c": true, "order": true, "docs-first": true, "lang": true, "ascii-tree": true,
+	"tree-stats": true, "no-tree": true, "toc": true, "file-header": true, "separator": true,
+	"content-header": true, "front-matter": true, "overview": true, "code-map": true, "dependencies": true,
+	"dedup": true, "file-hashes": true, "file-git-info": true, "working-diff": true, "binary-metadata": true,
+	"no-skip-placeholders": true, "data-schemas": true, "condense-lockfiles": true, "sample-data": true,
+	"images": true, "keep-crlf": true, "path-prefix": true, "root-label": true, "quiet": true,
+	"no-progress": true,
+	// Budgets
+	"max-file-size": true, "skip-over-tokens": true, "max-bytes": true, "max-line-length": true,
+	"fail-over-tokens": true,
+}
+
+// builtinProfiles are available without a config file. A profile of the same
+// name in the config file replaces them.
+const builtinProfiles = `
+# The uncommitted changes and their tests, with a table of contents
+[review]
+changed = HEAD
+with-tests
+toc
+dedup
+
+# The layout of the project: the overview, the dependencies, the tree, the READMEs and the entrypoints
+[onboarding]
+overview
+dependencies
+types = README.md README.rst README.txt README main.go main.py __main__.py app.py manage.py index.js index.ts main.ts main.rs main.c main.cpp Main.java Program.cs
+`
+
+// parseConfig reads a config file. Lines are flag names without dashes,
+// optionally followed by "=" and a value; "types" sets the file types.
+// Lines before the first [section] are defaults, every section is a profile.
+// Empty lines and lines starting with # are ignored.
+func parseConfig(r io.Reader, path string) (*config, error) {
+	cfg := &config{path: path, profiles: make(map[string][]configSetting)}
+	profile := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, fmt.Errorf("%s:%d: invalid profile %s", path, n, line)
+			}
+			profile = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := cfg.profiles[profile]; ok {
+				return nil, fmt.Errorf("%s:%d: profile %s is defined twice", path, n, profile)
+			}
+			cfg.profiles[profile] = nil
+			continue
+		}
+		name, value, _ := strings.Cut(line, "=")
+		setting := configSetting{
+			name:  strings.TrimLeft(strings.TrimSpace(name), "-"),
+			value: strings.TrimSpace(value),
+			line:  n,
+		}
+		if profile == "" {
+			cfg.defaults = append(cfg.defaults, setting)
+		} else {
+			cfg.profiles[profile] = append(cfg.profiles[profile], setting)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// loadConfig reads the config file given with --config, or else the .git2llm
+// in the current directory or git2llm/config in the user config directory,
+// whichever exists first. Without any file the config is empty.
+func (c *cliConfig) loadConfig() (*config, error) {
+	paths := []string{configFileName}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "git2llm", "config"))
+	}
+	if c.configFile != "" {
+		paths = []string{c.configFile}
+	}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) && c.configFile == "" {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error opening config file: %w", err)
+		}
+		defer file.Close()
+		cfg, err := parseConfig(file, path)
+		if err == nil {
+			cfg.untrusted = path == configFileName && c.configFile == ""
+		}
+		return cfg, err
+	}
+	return &config{profiles: make(map[string][]configSetting)}, nil
+}
+
+// applyConfig sets the flags of fs from the defaults of the config file and the
+// profile given with --profile, and then from the environment (see applyEnv).
+// Flags given on the command line take precedence, except repeatable flags such
+// as -e, whose values are added. The flags set in the end are recorded for the
+// front matter.
+func (c *cliConfig) applyConfig(fs *flag.FlagSet) error {
+	// The config file and profile are needed first
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, name := range []string{"config", "profile"} {
+		if env, value, ok := lookupEnv(name); ok && !explicit[name] && fs.Lookup(name) != nil {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("environment variable %s: invalid value for %s: %w", env, name, err)
+			}
+		}
+	}
+	cfg, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.checkTrusted(cfg.defaults); err != nil {
+		return err
+	}
+	settings := cfg.defaults
+	if c.profile != "" {
+		builtin, _ := parseConfig(strings.NewReader(builtinProfiles), "built-in profiles")
+		profile, ok := cfg.profiles[c.profile]
+		if ok {
+			if err := cfg.checkTrusted(profile); err != nil {
+				return err
+			}
+		} else {
+			profile, ok = builtin.profiles[c.profile]
+		}
+		if !ok {
+			names := make(map[string]bool)
+			for _, profiles := range []map[string][]configSetting{cfg.profiles, builtin.profiles} {
+				for name := range profiles {
+					names[name] = true
+				}
+			}
+			available := make([]string, 0, len(names))
+			for name := range names {
+				available = append(available, name)
+			}
+			sort.Strings(available)
+			return fmt.Errorf("unknown profile %s, available: %s", c.profile, strings.Join(available, ", "))
+		}
+		settings = append(append([]configSetting{}, settings...), profile...)
+	}
+
+	for _, s := range settings {
+		if s.name == "types" {
+			c.profileTypes = strings.Fields(s.value)
+			continue
+		}
+		f := fs.Lookup(s.name)
+		if f == nil || s.name == "profile" || s.name == "config" {
+			return fmt.Errorf("%s:%d: unknown option %s", cfg.pathOr("profile "+c.profile), s.line, s.name)
+		}
+		if _, repeatable := f.Value.(*stringSliceFlag); explicit[s.name] && !repeatable {
+			continue
+		}
+		value := s.value
+		if value == "" {
+			value = "true" // Flags without a value are booleans
+		}
+		if err := fs.Set(s.name, value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value for %s: %w", cfg.pathOr("profile "+c.profile), s.line, s.name, err)
+		}
+	}
+	if err := applyEnv(fs); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) { c.options = append(c.options, f.Name+"="+f.Value.String()) })
+	return nil
+}
+
+// checkTrusted returns an error for the first of settings an untrusted config
+// file may not set, see repoConfigFlags.
+func (cfg *config) checkTrusted(settings []configSetting) error {
+	if !cfg.untrusted {
+		return nil
+	}
+	for _, s := range settings {
+		if s.name != "types" && !repoConfigFlags[s.name] {
+			return fmt.Errorf("%s:%d: %s can't be set by the %s of the current directory, give it with --config %s to trust it", cfg.path, s.line, s.name, configFileName, configFileName)
+		}
+	}
+	return nil
+}
+
+// pathOr returns the path of the config file, or name if there is none.
+func (cfg *config) pathOr(name string) string {
+	if cfg.path == "" {
+		return name
+	}
+	return cfg.path
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig(strings.NewReader("# comment\ngitignore\n\n[review]\nchanged = main\n--toc\n[api]\ntypes = .go .proto\n"), "test")
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+	if expected := []configSetting{{name: "gitignore", line: 2}}; !reflect.DeepEqual(cfg.defaults, expected) {
+		t.Errorf("Expected defaults %v, got %v", expected, cfg.defaults)
+	}
+	expected := map[string][]configSetting{
+		"review": {{name: "changed", value: "main", line: 5}, {name: "toc", line: 6}},
+		"api":    {{name: "types", value: ".go .proto", line: 8}},
+	}
+	if !reflect.DeepEqual(cfg.profiles, expected) {
+		t.Errorf("Expected profiles %v, got %v", expected, cfg.profiles)
+	}
+
+	for _, input := range []string{"[review\n", "[a]\n[a]\n"} {
+		if _, err := parseConfig(strings.NewReader(input), "test"); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestCliConfigApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := "gitignore\nchanged = main\n\n[review]\nchanged = develop\ntoc\ne = *.pb.go\nhops = 3\ntypes = .go .proto\n\n[bad]\nno-such-flag\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	parse := func(args ...string) (*cliConfig, error) {
+		var cfg cliConfig
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		cfg.registerFlags(fs)
+		if err := fs.Parse(append([]string{"--config", path}, args...)); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return &cfg, cfg.applyConfig(fs)
+	}
+
+	cfg, err := parse()
+	if err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if !cfg.gitignore || cfg.changed != "main" || cfg.toc {
+		t.Errorf("Expected only the defaults to be applied, got gitignore=%v changed=%q toc=%v", cfg.gitignore, cfg.changed, cfg.toc)
+	}
+
+	cfg, err = parse("--profile", "review", "--hops", "2", "-e", "vendor")
+	if err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if cfg.changed != "develop" || !cfg.toc || !cfg.gitignore {
+		t.Errorf("Expected the profile on top of the defaults, got changed=%q toc=%v gitignore=%v", cfg.changed, cfg.toc, cfg.gitignore)
+	}
+	if cfg.hops != 2 {
+		t.Errorf("Expected the command line --hops 2 to take precedence, got %d", cfg.hops)
+	}
+	if expected := (stringSliceFlag{"vendor", "*.pb.go"}); !reflect.DeepEqual(cfg.excludePatterns, expected) {
+		t.Errorf("Expected patterns %v, got %v", expected, cfg.excludePatterns)
+	}
+	if expected := []string{".go", ".proto"}; !reflect.DeepEqual(cfg.profileTypes, expected) {
+		t.Errorf("Expected types %v, got %v", expected, cfg.profileTypes)
+	}
+
+	if _, err := parse("--profile", "bad"); err == nil || !strings.Contains(err.Error(), ":12: unknown option no-such-flag") {
+		t.Errorf("Expected an unknown option error, got %v", err)
+	}
+	if _, err := parse("--profile", "missing"); err == nil || !strings.Contains(err.Error(), "available: bad, onboarding, review") {
+		t.Errorf("Expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestCliConfigBuiltinProfiles(t *testing.T) {
+	var cfg cliConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.registerFlags(fs)
+	if err := fs.Parse([]string{"--config", os.DevNull, "--profile", "onboarding"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if !cfg.overview || !cfg.dependencies || !reflect.DeepEqual(cfg.profileTypes[:2], []string{"README.md", "README.rst"}) || !strings.Contains(strings.Join(cfg.profileTypes, " "), " main.go ") {
+		t.Errorf("Expected the onboarding profile with the READMEs and entrypoints, got overview=%v dependencies=%v types=%v", cfg.overview, cfg.dependencies, cfg.profileTypes)
+	}
+
+	cfg = cliConfig{}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.registerFlags(fs)
+	if err := fs.Parse([]string{"--config", os.DevNull, "--profile", "review"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if cfg.changed != "HEAD" || !cfg.withTests || !cfg.toc {
+		t.Errorf("Expected the review profile with the tests, got changed=%q with-tests=%v toc=%v", cfg.changed, cfg.withTests, cfg.toc)
+	}
+}
+
+func TestCliConfigUntrusted(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd failed: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("os.Chdir failed: %v", err)
+	}
+	defer os.Chdir(wd)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No user config file
+
+	apply := func(content string, args ...string) (*cliConfig, error) {
+		if err := os.WriteFile(configFileName, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		var cfg cliConfig
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		cfg.registerFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return &cfg, cfg.applyConfig(fs)
+	}
+
+	cfg, err := apply("toc\nmax-bytes = 1000\n[small]\ne = vendor\ntypes = .go\n", "--profile", "small")
+	if err != nil {
+		t.Fatalf("Expected filters, format and budgets to be allowed, got %v", err)
+	}
+	if !cfg.toc || cfg.maxBytes != 1000 || len(cfg.excludePatterns) != 1 {
+		t.Errorf("Expected the settings to be applied, got toc=%v max-bytes=%d excludes=%v", cfg.toc, cfg.maxBytes, cfg.excludePatterns)
+	}
+	for _, content := range []string{"exec-filter = touch pwned; cat\n", "toc\n[p]\nsummarize-over = 10\n", "no-redact\n", "allow = *.pem\n"} {
+		if _, err := apply(content, "--profile", "p"); err == nil || !strings.Contains(err.Error(), "--config .git2llm") {
+			t.Errorf("Expected %q to be refused, got %v", content, err)
+		}
+	}
+	// Given with --config, the file is trusted
+	if cfg, err := apply("no-redact\n", "--config", configFileName); err != nil || !cfg.noRedact {
+		t.Errorf("Expected the file given with --config to be trusted, got %v", err)
+	}
+}
+
+func TestCliConfigApplyEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("changed = main\n\n[ci]\ntoc\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("GIT2LLM_CONFIG", path)
+	t.Setenv("GIT2LLM_PROFILE", "ci")
+	t.Setenv("GIT2LLM_MODEL", "gpt-4o")
+	t.Setenv("GIT2LLM_EXCLUDE", "vendor, *.pb.go")
+	t.Setenv("GIT2LLM_CHANGED", "develop")
+	t.Setenv("GIT2LLM_MAX_DEPTH", "3")
+	t.Setenv("GIT2LLM_TOC", "false")
+	t.Setenv("GIT2LLM_GITIGNORE", "1")
+	t.Setenv("GIT2LLM_REF", "")
+
+	var cfg cliConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.registerFlags(fs)
+	if err := fs.Parse([]string{"--max-depth", "2", "-e", "testdata"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if cfg.model != "gpt-4o" || !cfg.gitignore || cfg.ref != "" {
+		t.Errorf("Expected the environment to set the flags, got model=%q gitignore=%v ref=%q", cfg.model, cfg.gitignore, cfg.ref)
+	}
+	if cfg.maxDepth != 2 || cfg.changed != "main" || !cfg.toc {
+		t.Errorf("Expected the command line and the config file to take precedence, got max-depth=%d changed=%q toc=%v", cfg.maxDepth, cfg.changed, cfg.toc)
+	}
+	if expected := (stringSliceFlag{"testdata", "vendor", "*.pb.go"}); !reflect.DeepEqual(cfg.excludePatterns, expected) {
+		t.Errorf("Expected patterns %v, got %v", expected, cfg.excludePatterns)
+	}
+
+	t.Setenv("GIT2LLM_MAX_DEPTH", "deep")
+	cfg = cliConfig{}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg.registerFlags(fs)
+	if err := cfg.applyConfig(fs); err == nil || !strings.Contains(err.Error(), "GIT2LLM_MAX_DEPTH") {
+		t.Errorf("Expected an invalid value error naming the variable, got %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for name, expected := range map[string]string{"max-depth": "GIT2LLM_MAX_DEPTH", "e": "GIT2LLM_EXCLUDE", "t": "", "help": ""} {
+		if got := envName(name); got != expected {
+			t.Errorf("For %s, expected %q, got %q", name, expected, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// runCount implements the count command: it scans like the main command, with
+// the same options, but only prints the number of tokens, in total and with
+// --files per file, e.g. for a pre-commit hook.
+func runCount(args []string) int {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	var cfg cliConfig
+	cfg.registerFlags(fs)
+	var perFile bool
+	var failOverTokens int
+	fs.BoolVar(&perFile, "files", false, "Also print the tokens of every file, most first")
+	fs.IntVar(&failOverTokens, "fail-over-tokens", 0, "Exit with status 3 if there are more than N tokens")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s count [options] [start_path...] [file_extensions...]\n\n", os.Args[0])
+		fmt.Println("The start path defaults to the current directory. The options are those of a scan.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if cfg.help {
+		fs.Usage()
+		return 0
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		cfg.logger().Error(err.Error())
+		return 1
+	}
+
+	logger := cfg.logger()
+	if failOverTokens < 0 {
+		logger.Error("--fail-over-tokens must be positive")
+		return 1
+	}
+	args = fs.Args()
+	if len(args) == 0 && cfg.remote() == "" && cfg.fromURLs == "" {
+		args = []string{"."}
+	}
+	cfg.countTokens = true
+	roots, err := cfg.newRoots(args, io.Discard)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	result, err := Scan(roots...)
+	if err != nil {
+		logger.Error("Scan failed", "error", err)
+		return 1
+	}
+	if err := writeCounts(os.Stdout, result, perFile); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if failOverTokens > 0 && result.Tokens > failOverTokens {
+		logger.Error("Token limit exceeded", "tokens", result.Tokens, "limit", failOverTokens)
+		return exitOverTokens
+	}
+	return 0
+}
+
+// writeCounts writes the total tokens of result, preceded by the tokens of
+// every file, most first, if perFile is set. The total includes the tree and
+// headers, so it is more than the sum of the files.
+func writeCounts(w io.Writer, result *ScanResult, perFile bool) error {
+	if perFile {
+		files := append([]FileResult(nil), result.Files...)
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].Tokens > files[j].Tokens
+		})
+		for _, f := range files {
+			if _, err := fmt.Fprintf(w, "%8d %s\n", f.Tokens, f.Path); err != nil {
+				return fmt.Errorf("error writing counts: %w", err)
+			}
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%8d total\n", result.Tokens); err != nil {
+		return fmt.Errorf("error writing counts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCounts(t *testing.T) {
+	result := &ScanResult{
+		Files:  []FileResult{{Path: "a.go", Tokens: 10}, {Path: "b.go", Tokens: 250}, {Path: "c.go", Tokens: 10}},
+		Tokens: 300,
+	}
+	var output strings.Builder
+	if err := writeCounts(&output, result, false); err != nil {
+		t.Fatalf("writeCounts failed: %v", err)
+	}
+	if output.String() != "     300 total\n" {
+		t.Errorf("Expected only the total, got %q", output.String())
+	}
+
+	output.Reset()
+	if err := writeCounts(&output, result, true); err != nil {
+		t.Fatalf("writeCounts failed: %v", err)
+	}
+	expected := "     250 b.go\n      10 a.go\n      10 c.go\n     300 total\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+	if result.Files[0].Path != "a.go" {
+		t.Errorf("Expected the result not to be reordered")
+	}
+}
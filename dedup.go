@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// WithDedup emits the content of identical files once. Later copies only
+// reference the first path they were seen at.
+func WithDedup(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.contentHashes = nil
+		if enabled {
+			g.contentHashes = make(map[[sha256.Size]byte]string)
+		}
+	}
+}
+
+// duplicateOf returns the path of an earlier file with the same content as the
+// file at filePath, or records relPath as the first file with this content.
+// data is the content if it was already read. Empty and unreadable files are
+// never duplicates.
+func (g *Git2LLM) duplicateOf(filePath, relPath string, data []byte) (string, bool) {
+	h := sha256.New()
+	size := int64(len(data))
+	if data == nil {
+		file, err := g.fs.Open(filePath)
+		if err != nil {
+			return "", false // Reported when the content is read
+		}
+		size, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", false
+		}
+	} else {
+		h.Write(data)
+	}
+	if size == 0 {
+		return "", false
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	if original, ok := g.contentHashes[sum]; ok {
+		return original, true
+	}
+	g.contentHashes[sum] = relPath
+	return "", false
+}
+
+// writeDuplicate writes the stanza of a file whose content was already emitted for original.
+func (g *Git2LLM) writeDuplicate(relPath, original string) error {
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (Identical to %s)\n", g.fileHeader(relPath), original); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if err := g.writeSeparator(g.outputWriter); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (Identical to %s)\n\n\n", g.contentHeader(relPath), original); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
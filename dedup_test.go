@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMDedup(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"a/util.go":         "package util\n",
+		"b/other.go":        "package other\n",
+		"a/empty.txt":       "",
+		"b/empty.txt":       "",
+		"shared/util.go":    "package util\n",
+		"shared/unique.txt": "unique\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	var roots []*Git2LLM
+	for _, dir := range []string{"a", "b", "shared"} {
+		g, err := NewGit2LLM(filepath.Join(tempDir, dir), nil, nil, &output, false, false, false, nil, "", false, WithPathPrefix(dir), WithDedup(true))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		roots = append(roots, g)
+	}
+	if err := ScanRepositories(roots...); err != nil {
+		t.Fatalf("ScanRepositories failed: %v", err)
+	}
+
+	result := output.String()
+	if strings.Count(result, "package util\n") != 1 {
+		t.Errorf("Expected the content of util.go once, got:\n%s", result)
+	}
+	if !strings.Contains(result, "File: shared/util.go (Identical to a/util.go)\n") {
+		t.Errorf("Expected shared/util.go to reference a/util.go, got:\n%s", result)
+	}
+	// Empty files are not worth a reference
+	for _, name := range []string{"a/empty.txt", "b/empty.txt"} {
+		if !strings.Contains(result, "File: "+name+"\n") {
+			t.Errorf("Expected %s to be included, got:\n%s", name, result)
+		}
+	}
+	skipped := skippedFiles(roots)
+	if len(skipped) != 1 || skipped[0].Path != "shared/util.go" || skipped[0].Reason != SkipDuplicate || skipped[0].Detail != "a/util.go" {
+		t.Errorf("Expected shared/util.go to be recorded as a duplicate, got %+v", skipped)
+	}
+}
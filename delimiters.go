@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pathPlaceholder is replaced by the path of the file in the delimiter templates.
+const pathPlaceholder = "{path}"
+
+// Delimiters frame every file in the text output. Annotations such as
+// "(Values redacted)" are appended to the header lines.
+type Delimiters struct {
+	FileHeader    string // Line naming the file, e.g. "File: {path}"
+	Separator     string // Line below the file header; empty leaves it out
+	ContentHeader string // Line above the content, e.g. "Content of {path}:"
+}
+
+var defaultDelimiters = Delimiters{
+	FileHeader:    "File: " + pathPlaceholder,
+	Separator:     strings.Repeat("-", 50),
+	ContentHeader: "Content of " + pathPlaceholder + ":",
+}
+
+// WithDelimiters replaces the lines framing every file, e.g. when they collide
+// with the contents or a downstream parser expects others. The templates must
+// contain {path} and no line may contain a newline, see check.
+func WithDelimiters(d Delimiters) Option {
+	return func(g *Git2LLM) {
+		g.delimiters = &d
+	}
+}
+
+// check reports an error if the delimiters don't name the file or would take
+// more than a line each, which the table of contents relies on.
+func (d Delimiters) check() error {
+	for _, t := range []struct{ flag, value string }{{"file-header", d.FileHeader}, {"content-header", d.ContentHeader}} {
+		if !strings.Contains(t.value, pathPlaceholder) {
+			return fmt.Errorf("invalid --%s %q: must contain %s", t.flag, t.value, pathPlaceholder)
+		}
+		if strings.ContainsAny(t.value, "\r\n") {
+			return fmt.Errorf("invalid --%s %q: must be a single line", t.flag, t.value)
+		}
+	}
+	if strings.ContainsAny(d.Separator, "\r\n") {
+		return fmt.Errorf("invalid --separator %q: must be a single line", d.Separator)
+	}
+	return nil
+}
+
+// frame returns the delimiters of the output.
+func (g *Git2LLM) frame() *Delimiters {
+	if g.delimiters == nil {
+		return &defaultDelimiters
+	}
+	return g.delimiters
+}
+
+// fileHeader returns the line naming the file at relPath, without a newline.
+func (g *Git2LLM) fileHeader(relPath string) string {
+	return strings.ReplaceAll(g.frame().FileHeader, pathPlaceholder, relPath)
+}
+
+// contentHeader returns the line above the content of the file at relPath, without a newline.
+func (g *Git2LLM) contentHeader(relPath string) string {
+	return strings.ReplaceAll(g.frame().ContentHeader, pathPlaceholder, relPath)
+}
+
+// writeSeparator writes the line below the file header, if there is one.
+func (g *Git2LLM) writeSeparator(w io.Writer) error {
+	separator := g.frame().Separator
+	if separator == "" {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, separator); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// separatorEscaper is an io.Writer passing the content of a file on to w with
+// a backslash added to every line consisting of the separator, optionally
+// after backslashes, so a line of the content can't be taken for the end of a
+// file header. Flush must be called after the last write.
+type separatorEscaper struct {
+	w         io.Writer
+	separator []byte
+	line      []byte // Start of the current line while it may still need escaping
+	slashes   int    // Leading backslashes of line
+	matching  bool   // The current line can still be an escaped or unescaped separator
+	escaped   int    // Lines escaped
+	out       []byte
+}
+
+func newSeparatorEscaper(w io.Writer, separator string) *separatorEscaper {
+	return &separatorEscaper{w: w, separator: []byte(separator), matching: true}
+}
+
+func (e *separatorEscaper) Write(p []byte) (int, error) {
+	e.out = e.out[:0]
+	for _, b := range p {
+		if !e.matching {
+			e.out = append(e.out, b)
+			e.matching = b == '\n'
+			continue
+		}
+		if b == '\n' {
+			e.endLine()
+			e.out = append(e.out, b)
+			continue
+		}
+		pos := len(e.line) - e.slashes
+		switch {
+		case pos == 0 && b == '\\' && e.separator[0] != '\\':
+			e.slashes++
+		case pos < len(e.separator) && b == e.separator[pos]:
+		default:
+			e.out = append(append(e.out, e.line...), b)
+			e.line, e.slashes, e.matching = e.line[:0], 0, false
+			continue
+		}
+		e.line = append(e.line, b)
+	}
+	if _, err := e.w.Write(e.out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// endLine writes the buffered start of the line, escaped if it is a separator.
+func (e *separatorEscaper) endLine() {
+	if len(e.line)-e.slashes == len(e.separator) {
+		e.out = append(e.out, '\\')
+		e.escaped++
+	}
+	e.out = append(e.out, e.line...)
+	e.line, e.slashes, e.matching = e.line[:0], 0, true
+}
+
+// Flush writes a last line without a newline and resets the state for the next file.
+func (e *separatorEscaper) Flush() error {
+	e.out = e.out[:0]
+	e.endLine()
+	if len(e.out) == 0 {
+		return nil
+	}
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// unescapeSeparator reverses separatorEscaper for a line of content without its newline.
+func unescapeSeparator(line, separator string) string {
+	if rest := strings.TrimLeft(line, `\`); rest == separator && len(line) > len(rest) && !strings.HasPrefix(separator, `\`) {
+		return line[1:]
+	}
+	return line
+}
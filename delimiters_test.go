@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScanDelimiters(t *testing.T) {
+	tempDir := t.TempDir()
+	for fileName, content := range map[string]string{"main.go": "package main\n", ".env": "KEY=secret\n"} {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	delimiters := Delimiters{FileHeader: "=== {path} ===", ContentHeader: "<<< {path}"}
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false,
+		WithDelimiters(delimiters), WithDotfileIncludes(".env"), WithTableOfContents(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{"=== main.go ===\n<<< main.go\npackage main\n", "=== .env === (Values redacted)\n<<< .env\n"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "File: ") || strings.Contains(result, "Content of") || strings.Contains(result, strings.Repeat("-", 50)) {
+		t.Errorf("Expected none of the default delimiters. Result:\n%s", result)
+	}
+
+	// The table of contents points at the file headers
+	lines := strings.Split(result, "\n")
+	entries := regexp.MustCompile(`(?m)^(\S+) \(line (\d+)\)$`).FindAllStringSubmatch(result, -1)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 TOC entries. Result:\n%s", result)
+	}
+	for _, entry := range entries {
+		n, _ := strconv.Atoi(entry[2])
+		if n < 1 || n > len(lines) || !strings.HasPrefix(lines[n-1], "=== "+entry[1]+" ===") {
+			t.Errorf("TOC entry %q does not point at the header of %s", entry[0], entry[1])
+		}
+	}
+}
+
+func TestDelimitersCheck(t *testing.T) {
+	if err := defaultDelimiters.check(); err != nil {
+		t.Errorf("Expected the default delimiters to be valid, got %v", err)
+	}
+	for _, d := range []Delimiters{
+		{FileHeader: "File:", ContentHeader: "{path}"},
+		{FileHeader: "{path}", ContentHeader: "Content:"},
+		{FileHeader: "{path}\n", ContentHeader: "{path}"},
+		{FileHeader: "{path}", Separator: "--\n--", ContentHeader: "{path}"},
+	} {
+		if err := d.check(); err == nil {
+			t.Errorf("Expected an error for %+v", d)
+		}
+	}
+}
+
+func TestSeparatorEscaper(t *testing.T) {
+	var output strings.Builder
+	e := newSeparatorEscaper(&output, "---")
+	input := "---\n----\n--\n\\---\na---\n\\\\x\n---"
+	// Split the input so lines span writes
+	for _, chunk := range []string{input[:2], input[2:9], input[9:]} {
+		if _, err := e.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expected := "\\---\n----\n--\n\\\\---\na---\n\\\\x\n\\---"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+	if e.escaped != 3 {
+		t.Errorf("Expected 3 escaped lines, got %d", e.escaped)
+	}
+	inputLines, outputLines := strings.Split(input, "\n"), strings.Split(expected, "\n")
+	for i := range inputLines {
+		if got := unescapeSeparator(outputLines[i], "---"); got != inputLines[i] {
+			t.Errorf("unescapeSeparator(%q) = %q, expected %q", outputLines[i], got, inputLines[i])
+		}
+	}
+}
+
+func TestSeparatorRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	// A file that quotes the output format must not be split by apply
+	content := "Example output:\nFile: other.go\n" + defaultDelimiters.Separator + "\nContent of other.go:\n\\" + defaultDelimiters.Separator + "\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if strings.Count(output.String(), "\n"+defaultDelimiters.Separator+"\n") != 1 {
+		t.Errorf("Expected only the separator of notes.txt unescaped. Result:\n%s", output.String())
+	}
+	files := parsePatch(output.String())
+	if len(files) != 1 || files[0].path != "notes.txt" || files[0].content != content {
+		t.Errorf("Expected notes.txt with its content, got %+v", files)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// WithDependencies emits a summary of the direct dependencies declared in the
+// manifest files of the start path (go.mod, package.json, pyproject.toml,
+// requirements.txt, Cargo.toml, composer.json and Gemfile), whether or not the
+// manifests themselves are part of the output.
+func WithDependencies(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.dependencies = enabled
+	}
+}
+
+// dependency is a direct dependency declared in a manifest.
+type dependency struct {
+	name    string
+	version string // As declared; may be a constraint or empty
+	kind    string // "dev", "build" or "" for regular dependencies
+}
+
+// manifestParsers read the direct dependencies of the manifest files, in output order.
+var manifestParsers = []struct {
+	name  string
+	parse func(content []byte) []dependency
+}{
+	{"go.mod", goModDependencies},
+	{"package.json", packageJSONDependencies},
+	{"pyproject.toml", pyprojectDependencies},
+	{"requirements.txt", requirementsDependencies},
+	{"Cargo.toml", cargoDependencies},
+	{"composer.json", composerDependencies},
+	{"Gemfile", gemfileDependencies},
+}
+
+// writeDependencies writes the dependencies of all roots to w. Nothing is
+// written if no manifest declares any.
+func writeDependencies(w io.Writer, roots []*Git2LLM) error {
+	var out strings.Builder
+	for _, g := range roots {
+		for _, m := range manifestParsers {
+			content, ok := g.readRootFile(m.name)
+			if !ok {
+				continue
+			}
+			deps := m.parse(content)
+			if len(deps) == 0 {
+				continue
+			}
+			fmt.Fprintf(&out, "%s:\n", g.displayPath(m.name))
+			for _, d := range deps {
+				line := "  " + d.name
+				if d.version != "" {
+					line += " " + d.version
+				}
+				if d.kind != "" {
+					line += " (" + d.kind + ")"
+				}
+				out.WriteString(line + "\n")
+			}
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Dependencies:\n-------------\n%s\n\n", out.String()); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+func goModDependencies(content []byte) []dependency {
+	var deps []dependency
+	for _, r := range parseGoMod(content).requires {
+		if r[2] == "" {
+			deps = append(deps, dependency{name: r[0], version: r[1]})
+		}
+	}
+	return deps
+}
+
+func packageJSONDependencies(content []byte) []dependency {
+	var pkg packageJSON
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil
+	}
+	return append(sortedDependencies(pkg.Dependencies, ""), sortedDependencies(pkg.DevDependencies, "dev")...)
+}
+
+func composerDependencies(content []byte) []dependency {
+	var composer struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err := json.Unmarshal(content, &composer); err != nil {
+		return nil
+	}
+	return append(sortedDependencies(composer.Require, ""), sortedDependencies(composer.RequireDev, "dev")...)
+}
+
+func sortedDependencies(m map[string]string, kind string) []dependency {
+	var deps []dependency
+	for _, name := range sortedKeys(m) {
+		deps = append(deps, dependency{name: name, version: m[name], kind: kind})
+	}
+	return deps
+}
+
+func pyprojectDependencies(content []byte) []dependency {
+	var deps []dependency
+	for _, r := range pyprojectRequirements(tomlTables(content)) {
+		deps = append(deps, dependency{name: r[0], version: r[1]})
+	}
+	return deps
+}
+
+func requirementsDependencies(content []byte) []dependency {
+	var deps []dependency
+	for _, r := range requirementsTxt(content) {
+		deps = append(deps, dependency{name: r[0], version: r[1]})
+	}
+	return deps
+}
+
+// cargoVersion matches the version of a dependency given as an inline table.
+var cargoVersion = regexp.MustCompile(`version\s*=\s*"([^"]*)"`)
+
+func cargoDependencies(content []byte) []dependency {
+	tables := tomlTables(content)
+	var deps []dependency
+	for _, t := range [][2]string{{"dependencies", ""}, {"dev-dependencies", "dev"}, {"build-dependencies", "build"}} {
+		for _, name := range sortedKeys(tables[t[0]]) {
+			value := tables[t[0]][name]
+			version := tomlString(value)
+			if strings.HasPrefix(value, "{") {
+				version = ""
+				if m := cargoVersion.FindStringSubmatch(value); m != nil {
+					version = m[1]
+				}
+			}
+			deps = append(deps, dependency{name: name, version: version, kind: t[1]})
+		}
+	}
+	return deps
+}
+
+// gemLine matches a gem declaration in a Gemfile: gem "rails", "~> 7.1".
+var gemLine = regexp.MustCompile(`^\s*gem\s+["']([^"']+)["'](?:\s*,\s*["']([^"']+)["'])?`)
+
+func gemfileDependencies(content []byte) []dependency {
+	var deps []dependency
+	group := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "group "):
+			if strings.Contains(line, ":development") || strings.Contains(line, ":test") {
+				group = "dev"
+			}
+		case line == "end":
+			group = ""
+		}
+		if m := gemLine.FindStringSubmatch(line); m != nil {
+			deps = append(deps, dependency{name: m[1], version: m[2], kind: group})
+		}
+	}
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].kind < deps[j].kind })
+	return deps
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMDependencies(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.22\n\nrequire github.com/spf13/cobra v1.8.0\n\nrequire (\n\tgolang.org/x/sys v0.20.0 // indirect\n)\n",
+		"package.json":     `{"dependencies": {"react": "^18.0.0", "axios": "1.6.0"}, "devDependencies": {"vite": "^5.0.0"}}`,
+		"requirements.txt": "# Web\nDjango>=4.2,<5 ; python_version > '3.8'\nrequests\n-r dev.txt\n",
+		"Cargo.toml":       "[package]\nname = \"core\"\n\n[dependencies]\nserde = \"1.0\"\ntokio = { version = \"1.36\", features = [\"full\"] }\nlocal = { path = \"../local\" }\n\n[dev-dependencies]\ncriterion = \"0.5\"\n",
+		"Gemfile":          "source \"https://rubygems.org\"\ngem \"rails\", \"~> 7.1\"\ngroup :development, :test do\n  gem 'rspec'\nend\ngem \"puma\"\n",
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	// The manifests themselves are not part of the output
+	git2llm, err := NewGit2LLM(tempDir, []string{".go"}, nil, &output, false, false, false, nil, "", false, WithDependencies(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	expect := `Dependencies:
+-------------
+go.mod:
+  github.com/spf13/cobra v1.8.0
+package.json:
+  axios 1.6.0
+  react ^18.0.0
+  vite ^5.0.0 (dev)
+requirements.txt:
+  django >=4.2,<5
+  requests
+Cargo.toml:
+  local
+  serde 1.0
+  tokio 1.36
+  criterion 0.5 (dev)
+Gemfile:
+  rails ~> 7.1
+  puma
+  rspec (dev)
+
+
+Directory Structure:
+`
+	if !strings.HasPrefix(output.String(), expect) {
+		t.Errorf("Expected output to start with:\n%s\ngot:\n%s", expect, output.String())
+	}
+}
+
+func TestPyprojectDependencies(t *testing.T) {
+	pyproject := "[project]\nname = \"app\"\ndependencies = [\n  \"fastapi[all]>=0.110\",\n  \"uvicorn\",\n]\n\n[tool.poetry.dependencies]\npython = \"^3.11\"\npydantic = \"^2.6\"\n"
+	deps := pyprojectDependencies([]byte(pyproject))
+	expect := []dependency{{name: "fastapi", version: ">=0.110"}, {name: "uvicorn"}, {name: "pydantic", version: "^2.6"}}
+	if len(deps) != len(expect) {
+		t.Fatalf("Expected %v, got %v", expect, deps)
+	}
+	for i := range expect {
+		if deps[i] != expect[i] {
+			t.Errorf("Expected %v, got %v", expect[i], deps[i])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// WithDotfiles includes dotfiles and dotfolders, which are excluded by default.
+// The default exclusion patterns (.git, .idea, ...) still apply.
+func WithDotfiles(include bool) Option {
+	return func(g *Git2LLM) {
+		g.includeDotfiles = include
+	}
+}
+
+// WithDotfileIncludes includes the hidden paths matching the given patterns even
+// though dotfiles are excluded. Patterns are slash separated, relative to the
+// start path and may use ** for any number of directories, e.g. .github/workflows/**.
+func WithDotfileIncludes(patterns ...string) Option {
+	return func(g *Git2LLM) {
+		g.dotfileIncludes = append(g.dotfileIncludes, patterns...)
+	}
+}
+
+// defaultDotfileIncludes are hidden paths included by default, because they
+// explain how a project is built, checked and configured.
+var defaultDotfileIncludes = []string{
+	".github/workflows/**",
+	".gitlab-ci.yml",
+	".golangci.yml",
+	".golangci.yaml",
+	".dockerignore",
+	".editorconfig",
+	".env.example",
+}
+
+// WithDefaultDotfiles includes the hidden paths of defaultDotfileIncludes even
+// though dotfiles are excluded. It is enabled by default.
+func WithDefaultDotfiles(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.noDefaultDotfiles = !enabled
+	}
+}
+
+// isHidden reports whether relPath is excluded by the dotfile rule.
+func (g *Git2LLM) isHidden(relPath string, parts []string) bool {
+	if g.includeDotfiles {
+		return false
+	}
+	hidden := false
+	for _, part := range parts {
+		if part != "" && strings.HasPrefix(part, ".") {
+			hidden = true
+			break
+		}
+	}
+	if !hidden {
+		return false
+	}
+	includes := g.dotfileIncludes
+	if !g.noDefaultDotfiles {
+		includes = append(includes[:len(includes):len(includes)], defaultDotfileIncludes...)
+	}
+	for _, pattern := range includes {
+		// Directories leading to an included path must be walked as well
+		if matchGlob(pattern, relPath) || matchGlobParent(pattern, relPath) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchGlob reports whether the slash separated name matches pattern. A "**"
+// element matches any number of path elements, other elements are matched with path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchGlobParent reports whether dir can contain paths matching pattern.
+func matchGlobParent(pattern, dir string) bool {
+	elements := strings.Split(pattern, "/")
+	for i, part := range strings.Split(dir, "/") {
+		if i >= len(elements)-1 {
+			return false
+		}
+		if elements[i] == "**" {
+			return true
+		}
+		if matched, _ := path.Match(elements[i], part); !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// emitter writes the pack in another format alongside the text output, from
+// the same traversal. The trees and the contents of the included files are
+// passed on as they are written, the file list and skipped files at the end.
+type emitter interface {
+	start(frontMatter bool) error // With front matter, the metadata is passed to finish
+	writeTree(tree string) error
+	beginFile(relPath, lang string) (io.Writer, error) // lang is a syntax hint such as go or sh
+	endFile() error
+	finish(result *ScanResult, meta *Metadata) error
+}
+
+// emitFormats create the emitters of the formats supported by --emit besides text.
+var emitFormats = map[string]func(w io.Writer) emitter{
+	"md":   func(w io.Writer) emitter { return &markdownEmitter{w: w} },
+	"json": func(w io.Writer) emitter { return &jsonEmitter{w: w} },
+}
+
+// withEmitters writes the output in further formats. The emitters must be
+// shared by all roots of a scan.
+func withEmitters(emitters ...emitter) Option {
+	return func(g *Git2LLM) {
+		g.emitters = emitters
+	}
+}
+
+// emitTarget is a format and the file it is written to, from --emit md=out.md.
+type emitTarget struct {
+	format string
+	path   string
+}
+
+// parseEmitTargets parses the values of --emit: comma separated FORMAT=FILE pairs.
+func parseEmitTargets(values []string) ([]emitTarget, error) {
+	var targets []emitTarget
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, spec := range strings.Split(value, ",") {
+			format, file, ok := strings.Cut(strings.TrimSpace(spec), "=")
+			_, known := emitFormats[format]
+			if !ok || file == "" || !(known || format == "text") {
+				formats := []string{"text"}
+				for name := range emitFormats {
+					formats = append(formats, name)
+				}
+				sort.Strings(formats)
+				return nil, fmt.Errorf("invalid --emit %q: expected FORMAT=FILE with FORMAT one of %s", spec, strings.Join(formats, ", "))
+			}
+			if seen[file] {
+				return nil, fmt.Errorf("invalid --emit %q: %s is written twice", spec, file)
+			}
+			seen[file] = true
+			targets = append(targets, emitTarget{format: format, path: file})
+		}
+	}
+	return targets, nil
+}
+
+// markdownBufferSize is the most of a file the markdown emitter buffers to make
+// the fence longer than any run of backticks starting a line. The rest of a
+// larger file is streamed inside a fence of at least longFence backticks.
+const markdownBufferSize = 64 * 1024
+
+// longFence is the length of the fence of files over markdownBufferSize, more
+// backticks than any line of ordinary content starts with.
+const longFence = 16
+
+// markdownEmitter writes the trees and the files as fenced code blocks under
+// headings, after the YAML front matter if there is one. The start of every
+// file is buffered, so the fence can be made longer than any run of backticks
+// starting a line of the file, see markdownBufferSize.
+type markdownEmitter struct {
+	w       io.Writer
+	dst     io.Writer // The output while w is a spool for the front matter
+	spool   *spool
+	heading bool // The heading of the trees was written
+	lang    string
+	content bytes.Buffer
+	fence   string // Once the file is streamed
+	last    byte   // The last byte streamed
+}
+
+func (m *markdownEmitter) start(frontMatter bool) error {
+	if !frontMatter {
+		return nil
+	}
+	spool, err := newSpool()
+	if err != nil {
+		return err
+	}
+	m.dst, m.w, m.spool = m.w, spool, spool
+	return nil
+}
+
+func (m *markdownEmitter) writeTree(tree string) error {
+	if !m.heading {
+		m.heading = true
+		if _, err := fmt.Fprint(m.w, "## Directory Structure\n\n"); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintf(m.w, "```\n%s```\n\n", tree); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+func (m *markdownEmitter) beginFile(relPath, lang string) (io.Writer, error) {
+	m.lang, m.fence = lang, ""
+	m.content.Reset()
+	if _, err := fmt.Fprintf(m.w, "## %s\n\n", relPath); err != nil {
+		return nil, fmt.Errorf("error writing to output file: %w", err)
+	}
+	return markdownContent{m}, nil
+}
+
+// markdownContent is the writer of the content of a file, buffering its start.
+type markdownContent struct {
+	m *markdownEmitter
+}
+
+func (c markdownContent) Write(p []byte) (int, error) {
+	m := c.m
+	if m.fence == "" {
+		if m.content.Len()+len(p) <= markdownBufferSize {
+			return m.content.Write(p)
+		}
+		// Too large to buffer, open the fence and stream the rest
+		m.fence = codeFence(m.content.Bytes())
+		if len(m.fence) < longFence {
+			m.fence = strings.Repeat("`", longFence)
+		}
+		if _, err := fmt.Fprintf(m.w, "%s%s\n%s", m.fence, m.lang, m.content.Bytes()); err != nil {
+			return 0, fmt.Errorf("error writing to output file: %w", err)
+		}
+		m.last = '\n'
+		if m.content.Len() > 0 {
+			m.last = m.content.Bytes()[m.content.Len()-1]
+		}
+		m.content.Reset()
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := m.w.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("error writing to output file: %w", err)
+	}
+	m.last = p[len(p)-1]
+	return n, nil
+}
+
+func (m *markdownEmitter) endFile() error {
+	if m.fence != "" {
+		newline := ""
+		if m.last != '\n' {
+			newline = "\n"
+		}
+		if _, err := fmt.Fprintf(m.w, "%s%s\n\n", newline, m.fence); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+		return nil
+	}
+	content := m.content.Bytes()
+	fence := codeFence(content)
+	newline := ""
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		newline = "\n"
+	}
+	if _, err := fmt.Fprintf(m.w, "%s%s\n%s%s%s\n\n", fence, m.lang, content, newline, fence); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// codeFence returns a fence of backticks that no line of content can close:
+// three, or one more than the longest run starting a line.
+func codeFence(content []byte) string {
+	longest := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimLeft(line, " \t")
+		n := len(line) - len(bytes.TrimLeft(line, "`"))
+		longest = max(longest, n)
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func (m *markdownEmitter) finish(_ *ScanResult, meta *Metadata) error {
+	if m.spool == nil {
+		return nil
+	}
+	defer m.spool.close()
+	front, err := meta.yaml()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(m.dst, front); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return m.spool.copyTo(m.dst)
+}
+
+// jsonEmitter writes a JSON object with the trees, the contents of the files,
+// and at the end the files with their sizes and token counts, the skipped
+// files, the total tokens and the metadata if there is front matter. The
+// contents are buffered one file at a time.
+type jsonEmitter struct {
+	w       io.Writer
+	trees   []string
+	started bool
+	files   int
+	path    string
+	content bytes.Buffer
+}
+
+func (j *jsonEmitter) start(bool) error {
+	return nil
+}
+
+func (j *jsonEmitter) writeTree(tree string) error {
+	j.trees = append(j.trees, tree)
+	return nil
+}
+
+// open writes the trees and opens the list of contents.
+func (j *jsonEmitter) open() error {
+	if j.started {
+		return nil
+	}
+	j.started = true
+	tree, err := json.Marshal(strings.Join(j.trees, "\n"))
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if _, err := fmt.Fprintf(j.w, "{\n  \"tree\": %s,\n  \"contents\": [", tree); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+func (j *jsonEmitter) beginFile(relPath, _ string) (io.Writer, error) {
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	j.path = relPath
+	j.content.Reset()
+	return &j.content, nil
+}
+
+func (j *jsonEmitter) endFile() error {
+	entry, err := json.Marshal(struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}{j.path, j.content.String()})
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	sep := ",\n    "
+	if j.files == 0 {
+		sep = "\n    "
+	}
+	j.files++
+	if _, err := fmt.Fprintf(j.w, "%s%s", sep, entry); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+func (j *jsonEmitter) finish(result *ScanResult, meta *Metadata) error {
+	if err := j.open(); err != nil {
+		return err
+	}
+	files, skipped := result.Files, result.Skipped
+	if files == nil {
+		files = []FileResult{}
+	}
+	if skipped == nil {
+		skipped = []SkippedFile{}
+	}
+	filesJSON, err := json.MarshalIndent(files, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	skippedJSON, err := json.MarshalIndent(skipped, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	end := "\n  ]"
+	if j.files == 0 {
+		end = "]"
+	}
+	if _, err := fmt.Fprintf(j.w, "%s,\n  \"files\": %s,\n  \"skipped\": %s,\n  \"tokens\": %d", end, filesJSON, skippedJSON, result.Tokens); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if meta != nil {
+		metaJSON, err := json.MarshalIndent(meta, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("json.MarshalIndent: %w", err)
+		}
+		if _, err := fmt.Fprintf(j.w, ",\n  \"metadata\": %s", metaJSON); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	if _, err := fmt.Fprint(j.w, "\n}\n"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEmitTargets(t *testing.T) {
+	targets, err := parseEmitTargets([]string{"md=out.md,json=out.json", "text=out.txt"})
+	if err != nil {
+		t.Fatalf("parseEmitTargets failed: %v", err)
+	}
+	expected := []emitTarget{{"md", "out.md"}, {"json", "out.json"}, {"text", "out.txt"}}
+	if len(targets) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, targets)
+	}
+	for i := range expected {
+		if targets[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], targets[i])
+		}
+	}
+
+	for _, value := range []string{"out.md", "html=out.html", "md=", "md=out,json=out"} {
+		if _, err := parseEmitTargets([]string{value}); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestScanEmitters(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":   "package main\n",
+		"notes.txt": "no newline",
+		"image.bin": "\x00\x01",
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var text, md, js strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &text, false, false, true, nil, "estimate", false,
+		withEmitters(emitFormats["md"](&md), emitFormats["json"](&js)))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if !strings.Contains(text.String(), "Content of main.go:\npackage main\n") {
+		t.Errorf("Expected the text output. Result:\n%s", text.String())
+	}
+	for _, expected := range []string{"## Directory Structure\n\n```\n/ \n", "## main.go\n\n```go\npackage main\n```\n", "## notes.txt\n\n```text\nno newline\n```\n"} {
+		if !strings.Contains(md.String(), expected) {
+			t.Errorf("Expected %q in the markdown. Result:\n%s", expected, md.String())
+		}
+	}
+
+	var doc struct {
+		Tree     string `json:"tree"`
+		Contents []struct {
+			Path    string `json:"path"`
+			Content string `json:"content"`
+		} `json:"contents"`
+		Files   []FileResult  `json:"files"`
+		Skipped []SkippedFile `json:"skipped"`
+		Tokens  int           `json:"tokens"`
+	}
+	if err := json.Unmarshal([]byte(js.String()), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, js.String())
+	}
+	if doc.Tree != result.Tree || doc.Tokens != result.Tokens || len(doc.Files) != 2 || doc.Files[0].Tokens == 0 {
+		t.Errorf("Expected the tree, files and tokens of the scan, got %+v", doc)
+	}
+	if len(doc.Contents) != 2 || doc.Contents[0].Path != "main.go" || doc.Contents[1].Content != "no newline" {
+		t.Errorf("Expected the contents of main.go and notes.txt, got %+v", doc.Contents)
+	}
+	if len(doc.Skipped) != 1 || doc.Skipped[0].Path != "image.bin" {
+		t.Errorf("Expected image.bin to be skipped, got %+v", doc.Skipped)
+	}
+}
+
+func TestMarkdownFence(t *testing.T) {
+	var md strings.Builder
+	m := emitFormats["md"](&md)
+	w, err := m.beginFile("README.md", "md")
+	if err != nil {
+		t.Fatalf("beginFile failed: %v", err)
+	}
+	w.Write([]byte("# Usage\n\n```sh\nmake\n```\n  ````\ninline ````` run"))
+	if err := m.endFile(); err != nil {
+		t.Fatalf("endFile failed: %v", err)
+	}
+	expected := "## README.md\n\n`````md\n# Usage\n\n```sh\nmake\n```\n  ````\ninline ````` run\n`````\n\n"
+	if md.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, md.String())
+	}
+	if files := parsePatch(md.String()); len(files) != 1 || !strings.HasSuffix(files[0].content, "run\n") {
+		t.Errorf("Expected the whole file to be one block, got %+v", files)
+	}
+}
+
+func TestMarkdownFenceLargeFile(t *testing.T) {
+	var md strings.Builder
+	m := emitFormats["md"](&md)
+	w, err := m.beginFile("big.txt", "text")
+	if err != nil {
+		t.Fatalf("beginFile failed: %v", err)
+	}
+	line := "````` not a fence\n"
+	chunk := []byte(strings.Repeat(line, 1000))
+	for written := 0; written <= markdownBufferSize; written += len(chunk) {
+		w.Write(chunk)
+	}
+	// The start is buffered, the rest streamed
+	if md.Len() <= markdownBufferSize {
+		t.Fatalf("Expected the file to be streamed once it is over %d bytes, got %d bytes", markdownBufferSize, md.Len())
+	}
+	w.Write([]byte("end"))
+	if err := m.endFile(); err != nil {
+		t.Fatalf("endFile failed: %v", err)
+	}
+	fence := strings.Repeat("`", longFence)
+	text := md.String()
+	if !strings.HasPrefix(text, "## big.txt\n\n"+fence+"text\n"+line) || !strings.HasSuffix(text, line+"end\n"+fence+"\n\n") {
+		t.Errorf("Expected the file in a fence of %d backticks, got %q...%q", longFence, text[:50], text[len(text)-50:])
+	}
+	if files := parsePatch(text); len(files) != 1 || !strings.HasSuffix(files[0].content, "end\n") {
+		t.Errorf("Expected the whole file to be one block, got %d blocks", len(files))
+	}
+}
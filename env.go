@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix starts the names of the environment variables setting flags.
+const envPrefix = "GIT2LLM_"
+
+// envAliases name the environment variables of the flags with a single letter.
+// Single letters with a long form, such as -t, are set through the long form.
+var envAliases = map[string]string{
+	"c": "COUNT_TOKENS",
+	"e": "EXCLUDE",
+	"m": "MODEL",
+	"o": "OUTPUT",
+	"R": "NO_RECURSE",
+}
+
+// envName returns the environment variable setting the flag name, e.g.
+// GIT2LLM_MAX_DEPTH for --max-depth, or "" if there is none.
+func envName(name string) string {
+	if alias, ok := envAliases[name]; ok {
+		return envPrefix + alias
+	}
+	if len(name) == 1 || name == "help" || name == "version" {
+		return ""
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// lookupEnv returns the environment variable of the flag name and its value,
+// and whether it is set and not empty.
+func lookupEnv(name string) (string, string, bool) {
+	env := envName(name)
+	if env == "" {
+		return "", "", false
+	}
+	value, ok := os.LookupEnv(env)
+	return env, value, ok && value != ""
+}
+
+// applyEnv sets the flags of fs that are not set yet, on the command line or
+// by the config file, from their environment variables. Repeatable flags such
+// as -e take a comma separated list, whose values are added.
+func applyEnv(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		env, value, ok := lookupEnv(f.Name)
+		if !ok || err != nil || f.Name == "profile" || f.Name == "config" {
+			return
+		}
+		values := []string{value}
+		if _, repeatable := f.Value.(*stringSliceFlag); repeatable {
+			values = strings.Split(value, ",")
+		} else if set[f.Name] {
+			return
+		}
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			if setErr := fs.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("environment variable %s: invalid value for %s: %w", env, f.Name, setErr)
+				return
+			}
+		}
+	})
+	return err
+}
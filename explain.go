@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runExplain implements the explain command: it reports for every path whether
+// it would be included and, if not, which rules leave it out.
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	var cfg cliConfig
+	cfg.registerFlags(fs)
+	var start string
+	fs.StringVar(&start, "start", ".", "Start path the paths are checked against, as in a scan")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s explain [options] <path> [path...]\n\n", os.Args[0])
+		fmt.Println("Paths are relative to the current directory. The options are those of a scan.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args) // ExitOnError
+	if cfg.help {
+		fs.Usage()
+		return 0
+	}
+	if err := cfg.applyConfig(fs); err != nil {
+		cfg.logger().Error(err.Error())
+		return 1
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 1
+	}
+
+	logger := cfg.logger()
+	roots, err := cfg.newRoots([]string{start}, io.Discard)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	g := roots[0]
+	status := 0
+	for _, arg := range fs.Args() {
+		relPath, err := explainPath(start, arg)
+		if err != nil {
+			logger.Error(err.Error())
+			status = 1
+			continue
+		}
+		reasons := g.explain(relPath)
+		if len(reasons) == 0 {
+			fmt.Printf("%s: included\n", relPath)
+			continue
+		}
+		for _, reason := range reasons {
+			fmt.Printf("%s: excluded by %s\n", relPath, reason)
+		}
+	}
+	return status
+}
+
+// explainPath returns the path of name relative to start, which must contain it.
+func explainPath(start, name string) (string, error) {
+	absStart, err := filepath.Abs(start)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	absName, err := filepath.Abs(name)
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs: %w", err)
+	}
+	// Compare real paths, so a start path such as ~/work -> /mnt/work contains
+	// /mnt/work/x.go. The name itself may be a symlink, so only its directory
+	// is resolved.
+	absStart = realPath(absStart)
+	absName = filepath.Join(realPath(filepath.Dir(absName)), filepath.Base(absName))
+	relPath, err := filepath.Rel(absStart, absName)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not below the start path %s", name, start)
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// realPath returns the absolute path p with the symlinks of its longest
+// existing ancestor resolved, as the rest of it may not exist.
+func realPath(p string) string {
+	for dir, rest := p, ""; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return p
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// explain returns every rule that leaves relPath out of the output, or nothing
+// if it is included. Path rules are always checked; the content of the file is
+// only checked if it exists.
+func (g *Git2LLM) explain(relPath string) []string {
+	var reasons []string
+	parts := strings.Split(relPath, "/")
+	if g.noRecurse && len(parts) > 1 {
+		reasons = append(reasons, "-R, only the start directory is scanned")
+	}
+	if g.maxDepth > 0 && len(parts) > g.maxDepth {
+		reasons = append(reasons, fmt.Sprintf("--max-depth %d", g.maxDepth))
+	}
+	if g.isHidden(relPath, parts) {
+		reasons = append(reasons, "the dotfile rule (see --include-dotfiles and --include)")
+	}
+	if g.isGitIgnored(relPath) {
+		reasons = append(reasons, "git (--gitignore)")
+	}
+	if g.isVendored(parts) || g.isVendoredDir(relPath) {
+		reasons = append(reasons, "the vendored code rule (see --include-vendored)")
+	}
+	if attr, source := g.excludingAttribute(relPath); attr != "" {
+		reasons = append(reasons, fmt.Sprintf("the %s attribute in %s (see --no-gitattributes)", attr, source))
+	}
+
+	patterns := make([]string, 0, len(g.exclusionPatterns))
+	for pattern := range g.exclusionPatterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matchPattern(pattern, relPath, parts) {
+			reasons = append(reasons, fmt.Sprintf("pattern %q from %s", pattern, g.patternSource(pattern)))
+		}
+	}
+
+	filePath := filepath.Join(g.startPath, filepath.FromSlash(relPath))
+	info, err := g.fs.Lstat(filePath)
+	if err != nil || info.IsDir() {
+		return reasons
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return append(reasons, "being a symlink")
+	}
+	if kind := specialFileKind(info.Mode()); kind != "" {
+		return append(reasons, "being a "+kind)
+	}
+	head, err := g.readHead(filePath)
+	if err != nil {
+		return append(reasons, fmt.Sprintf("being unreadable (%v)", err))
+	}
+	for _, rule := range g.contentRules {
+		if rule.match(head) {
+			reasons = append(reasons, fmt.Sprintf("content rule %q from %s", rule.pattern, rule.source))
+		}
+	}
+	switch g.isForbiddenFile(filePath) {
+	case "binary":
+		if g.isForcedText(relPath) {
+			break
+		}
+		_, schema := schemaReaders[strings.ToLower(filepath.Ext(filePath))]
+		if !g.binaryMetadata && !(g.dataSchemas && schema) {
+			reasons = append(reasons, "binary content, only the path is listed (see --binary-metadata)")
+		}
+	case "private key":
+		reasons = append(reasons, "containing a private key")
+	}
+	return reasons
+}
+
+// patternSource returns where a path pattern was added: "default", "-e", "test
+// patterns" or the ignore file it was read from.
+func (g *Git2LLM) patternSource(pattern string) string {
+	if source, ok := g.patternSources[pattern]; ok {
+		return source
+	}
+	return "unknown source"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGit2LLMExplain(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		".llmignore":         "vendor/\n*.log\ncontent:DO NOT EDIT\n",
+		"main.go":            "package main\n",
+		"server_test.go":     "package main\n",
+		"vendor/lib.go":      "package lib\n",
+		"gen.go":             "// Code generated. DO NOT EDIT.\n",
+		"vendor/debug.log":   "log\n",
+		".env":               "KEY=value\n",
+		"internal/x/deep.go": "package x\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, io.Discard, false, true, false, []string{"*.md", ".git"}, "", false, WithMaxDepth(2))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	testCases := []struct {
+		path   string
+		expect []string
+	}{
+		{"main.go", nil},
+		{"server_test.go", []string{`pattern "*_test.go" from test patterns`}},
+		{"vendor/lib.go", []string{"the vendored code rule (see --include-vendored)", `pattern "vendor/" from .llmignore`}},
+		{"vendor/debug.log", []string{"the vendored code rule (see --include-vendored)", `pattern "*.log" from .llmignore`, `pattern "vendor/" from .llmignore`}},
+		{"gen.go", []string{`content rule "content:DO NOT EDIT" from .llmignore`}},
+		{"README.md", []string{`pattern "*.md" from -e`}},
+		{".git/config", []string{"the dotfile rule (see --include-dotfiles and --include)", `pattern ".git" from default`}},
+		{"internal/x/deep.go", []string{"--max-depth 2"}},
+	}
+	for _, tc := range testCases {
+		if reasons := git2llm.explain(tc.path); !reflect.DeepEqual(reasons, tc.expect) {
+			t.Errorf("For %s, expected %q, got %q", tc.path, tc.expect, reasons)
+		}
+	}
+}
+
+func TestExplainPath(t *testing.T) {
+	start := t.TempDir()
+	relPath, err := explainPath(start, filepath.Join(start, "pkg", "main.go"))
+	if err != nil || relPath != "pkg/main.go" {
+		t.Errorf("Expected pkg/main.go, got %q (%v)", relPath, err)
+	}
+	if _, err := explainPath(filepath.Join(start, "pkg"), filepath.Join(start, "other.go")); err == nil {
+		t.Error("Expected an error for a path outside the start path")
+	}
+
+	// A symlinked start path contains the paths below its target
+	link := filepath.Join(t.TempDir(), "work")
+	if err := os.Symlink(start, link); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+	relPath, err = explainPath(link, filepath.Join(start, "pkg", "main.go"))
+	if err != nil || relPath != "pkg/main.go" {
+		t.Errorf("Expected pkg/main.go below the symlinked start path, got %q (%v)", relPath, err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// exitUnchanged is the exit status when the fingerprint equals --if-changed.
+const exitUnchanged = 4
+
+// contentFiles returns the files whose contents are written, in output order.
+// They are collected once per scan, so Fingerprint can be called before Scan
+// without recording skipped files twice.
+func (g *Git2LLM) contentFiles() ([]manifestEntry, error) {
+	if g.contents != nil {
+		return g.contents, nil
+	}
+	files, err := g.collectFiles()
+	if err != nil {
+		return nil, err
+	}
+	files = g.filterOnlyPaths(files)
+	files = g.filterSince(files)
+	files = g.filterTests(files)
+	if g.order == OrderGrouped {
+		files = groupTests(files)
+	}
+	if g.docsFirst {
+		files = docsFirst(files)
+	}
+	if files == nil {
+		files = []manifestEntry{}
+	}
+	g.contents = files
+	return files, nil
+}
+
+// Fingerprint returns a hash of the paths and contents of the files a scan of
+// roots includes: the SHA-256 of a manifest listing the SHA-256 and path of
+// every file, sorted by path, like the output of sha256sum. It only changes
+// when a file is added, removed, renamed or edited; the options, the time and
+// the order of the files don't affect it. The files are read as they are, so
+// the fingerprint can be taken before the output is written.
+func Fingerprint(roots ...*Git2LLM) (string, error) {
+	sums := make(map[string]string)
+	for _, g := range roots {
+		files, err := g.contentFiles()
+		if err != nil {
+			return "", err
+		}
+		for _, f := range files {
+			sum, err := g.hashFile(f.path)
+			if err != nil {
+				// The scan skips the file as unreadable as well
+				g.logger.Debug("Error hashing file", "path", f.relPath, "error", err)
+				sum = "-"
+			}
+			sums[g.displayPath(f.relPath)] = sum
+		}
+	}
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s  %s\n", sums[p], p)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WithFileHashes appends the SHA-256 of every file whose content is written to
+// its header and adds it to its record in the JSON output, so a consumer can
+// tell which files changed since an earlier pack without comparing contents.
+// The hash is taken of the file on disk, as sha256sum prints it, so it doesn't
+// change with the options.
+func WithFileHashes(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.fileHashes = enabled
+	}
+}
+
+// hashFile returns the hex SHA-256 of the file at filePath.
+func (g *Git2LLM) hashFile(filePath string) (string, error) {
+	f, err := g.fs.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// outlinePatterns match the lines declaring public symbols, by language. Go
+// files are parsed instead.
+var outlinePatterns = map[string]*regexp.Regexp{
+	"python":     regexp.MustCompile(`(?m)^(?:    )?(?:async\s+)?(?:def|class)\s+[A-Za-z]\w*.*$`),
+	"javascript": regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:async\s+)?(?:function\*?|class|const|let|var)\b.*$`),
+	"jsx":        regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:async\s+)?(?:function\*?|class|const|let|var)\b.*$`),
+	"typescript": regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|var|interface|type|enum|namespace)\b.*$`),
+	"tsx":        regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|var|interface|type|enum|namespace)\b.*$`),
+	"java":       regexp.MustCompile(`(?m)^\s*public\s[^=;]*$`),
+	"csharp":     regexp.MustCompile(`(?m)^\s*public\s[^=;]*$`),
+	"kotlin":     regexp.MustCompile(`(?m)^\s*(?:(?:public|open|abstract|data|sealed|enum|inline|suspend|override)\s+)*(?:class|interface|object|fun)\s+[A-Za-z].*$`),
+	"rust":       regexp.MustCompile(`(?m)^\s*pub\s+(?:async\s+)?(?:unsafe\s+)?(?:fn|struct|enum|trait|type|const|static|mod)\b.*$`),
+	"ruby":       regexp.MustCompile(`(?m)^\s*(?:class|module|def)\s+.*$`),
+	"php":        regexp.MustCompile(`(?m)^\s*(?:(?:abstract|final)\s+)?(?:class|interface|trait|enum)\s+\w.*$|^\s*public\s+(?:static\s+)?function\s.*$`),
+	"swift":      regexp.MustCompile(`(?m)^\s*(?:public|open)\s.*$`),
+	"elixir":     regexp.MustCompile(`(?m)^\s*(?:defmodule|def)\s.*$`),
+}
+
+// focusOutliner is a Transformer keeping the files of the focus directories in
+// full and replacing all others with an outline of the symbols they export,
+// such as the signatures of the exported functions of a Go file. The docs in
+// the directories leading to the focus, such as the README of the start path,
+// are kept in full too. Files without an outline are dropped; the tree still
+// lists them.
+type focusOutliner struct {
+	focus     []string          // Slash separated, relative to the start path
+	prefix    string            // The path prefix of the root, as set by WithPathPrefix
+	languages map[string]string // As set by WithLanguages
+}
+
+func newFocusOutliner(focus []string, prefix string, languages map[string]string) Transformer {
+	return focusOutliner{focus: focus, prefix: prefix, languages: languages}
+}
+
+func (f focusOutliner) Transform(filePath string, content []byte) ([]byte, bool, error) {
+	relPath := filePath
+	if f.prefix != "" {
+		relPath = strings.TrimPrefix(filePath, f.prefix+"/")
+	}
+	if f.inFocus(relPath) {
+		return content, true, nil
+	}
+	lines := outline(filePath, content, f.languages)
+	if len(lines) == 0 {
+		return nil, false, nil
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[Outline of %s outside the focus: its exported symbols; the full content is not included]\n", filePath)
+	for _, line := range lines {
+		out.WriteString(strings.TrimRight(line, " \t") + "\n")
+	}
+	return out.Bytes(), true, nil
+}
+
+// outline returns the lines declaring the exported symbols of a file, or nil
+// if it has none or its language has no outline.
+func outline(filePath string, content []byte, languages map[string]string) []string {
+	if strings.HasSuffix(filePath, ".go") {
+		return outlineGo(filePath, content)
+	}
+	re, ok := outlinePatterns[languageOf(filePath, languages)]
+	if !ok {
+		return nil
+	}
+	var lines []string
+	for _, line := range re.FindAll(content, -1) {
+		lines = append(lines, strings.TrimRight(strings.TrimRight(string(line), " \t\r"), "{:"))
+	}
+	return lines
+}
+
+// inFocus reports whether the file at relPath is in one of the focus
+// directories, or is a doc directly in a directory leading to one, see
+// isDocName. Other files on the way are outlined, so a flat repository
+// shrinks too.
+func (f focusOutliner) inFocus(relPath string) bool {
+	dir := path.Dir(relPath)
+	for _, p := range f.focus {
+		if relPath == p || strings.HasPrefix(relPath, p+"/") {
+			return true
+		}
+		if (dir == "." || strings.HasPrefix(p, dir+"/")) && isDocName(path.Base(relPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// outlineGo returns the package clause and the exported declarations of a Go
+// file without their bodies, or nil if it doesn't parse.
+func outlineGo(filePath string, content []byte) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	text := func(from, to token.Pos) string {
+		return strings.Join(strings.Fields(string(content[fset.Position(from).Offset:fset.Position(to).Offset])), " ")
+	}
+
+	lines := []string{"package " + file.Name.Name}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() || d.Recv != nil && len(d.Recv.List) > 0 && !ast.IsExported(receiverType(d.Recv.List[0].Type)) {
+				continue
+			}
+			end := d.End()
+			if d.Body != nil {
+				end = d.Body.Pos()
+			}
+			lines = append(lines, text(d.Pos(), end))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					line := "type " + text(s.Name.Pos(), s.Type.Pos()) + " "
+					switch s.Type.(type) {
+					case *ast.StructType:
+						line += "struct"
+					case *ast.InterfaceType:
+						line += "interface"
+					default:
+						line += text(s.Type.Pos(), s.Type.End())
+					}
+					lines = append(lines, line)
+				case *ast.ValueSpec:
+					var names []string
+					for _, name := range s.Names {
+						if name.IsExported() {
+							names = append(names, name.Name)
+						}
+					}
+					if len(names) == 0 {
+						continue
+					}
+					line := d.Tok.String() + " " + strings.Join(names, ", ")
+					if s.Type != nil {
+						line += " " + text(s.Type.Pos(), s.Type.End())
+					}
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	if len(lines) == 1 {
+		return nil
+	}
+	return lines
+}
+
+// cleanFocus returns the paths of --focus as clean slash separated paths
+// relative to the start path. "." stands for everything, so it empties the
+// focus.
+func cleanFocus(paths []string) ([]string, error) {
+	var focus []string
+	for _, p := range paths {
+		clean := path.Clean(filepath.ToSlash(p))
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("invalid --focus %s: must be inside the start path", p)
+		}
+		if clean == "." {
+			return nil, nil
+		}
+		focus = append(focus, clean)
+	}
+	return focus, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFocusOutliner(t *testing.T) {
+	f := newFocusOutliner([]string{"pkg/api"}, "", map[string]string{".pyx": "python"})
+
+	goSource := `package store
+
+import "context"
+
+// Store keeps things.
+type Store struct {
+	db string
+}
+
+type cache map[string]string
+
+type Loader interface {
+	Load(ctx context.Context) error
+}
+
+const Version, internal = "1", 2
+
+var ErrNotFound error
+
+func New(db string) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Get(ctx context.Context,
+	key string) (string, error) {
+	return "", nil
+}
+
+func (c cache) Get(key string) string { return c[key] }
+
+func helper() {}
+`
+	got, keep, err := f.Transform("pkg/store/store.go", []byte(goSource))
+	if err != nil || !keep {
+		t.Fatalf("Expected the Go file to be kept, got %v, %v", keep, err)
+	}
+	expected := "[Outline of pkg/store/store.go outside the focus: its exported symbols; the full content is not included]\n" +
+		"package store\n" +
+		"type Store struct\n" +
+		"type Loader interface\n" +
+		"const Version\n" +
+		"var ErrNotFound error\n" +
+		"func New(db string) *Store\n" +
+		"func (s *Store) Get(ctx context.Context, key string) (string, error)\n"
+	if string(got) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	got, keep, _ = f.Transform("tools/gen.pyx", []byte("import os\n\nclass Gen:\n    def run(self):\n        pass\n\n    def _private(self):\n        pass\n\ndef main():\n    pass\n"))
+	if !keep || !strings.HasSuffix(string(got), "]\nclass Gen\n    def run(self)\ndef main()\n") {
+		t.Errorf("Expected the outline of the Python file, got %q", got)
+	}
+
+	for _, p := range []string{"pkg/api/handler.go", "pkg/api/v1/notes.txt", "README.md", "pkg/CONTRIBUTING.md"} {
+		if got, keep, _ := f.Transform(p, []byte("content")); !keep || string(got) != "content" {
+			t.Errorf("Expected %s to be kept in full, got %q", p, got)
+		}
+	}
+	// Only the docs on the way to the focus are kept in full
+	got, keep, _ = f.Transform("main.go", []byte("package main\n\nfunc Run() {}\n\nfunc main() {}\n"))
+	if !keep || !strings.HasSuffix(string(got), "]\npackage main\nfunc Run()\n") {
+		t.Errorf("Expected the outline of main.go, got %q", got)
+	}
+	for _, p := range []string{"pkg/store/notes.txt", "web/private.go", "pkg/go.mod", "Makefile", "pkg/store/README.md"} {
+		if _, keep, _ := f.Transform(p, []byte("package web\n\nfunc private() {}\n")); keep {
+			t.Errorf("Expected %s without an outline to be dropped", p)
+		}
+	}
+}
+
+func TestFocusOutlinerPrefix(t *testing.T) {
+	f := newFocusOutliner([]string{"pkg/api"}, "backend", nil)
+	if got, keep, _ := f.Transform("backend/pkg/api/handler.go", []byte("content")); !keep || string(got) != "content" {
+		t.Errorf("Expected the prefixed file in the focus to be kept in full, got %q", got)
+	}
+	got, keep, _ := f.Transform("backend/pkg/store/store.go", []byte("package store\n\nfunc New() {}\n"))
+	if !keep || !strings.HasPrefix(string(got), "[Outline of backend/pkg/store/store.go ") {
+		t.Errorf("Expected an outline with the prefixed path, got %q", got)
+	}
+}
+
+func TestCleanFocus(t *testing.T) {
+	focus, err := cleanFocus([]string{"./pkg/api/", "web"})
+	if err != nil || strings.Join(focus, ",") != "pkg/api,web" {
+		t.Errorf("Expected pkg/api and web, got %v, %v", focus, err)
+	}
+	if focus, err := cleanFocus([]string{"pkg", "."}); err != nil || focus != nil {
+		t.Errorf("Expected . to empty the focus, got %v, %v", focus, err)
+	}
+	if _, err := cleanFocus([]string{"../other"}); err == nil {
+		t.Error("Expected an error for a path outside the start path")
+	}
+}
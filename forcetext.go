@@ -0,0 +1,32 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// WithForceText includes the content of files matching the patterns even if
+// they look binary because of NUL bytes, e.g. old text fixtures. Patterns are
+// slash separated and relative to the start path, ** matches any number of
+// directories, and patterns without a slash match the file name in any directory.
+// The same patterns can be given as "!binary:" rules in the .llmignore.
+func WithForceText(patterns ...string) Option {
+	return func(g *Git2LLM) {
+		g.forceText = append(g.forceText, patterns...)
+	}
+}
+
+// isForcedText reports whether the file at relPath is included as text regardless of its content.
+func (g *Git2LLM) isForcedText(relPath string) bool {
+	for _, pattern := range g.forceText {
+		if matchGlob(pattern, relPath) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := path.Match(pattern, path.Base(relPath)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
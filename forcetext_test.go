@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMForceText(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		".llmignore":             "!binary:assets/schema.bin\n",
+		"assets/schema.bin":      "schema\x00v1\n",
+		"fixtures/old/input.dat": "record\x00one\n",
+		"fixtures/other.bin":     "\x00\x01\x02",
+		"image.bin":              "\x00\x01\x02",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithForceText("*.dat"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expect := range []string{
+		"Content of assets/schema.bin:\nschemav1\n",
+		"Content of fixtures/old/input.dat:\nrecordone\n",
+		"Content of fixtures/other.bin: (Skipped - Binary File)",
+		"Content of image.bin: (Skipped - Binary File)",
+	} {
+		if !strings.Contains(result, expect) {
+			t.Errorf("Expected %q in output:\n%s", expect, result)
+		}
+	}
+	if reasons := git2llm.explain("assets/schema.bin"); len(reasons) != 0 {
+		t.Errorf("Expected assets/schema.bin to be included, got %q", reasons)
+	}
+
+	if err := git2llm.addPattern("!binary:", "-e"); err == nil {
+		t.Error("Expected an error for an empty !binary: pattern")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WithFrontMatter starts the output with a YAML front matter block recording
+// how it was made: the version, the time, the start paths, the model, options,
+// the fingerprint of the files and the number of files and tokens. The command line options are passed by
+// the caller, e.g. "e=vendor". As the totals are only known at the end, the
+// output is spooled to a temporary file until then.
+func WithFrontMatter(options ...string) Option {
+	return func(g *Git2LLM) {
+		g.frontMatter = true
+		g.frontMatterOptions = options
+	}
+}
+
+// Metadata describes how a pack was made.
+type Metadata struct {
+	Tool        string   `json:"tool"`
+	Version     string   `json:"version"`
+	Generated   string   `json:"generated"`
+	StartPaths  []string `json:"start_paths"`
+	Options     []string `json:"options"`
+	Fingerprint string   `json:"fingerprint"`     // Of the paths and contents of the files, see Fingerprint
+	Model       string   `json:"model,omitempty"` // Only if tokens are counted
+	Files       int      `json:"files"`
+	Tokens      int      `json:"tokens,omitempty"` // Only if tokens are counted
+}
+
+// newMetadata returns the metadata of a scan of roots, without the totals.
+func newMetadata(roots []*Git2LLM) *Metadata {
+	m := &Metadata{
+		Tool:      "git2llm",
+		Version:   strings.TrimSpace(embeddedVersion),
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Options:   roots[0].frontMatterOptions,
+	}
+	if m.Options == nil {
+		m.Options = []string{}
+	}
+	for _, g := range roots {
+		m.StartPaths = append(m.StartPaths, g.startPath)
+	}
+	if roots[0].countTokens {
+		m.Model = roots[0].model
+	}
+	return m
+}
+
+// yaml returns the metadata as a YAML front matter block, followed by an empty
+// line. Values are written as JSON, which is valid YAML, so any path or option
+// is quoted correctly. The number of lines doesn't depend on the totals.
+func (m *Metadata) yaml() (string, error) {
+	var b strings.Builder
+	b.WriteString("---\n")
+	type field struct {
+		key   string
+		value any
+	}
+	fields := []field{
+		{"tool", m.Tool},
+		{"version", m.Version},
+		{"generated", m.Generated},
+		{"start_paths", m.StartPaths},
+		{"options", m.Options},
+		{"fingerprint", m.Fingerprint},
+		{"files", m.Files},
+	}
+	if m.Model != "" {
+		fields = append(fields, field{"model", m.Model}, field{"tokens", m.Tokens})
+	}
+	for _, f := range fields {
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return "", fmt.Errorf("json.Marshal: %w", err)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f.key, value)
+	}
+	b.WriteString("---\n\n")
+	return b.String(), nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScanFrontMatter(t *testing.T) {
+	tempDir := t.TempDir()
+	for fileName, content := range map[string]string{"a.go": "package a\n", "b.go": "package b\n"} {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var text, md, js strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &text, false, false, true, nil, "estimate", false,
+		WithFrontMatter("toc=true", "c=true"), WithTableOfContents(true),
+		withEmitters(emitFormats["md"](&md), emitFormats["json"](&js)))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	output := text.String()
+	for _, expected := range []string{"---\ntool: \"git2llm\"\n", "start_paths: [" + mustJSON(t, tempDir) + "]\n", "options: [\"toc=true\",\"c=true\"]\n", "files: 2\nmodel: \"estimate\"\n", "---\n\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the front matter. Result:\n%s", expected, output)
+		}
+	}
+	if !strings.HasPrefix(output, "---\n") || !strings.Contains(output, "tokens: "+mustJSON(t, result.Tokens)+"\n") {
+		t.Errorf("Expected the output to start with the front matter and the tokens of the scan. Result:\n%s", output)
+	}
+
+	// The table of contents gives the lines of the output, front matter included
+	lines := strings.Split(output, "\n")
+	entries := regexp.MustCompile(`(?m)^(\S+) \(line (\d+)\)$`).FindAllStringSubmatch(output, -1)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 TOC entries. Result:\n%s", output)
+	}
+	for _, entry := range entries {
+		n, _ := strconv.Atoi(entry[2])
+		if n < 1 || n > len(lines) || lines[n-1] != "File: "+entry[1] {
+			t.Errorf("TOC entry %q does not point at the start of %s", entry[0], entry[1])
+		}
+	}
+
+	if !strings.HasPrefix(md.String(), "---\ntool: \"git2llm\"\n") || !strings.Contains(md.String(), "## a.go\n") {
+		t.Errorf("Expected the markdown to start with the front matter. Result:\n%s", md.String())
+	}
+	var doc struct {
+		Metadata Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(js.String()), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, js.String())
+	}
+	if doc.Metadata.Tool != "git2llm" || doc.Metadata.Files != 2 || doc.Metadata.Tokens != result.Tokens {
+		t.Errorf("Expected the metadata in the JSON, got %+v", doc.Metadata)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return string(data)
+}
@@ -3,14 +3,28 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/perbu/git2llm/tokens"
 )
@@ -18,15 +32,30 @@ import (
 const (
 	exclusionFile   = ".llmignore"
 	secretKeyMarker = "PRIVATE KEY"
+	// contentScanSize is how much of a file is inspected for binary data,
+	// private keys and content exclusion rules.
+	contentScanSize = 16384
 )
 
+// Prefixes for exclusion patterns that match on file content rather than path.
+const (
+	contentPrefix      = "content:"
+	contentRegexPrefix = "content-regex:"
+)
+
+// forceTextPrefix marks a pattern of files whose content is included even though
+// it looks binary, see WithForceText.
+const forceTextPrefix = "!binary:"
+
 //go:embed test-patterns.txt
 var testPatterns string
 
 //go:embed .version
 var embeddedVersion string
 
-// FS defines an interface for file system operations to improve testability.
+// FS defines the file system operations a scan uses. Every pass reads through
+// it, so a scan works the same on the working tree (OSFS), a git ref, a GitHub
+// repository or any io/fs file system (IOFS).
 type FS interface {
 	Open(name string) (File, error)
 	ReadDir(name string) ([]os.DirEntry, error)
@@ -70,32 +99,202 @@ type Git2LLM struct {
 	startPath               string
 	fileTypes               []string
 	exclusionPatterns       map[string]bool
+	matcher                 *patternMatcher   // exclusionPatterns, compiled on first use
+	patternSources          map[string]string // pattern -> where it was added, see explain
+	contentRules            []contentRule
 	verbose                 bool
 	excludeTests            bool
 	countTokens             bool
 	counter                 *tokens.Counter
-	tokens                  int
-	testPatternsFileContent string
+	pool                    *tokens.Pool // tokenizer workers during the content pass
+	tokens                  atomic.Int64
+	files                   int // files whose content was written
+	results                 []*FileResult
+	testPatternsPerLanguage map[string][]string // Language -> patterns, see WithTestPatterns
+	testLanguages           []string            // The languages of testPatternsPerLanguage that apply, all if empty
 	version                 string
 	model                   string
 	noRecurse               bool
+	maxDepth                int
+	tokenCache              *tokenCache
+	offline                 bool
+	pathPrefix              string
+	rootLabel               string
+	noTree                  bool
+	order                   string
+	docsFirst               bool
+	contents                []manifestEntry // The files of the current scan, see contentFiles
+	policy                  map[string]PolicyAction
+	maxFileSize             int64
+	maxFileTokens           int
+	maxBytes                int64
+	budget                  *outputBudget // Shared by the roots during a scan with a limit of bytes
+	violations              []PolicyViolation
+	pii                     *piiMasker // Set if personal data is detected, see WithPolicy
+	scope                   []string
+	languages               map[string]string // Overrides by extension or file name, see WithLanguages
+	emitters                []emitter
+	maxLineLength           int
+	delimiters              *Delimiters // nil for defaultDelimiters
+	noDefaultDotfiles       bool
+	frontMatter             bool
+	frontMatterOptions      []string
+	progressWriter          io.Writer
+	skipped                 []SkippedFile
+	redactPatterns          []string
+	transformers            []Transformer
+	sampleRows              int // See WithSampleData
+	onlyPaths               map[string]bool
+	since                   time.Time
+	onlyTests               bool
+	withTested              bool
+	gitIgnored              map[string]bool
+	contentHashes           map[[sha256.Size]byte]string
+	fileHashes              bool
+	commits                 map[string]CommitInfo // By path relative to the start path, with WithFileGitInfo
+	tableOfContents         bool
+	overview                bool
+	codeMap                 bool
+	dependencies            bool
+	workingDiff             bool
+	toc                     *tableOfContents
+	binaryMetadata          bool
+	noSkipPlaceholders      bool
+	images                  bool
+	imageDescriber          imageStreamer
+	forceText               []string
+	sanitize                bool
+	keepCRLF                bool
+	asciiTree               bool
+	treeStats               bool
+	dataSchemas             bool
+	includeDotfiles         bool
+	includeVendored         bool
+	noGitAttributes         bool
+	attributeRules          map[string][]attributeRule // By directory, read on first use
+	dotfileIncludes         []string
+	logger                  *slog.Logger
+	ignoreFile              string
+	noDefaultExcludes       bool
+	defaultExcludesFile     string
+	allowEntries            []string        // As given to WithAllow
+	allowPatterns           []string        // Path patterns of the allowlist
+	allowHashes             map[string]bool // SHA-256 fingerprints of the allowlist
+	checkpoint              func(path string)
+	resumeAfter             string
+	resumeSkip              *resumeSkip // Shared by all roots while resuming
+	stop                    <-chan struct{}
+	interrupted             int // Files left out after the scan was interrupted
+}
+
+// Option configures optional behavior of a Git2LLM instance.
+type Option func(*Git2LLM)
+
+// WithIgnoreFile reads the exclusion patterns from the local file at path
+// instead of the .llmignore in the start path.
+func WithIgnoreFile(path string) Option {
+	return func(g *Git2LLM) {
+		g.ignoreFile = path
+	}
+}
+
+// WithDefaultExcludes applies the default exclusion patterns (.git, .idea,
+// go.sum, ...). It is enabled by default.
+func WithDefaultExcludes(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.noDefaultExcludes = !enabled
+	}
+}
+
+// WithDefaultExcludesFile reads the default exclusion patterns from the local
+// file at path instead of using the built-in ones.
+func WithDefaultExcludesFile(path string) Option {
+	return func(g *Git2LLM) {
+		g.defaultExcludesFile = path
+	}
+}
+
+// WithMaxDepth limits the scan to n directory levels below the start path.
+// A depth of 1 only includes the start directory itself; 0 means unlimited.
+func WithMaxDepth(n int) Option {
+	return func(g *Git2LLM) {
+		g.maxDepth = n
+	}
+}
+
+// WithTokenCache persists per-file token counts in the file at path, so
+// unchanged files are not tokenized again on the next run.
+func WithTokenCache(path string) Option {
+	return withTokenCache(loadTokenCache(path))
+}
+
+// withTokenCache uses an already loaded cache, so several roots can share it.
+func withTokenCache(cache *tokenCache) Option {
+	return func(g *Git2LLM) {
+		g.tokenCache = cache
+	}
+}
+
+// WithOffline forbids downloading the tokenizer of the model, so counting
+// fails if it isn't cached yet.
+func WithOffline(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.offline = enabled
+	}
+}
+
+// WithPathPrefix prefixes all emitted paths and labels the tree root with prefix.
+func WithPathPrefix(prefix string) Option {
+	return func(g *Git2LLM) {
+		g.pathPrefix = strings.TrimSuffix(filepath.ToSlash(prefix), "/")
+	}
+}
+
+// WithRootLabel labels the root line of the directory tree with label instead
+// of "/". The emitted paths are not changed; see WithPathPrefix for that.
+func WithRootLabel(label string) Option {
+	return func(g *Git2LLM) {
+		g.rootLabel = strings.TrimSuffix(filepath.ToSlash(label), "/")
+	}
+}
+
+// WithTree controls whether the directory structure is written. Without it,
+// the directories are not traversed for the tree and its tokens are not counted.
+func WithTree(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.noTree = !enabled
+	}
+}
+
+// WithASCIITree draws the directory tree with ASCII characters, such as |--
+// and `--, instead of Unicode box drawing, for terminals and chats that mangle it.
+func WithASCIITree(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.asciiTree = enabled
+	}
+}
+
+// WithRedactPatterns sets the file patterns whose values are replaced with a
+// placeholder, keeping only the keys. An empty list disables redaction.
+func WithRedactPatterns(patterns []string) Option {
+	return func(g *Git2LLM) {
+		g.redactPatterns = patterns
+	}
 }
 
 // NewGit2LLM creates a new Git2LLM instance with the provided configuration
-func NewGit2LLM(startPath string, fileTypes []string, fs FS, outputWriter io.Writer, verbose bool, excludeTests bool, countTokens bool, excludePatterns []string, model string, noRecurse bool) (*Git2LLM, error) {
+func NewGit2LLM(startPath string, fileTypes []string, fs FS, outputWriter io.Writer, verbose bool, excludeTests bool, countTokens bool, excludePatterns []string, model string, noRecurse bool, opts ...Option) (*Git2LLM, error) {
 	if fs == nil {
 		fs = OSFS{}
 	}
 	if outputWriter == nil {
 		outputWriter = os.Stdout
 	}
-
-	var counter *tokens.Counter
-	if countTokens {
-		var err error
-		counter, err = tokens.New(model)
-		if err != nil {
-			return nil, fmt.Errorf("tokens.New(): %w", err)
+	// Walk the real directory if the start path is a symlink, such as
+	// ~/work -> /mnt/work, so every path is relative to the same root
+	if _, ok := fs.(OSFS); ok {
+		if resolved, err := filepath.EvalSymlinks(startPath); err == nil {
+			startPath = resolved
 		}
 	}
 
@@ -107,23 +306,56 @@ func NewGit2LLM(startPath string, fileTypes []string, fs FS, outputWriter io.Wri
 		verbose:                 verbose,
 		excludeTests:            excludeTests,
 		countTokens:             countTokens,
-		counter:                 counter,
-		testPatternsFileContent: testPatterns,
+		testPatternsPerLanguage: builtinTestPatterns,
 		version:                 embeddedVersion,
 		model:                   model,
 		noRecurse:               noRecurse,
+		redactPatterns:          defaultRedactPatterns,
+		sanitize:                true,
+		logger:                  newLogger(os.Stderr, logLevel(verbose), false),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	// Files are counted against the limit even if the output isn't
+	if countTokens || g.maxFileTokens > 0 {
+		counter, err := tokens.New(model, tokens.WithOffline(g.offline))
+		if err != nil {
+			return nil, fmt.Errorf("tokens.New(): %w", err)
+		}
+		g.counter = counter
 	}
 
-	// Load exclusion patterns from .llmignore file in the start path
+	// Load exclusion patterns from .llmignore file in the start path, unless another file was given
 	llmignorePath := filepath.Join(startPath, exclusionFile)
+	if g.ignoreFile != "" {
+		llmignorePath = ""
+	}
 	err := g.loadExclusionPatterns(llmignorePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load exclusion patterns: %w", err)
 	}
+	if g.ignoreFile != "" {
+		if err := g.loadIgnoreFile(g.ignoreFile); err != nil {
+			return nil, fmt.Errorf("failed to load exclusion patterns: %w", err)
+		}
+	}
+
+	if err := g.loadAllowlist(); err != nil {
+		return nil, fmt.Errorf("failed to load allowlist: %w", err)
+	}
 
 	// Add custom exclude patterns from flags
 	for _, pattern := range excludePatterns {
-		g.exclusionPatterns[pattern] = true
+		if err := g.addPattern(pattern, "-e"); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, language := range g.testLanguages {
+		if _, ok := g.testPatternsPerLanguage[language]; !ok {
+			return nil, fmt.Errorf("unknown test language %s, known: %s", language, strings.Join(slices.Sorted(maps.Keys(g.testPatternsPerLanguage)), ", "))
+		}
 	}
 
 	// Add test patterns if excluding tests
@@ -136,22 +368,12 @@ func NewGit2LLM(startPath string, fileTypes []string, fs FS, outputWriter io.Wri
 
 // loadTestPatterns adds test patterns to exclusion patterns
 func (g *Git2LLM) loadTestPatterns() {
-	testPatterns := strings.Split(g.testPatternsFileContent, "\n")
-	patterns := 0
-	for _, pattern := range testPatterns {
-		i := strings.Index(pattern, "#")
-		if i != -1 {
-			pattern = pattern[:i]
-		}
-		pattern = strings.TrimSpace(pattern)
-		if pattern != "" {
-			g.exclusionPatterns[pattern] = true
-			patterns++
-		}
-	}
-	if g.verbose {
-		fmt.Fprintf(os.Stderr, "Excluded %d test patterns\n", patterns)
+	patterns := g.testPatterns()
+	for pattern := range patterns {
+		g.exclusionPatterns[pattern] = true
+		g.recordSource(pattern, "test patterns")
 	}
+	g.logger.Debug("Excluded test patterns", "patterns", len(patterns))
 }
 
 // stringSliceFlag is a custom flag type that allows for multiple string values
@@ -166,35 +388,151 @@ func (s *stringSliceFlag) Set(value string) error {
 	return nil
 }
 
+// contentRule excludes files whose leading bytes match a literal string or a regular expression.
+type contentRule struct {
+	pattern string
+	source  string
+	literal []byte
+	re      *regexp.Regexp
+}
+
+func (r contentRule) match(content []byte) bool {
+	if r.re != nil {
+		return r.re.Match(content)
+	}
+	return bytes.Contains(content, r.literal)
+}
+
+// addPattern adds an exclusion pattern from source, e.g. "-e" or the name of an
+// ignore file. Patterns prefixed with "content:" or "content-regex:" become
+// content rules, "!binary:" patterns force text, and everything else is a path pattern.
+func (g *Git2LLM) addPattern(pattern, source string) error {
+	switch {
+	case strings.HasPrefix(pattern, forceTextPrefix):
+		glob := strings.TrimPrefix(pattern, forceTextPrefix)
+		if glob == "" {
+			return fmt.Errorf("empty %s pattern", forceTextPrefix)
+		}
+		g.forceText = append(g.forceText, glob)
+	case strings.HasPrefix(pattern, contentRegexPrefix):
+		re, err := regexp.Compile("(?m)" + strings.TrimPrefix(pattern, contentRegexPrefix))
+		if err != nil {
+			return fmt.Errorf("regexp.Compile: %w", err)
+		}
+		g.contentRules = append(g.contentRules, contentRule{pattern: pattern, source: source, re: re})
+	case strings.HasPrefix(pattern, contentPrefix):
+		literal := strings.TrimPrefix(pattern, contentPrefix)
+		if literal == "" {
+			return fmt.Errorf("empty content pattern")
+		}
+		g.contentRules = append(g.contentRules, contentRule{pattern: pattern, source: source, literal: []byte(literal)})
+	default:
+		// Patterns use forward slashes like paths, also when written with
+		// backslashes on Windows
+		pattern = filepath.ToSlash(pattern)
+		g.exclusionPatterns[pattern] = true
+		g.recordSource(pattern, source)
+	}
+	return nil
+}
+
+// recordSource remembers where a path pattern was added first.
+func (g *Git2LLM) recordSource(pattern, source string) {
+	if g.patternSources == nil {
+		g.patternSources = make(map[string]string)
+	}
+	if _, ok := g.patternSources[pattern]; !ok {
+		g.patternSources[pattern] = source
+	}
+}
+
 // loadExclusionPatterns reads exclusion patterns from a file.
 func (g *Git2LLM) loadExclusionPatterns(filePath string) error {
-	patterns := defaultPatterns()
-	if filePath != "" {
-		file, err := g.fs.Open(filePath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				g.exclusionPatterns = patterns
-				return nil // Exclusion file is optional
-			}
-			return fmt.Errorf("error opening exclusion file: %w", err)
+	g.exclusionPatterns = make(map[string]bool)
+	switch {
+	case g.noDefaultExcludes:
+	case g.defaultExcludesFile != "":
+		if err := g.loadIgnoreFile(g.defaultExcludesFile); err != nil {
+			return err
 		}
-		defer file.Close()
+	default:
+		g.exclusionPatterns = defaultPatterns()
+		for pattern := range g.exclusionPatterns {
+			g.recordSource(pattern, "default")
+		}
+	}
+	if filePath == "" {
+		return nil
+	}
+	file, err := g.fs.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Exclusion file is optional
+		}
+		return fmt.Errorf("error opening exclusion file: %w", err)
+	}
+	defer file.Close()
+	return g.readPatterns(file, exclusionFile)
+}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line != "" && !strings.HasPrefix(line, "#") {
-				patterns[line] = true
+// loadIgnoreFile reads exclusion patterns from a local file given by the user,
+// which must exist. It is read from disk even when scanning another file system.
+func (g *Git2LLM) loadIgnoreFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening exclusion file: %w", err)
+	}
+	defer file.Close()
+	return g.readPatterns(file, filePath)
+}
+
+// readPatterns adds the patterns in r, one per line, naming source as their
+// origin. Empty lines and comments are ignored, see parsePatternLine.
+func (g *Git2LLM) readPatterns(r io.Reader, source string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if pattern := parsePatternLine(scanner.Text()); pattern != "" {
+			if err := g.addPattern(pattern, source); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
 			}
 		}
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("error reading exclusion file: %w", err)
-		}
 	}
-	g.exclusionPatterns = patterns
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading exclusion file: %w", err)
+	}
 	return nil
 }
 
+// parsePatternLine returns the pattern on a line of an ignore file, or "" for
+// none. As in .gitignore, a line starting with # is a comment and trailing
+// whitespace is dropped unless escaped with a backslash. In addition, # after
+// whitespace starts a comment, e.g. "vendor/ # third party". \# is a literal #.
+func parsePatternLine(line string) string {
+	line = strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	var b strings.Builder
+	keep := 0           // Length of the pattern up to the last escaped character
+	afterSpace := false // The previous character is unescaped whitespace
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) && strings.IndexByte("# \t", line[i+1]) >= 0 {
+			i++
+			b.WriteByte(line[i])
+			keep, afterSpace = b.Len(), false
+			continue
+		}
+		if c == '#' && afterSpace {
+			break // The rest is a comment
+		}
+		b.WriteByte(c)
+		afterSpace = c == ' ' || c == '\t'
+	}
+	pattern := b.String()
+	return pattern[:keep] + strings.TrimRight(pattern[keep:], " \t\r")
+}
+
 // defaultPatterns returns a map of default exclusion patterns.
 // the default is to ignore the .git directory.
 func defaultPatterns() map[string]bool {
@@ -209,49 +547,77 @@ func defaultPatterns() map[string]bool {
 }
 
 // isExcluded checks if a path is excluded based on exclusion patterns.
-func (g *Git2LLM) isExcluded(path string) bool {
+// relPath uses forward slashes, see relPath, so a backslash is part of a name
+// on Unix.
+func (g *Git2LLM) isExcluded(relPath string) bool {
 	// Check if any part of the path is a dotfile/dotfolder
-	parts := strings.Split(path, string(os.PathSeparator))
+	parts := strings.Split(relPath, "/")
+	if g.isHidden(relPath, parts) {
+		return true
+	}
+	if g.isGitIgnored(relPath) {
+		return true
+	}
+	if g.isVendored(parts) {
+		return true
+	}
+	if attr, _ := g.excludingAttribute(relPath); attr != "" {
+		return true
+	}
+
+	if g.matcher == nil {
+		g.matcher = compilePatterns(g.exclusionPatterns)
+	}
+	return g.matcher.match(relPath, parts)
+}
+
+// matchPattern reports whether the exclusion pattern matches relPath, whose
+// elements are parts. isExcluded uses a patternMatcher with the same result.
+func matchPattern(pattern, relPath string, parts []string) bool {
+	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(relPath, pattern[1:]) || relPath == pattern[1:len(pattern)-1]
+	} else if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(relPath, pattern) || relPath == pattern[:len(pattern)-1]
+	} else if strings.HasPrefix(pattern, "/") {
+		return relPath == pattern[1:] || strings.HasPrefix(relPath, pattern[1:]+"/")
+	}
+	if matched, _ := path.Match(pattern, relPath); matched {
+		return true
+	}
 	for _, part := range parts {
-		if part != "" && strings.HasPrefix(part, ".") {
+		if matched, _ := path.Match(pattern, part); matched {
 			return true
 		}
 	}
+	return false
+}
 
-	for pattern := range g.exclusionPatterns {
-		if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
-			if strings.HasPrefix(path, pattern[1:]) || path == pattern[1:len(pattern)-1] {
-				return true
-			}
-		} else if strings.HasSuffix(pattern, "/") {
-			if strings.HasPrefix(path, pattern) || path == pattern[:len(pattern)-1] {
-				return true
-			}
-		} else if strings.HasPrefix(pattern, "/") {
-			if path == pattern[1:] || strings.HasPrefix(path, pattern[1:]+string(os.PathSeparator)) {
-				return true
-			}
-		} else {
-			if matched, _ := filepath.Match(pattern, path); matched {
-				return true
-			}
-			parts := strings.Split(path, string(os.PathSeparator))
-			for _, part := range parts {
-				if matched, _ := filepath.Match(pattern, part); matched {
-					return true
-				}
-			}
-		}
+// relPath returns the path of fullPath relative to the start path, using forward slashes.
+func (g *Git2LLM) relPath(fullPath string) (string, error) {
+	relPath, err := filepath.Rel(g.startPath, fullPath)
+	if err != nil {
+		return "", fmt.Errorf("error getting relative path: %w", err)
 	}
-	return false
+	if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("error getting relative path: %s is not below %s", fullPath, g.startPath)
+	}
+	return filepath.ToSlash(relPath), nil
 }
 
 // generateDirectoryStructureString generates a string representation of the directory structure.
 func (g *Git2LLM) generateDirectoryStructureString() (string, error) {
 	var tree strings.Builder
 
-	var generateTree func(dirPath string, prefix string) error
-	generateTree = func(dirPath string, prefix string) error {
+	var stats map[string]*dirStats
+	if g.treeStats {
+		var err error
+		if stats, err = g.collectTreeStats(); err != nil {
+			return "", err
+		}
+	}
+
+	var generateTree func(dirPath string, prefix string, depth int) error
+	generateTree = func(dirPath string, prefix string, depth int) error {
 		entries, err := g.fs.ReadDir(dirPath)
 		if err != nil {
 			return fmt.Errorf("error reading directory: %w", err)
@@ -267,53 +633,79 @@ func (g *Git2LLM) generateDirectoryStructureString() (string, error) {
 			return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name()) // Then alphabetical
 		})
 
-		for i, entry := range entries {
+		// Filter first, so the last entry shown gets the closing connector
+		shown := entries[:0]
+		for _, entry := range entries {
 			entryName := entry.Name()
-			relPath, err := filepath.Rel(g.startPath, filepath.Join(dirPath, entryName))
+			relPath, err := g.relPath(filepath.Join(dirPath, entryName))
 			if err != nil {
-				return fmt.Errorf("error getting relative path: %w", err)
+				return err
 			}
 
-			if g.isExcluded(relPath) {
+			if !g.inScope(relPath, entry.IsDir()) || g.isExcluded(relPath) || (entry.IsDir() && g.isVendoredDir(entryName)) {
 				continue
 			}
 
+			fullPath := filepath.Join(dirPath, entryName)
+
 			// Skip files that don't match fileTypes filter
-			if !entry.IsDir() && g.fileTypes != nil && len(g.fileTypes) > 0 {
-				var matched bool
-				for _, ext := range g.fileTypes {
-					if strings.HasSuffix(entryName, ext) {
-						matched = true
-						break
-					}
-				}
-				if !matched {
-					continue
-				}
+			if !entry.IsDir() && !g.matchesFileType(fullPath, entryName) {
+				continue
+			}
+
+			if !entry.IsDir() && g.matchContentRule(fullPath) != "" {
+				continue
 			}
+			shown = append(shown, entry)
+		}
+		entries = shown
+
+		for i, entry := range entries {
+			entryName := entry.Name()
+			fullPath := filepath.Join(dirPath, entryName)
 
 			var connector string
 			var newPrefix string
-			if i == len(entries)-1 {
+			switch {
+			case i == len(entries)-1 && g.asciiTree:
+				connector = "`-- "
+				newPrefix = prefix + "    "
+			case i == len(entries)-1:
 				connector = "└── "
 				newPrefix = prefix + "    "
-			} else {
+			case g.asciiTree:
+				connector = "|-- "
+				newPrefix = prefix + "|   "
+			default:
 				connector = "├── "
 				newPrefix = prefix + "│   "
 			}
 
-			fullPath := filepath.Join(dirPath, entryName)
 			if entry.IsDir() {
-				if _, err := fmt.Fprintf(&tree, "%s%s%s/\n", prefix, connector, entryName); err != nil {
+				var annotation string
+				if stats != nil {
+					relPath, err := g.relPath(fullPath)
+					if err != nil {
+						return err
+					}
+					annotation = stats[relPath].annotation(false)
+				}
+				if _, err := fmt.Fprintf(&tree, "%s%s%s/%s\n", prefix, connector, entryName, annotation); err != nil {
 					return fmt.Errorf("error writing to tree string: %w", err)
 				}
-				// Only recurse if not in non-recursive mode
-				if !g.noRecurse {
-					if err := generateTree(fullPath, newPrefix); err != nil {
+				// Only recurse if not in non-recursive mode and below the depth limit
+				if !g.noRecurse && (g.maxDepth == 0 || depth < g.maxDepth) {
+					if err := generateTree(fullPath, newPrefix, depth+1); err != nil {
 						return err
 					}
 				}
 			} else {
+				// Show where symlinks point, as their content is skipped
+				if entry.Type()&os.ModeSymlink != 0 {
+					if target := g.symlinkTarget(fullPath); target != "" {
+						entryName += " -> " + target
+					}
+				}
 				if _, err := fmt.Fprintf(&tree, "%s%s%s\n", prefix, connector, entryName); err != nil {
 					return fmt.Errorf("error writing to tree string: %w", err)
 				}
@@ -322,10 +714,20 @@ func (g *Git2LLM) generateDirectoryStructureString() (string, error) {
 		return nil
 	}
 
-	if _, err := fmt.Fprintf(&tree, "/ \n"); err != nil {
+	rootLine := "/ "
+	switch {
+	case g.rootLabel != "":
+		rootLine = g.rootLabel + "/"
+	case g.pathPrefix != "":
+		rootLine = g.pathPrefix + "/"
+	}
+	if annotation := stats[""].annotation(true); annotation != "" {
+		rootLine = strings.TrimSuffix(rootLine, " ") + annotation
+	}
+	if _, err := fmt.Fprintln(&tree, rootLine); err != nil {
 		return "", fmt.Errorf("error writing to tree string: %w", err)
 	}
-	if err := generateTree(g.startPath, ""); err != nil {
+	if err := generateTree(g.startPath, "", 1); err != nil {
 		return "", err
 	}
 	if g.countTokens {
@@ -333,7 +735,7 @@ func (g *Git2LLM) generateDirectoryStructureString() (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("g.counter.Count: %w", err)
 		}
-		g.tokens = g.tokens + newTokens
+		g.tokens.Add(int64(newTokens))
 	}
 
 	return tree.String(), nil
@@ -348,24 +750,31 @@ func (g *Git2LLM) isSymlink(filePath string) bool {
 	return info.Mode()&os.ModeSymlink != 0
 }
 
-// isBinaryFile checks if a file is likely a binary file by looking for null bytes in the first 16 kBytes.
-func (g *Git2LLM) isForbiddenFile(filePath string) string {
+// readHead reads up to contentScanSize bytes from the start of a file.
+func (g *Git2LLM) readHead(filePath string) ([]byte, error) {
 	file, err := g.fs.Open(filePath)
 	if err != nil {
-		return fmt.Sprintf("error(open): %v", err) //
+		return nil, fmt.Errorf("open: %w", err)
 	}
 	defer file.Close()
 
-	buffer := make([]byte, 16384)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
-		return fmt.Sprintf("error(read): %v", err) //
+	buffer := make([]byte, contentScanSize)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return buffer[:n], nil
+}
+
+// isBinaryFile checks if a file is likely a binary file by looking for null bytes in the first 16 kBytes.
+func (g *Git2LLM) isForbiddenFile(filePath string) string {
+	buffer, err := g.readHead(filePath)
+	if err != nil {
+		return fmt.Sprintf("error(%v)", err)
 	}
 
-	for i := 0; i < n; i++ {
-		if buffer[i] == 0 {
-			return "binary" // Found null byte, likely binary
-		}
+	if bytes.IndexByte(buffer, 0) != -1 {
+		return "binary" // Found null byte, likely binary
 	}
 
 	if bytes.Contains(buffer, []byte(secretKeyMarker)) {
@@ -374,243 +783,978 @@ func (g *Git2LLM) isForbiddenFile(filePath string) string {
 	return "" // No null byte in the checked portion, likely text
 }
 
+// matchContentRule returns the content rule matching the start of a file, or "" if none match.
+func (g *Git2LLM) matchContentRule(filePath string) string {
+	if len(g.contentRules) == 0 {
+		return ""
+	}
+	buffer, err := g.readHead(filePath)
+	if err != nil {
+		return "" // Unreadable files are reported when their content is processed
+	}
+	for _, rule := range g.contentRules {
+		if rule.match(buffer) {
+			return rule.pattern
+		}
+	}
+	return ""
+}
+
 // ScanRepository scans a folder, writes directory structure and file contents to output file.
 func (g *Git2LLM) ScanRepository() error {
-	if _, err := fmt.Fprintln(g.outputWriter, "Directory Structure:"); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	return ScanRepositories(g)
+}
+
+// ScanRepositories scans several folders into a single output with one tree per
+// root followed by the contents of all roots. The roots must share an output writer
+// and should have distinct path prefixes so emitted paths stay unambiguous.
+func ScanRepositories(roots ...*Git2LLM) error {
+	_, err := Scan(roots...)
+	return err
+}
+
+// Scan works like ScanRepositories and also returns what was written: the
+// files with their sizes and token counts, the skipped files and the tree.
+// If files violate a PolicyFail action, the scan is completed without them and
+// the result is returned with a *PolicyError. An interrupted scan returns its
+// result with ErrInterrupted.
+func Scan(roots ...*Git2LLM) (*ScanResult, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no roots to scan")
 	}
-	if _, err := fmt.Fprintln(g.outputWriter, "-------------------"); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	// Tokenizers load while the tree is walked
+	for _, g := range roots {
+		if g.counter != nil {
+			g.counter.Warm()
+		}
 	}
-
-	dirTree, err := g.generateDirectoryStructureString()
-	if err != nil {
-		return err
+	head := &lineCounter{}
+
+	// With front matter, the output is spooled until the totals are known
+	var meta *Metadata
+	var frontSpool *spool
+	output := roots[0].outputWriter
+	if roots[0].frontMatter {
+		meta = newMetadata(roots)
+		fingerprint, err := Fingerprint(roots...)
+		if err != nil {
+			return nil, err
+		}
+		meta.Fingerprint = fingerprint
+		front, err := meta.yaml()
+		if err != nil {
+			return nil, err
+		}
+		head.n = strings.Count(front, "\n") // Lines of the table of contents count from the top
+		if frontSpool, err = newSpool(); err != nil {
+			return nil, err
+		}
+		defer frontSpool.close()
+		for _, g := range roots {
+			defer func(out io.Writer) {
+				g.outputWriter = out
+			}(g.outputWriter)
+			g.outputWriter = frontSpool
+		}
 	}
-	if _, err := fmt.Fprint(g.outputWriter, dirTree); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	for _, e := range roots[0].emitters {
+		if err := e.start(meta != nil); err != nil {
+			return nil, err
+		}
 	}
-
-	if _, err := fmt.Fprintln(g.outputWriter, "\n\nFile Contents:"); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	var budget *outputBudget
+	if roots[0].maxBytes > 0 {
+		budget = &outputBudget{max: roots[0].maxBytes}
 	}
-	if _, err := fmt.Fprintln(g.outputWriter, "--------------"); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	w := io.MultiWriter(withBudget(roots[0].outputWriter, budget), head)
+	out := w
+	if roots[0].resumeAfter != "" {
+		if err := resumePoint(roots); err != nil {
+			return nil, err
+		}
+		// Everything before the file contents was written by the interrupted scan
+		w = io.Discard
+		resume := &resumeSkip{after: roots[0].resumeAfter}
+		for _, g := range roots {
+			g.resumeSkip = resume
+		}
 	}
-
-	if g.noRecurse {
-		// Non-recursive mode: only scan files in the start directory
-		err = g.scanDirectory(g.startPath)
-	} else {
-		// Recursive mode: use filepath.Walk
-		err = filepath.Walk(g.startPath, func(path string, info os.FileInfo, err error) error {
+	if roots[0].overview {
+		if err := writeOverview(w, roots); err != nil {
+			return nil, err
+		}
+	}
+	if roots[0].dependencies {
+		if err := writeDependencies(w, roots); err != nil {
+			return nil, err
+		}
+	}
+	var trees []string
+	if !roots[0].noTree {
+		if _, err := fmt.Fprintln(w, "Directory Structure:"); err != nil {
+			return nil, fmt.Errorf("error writing to output file: %w", err)
+		}
+		if _, err := fmt.Fprintln(w, "-------------------"); err != nil {
+			return nil, fmt.Errorf("error writing to output file: %w", err)
+		}
+		for i, g := range roots {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return nil, fmt.Errorf("error writing to output file: %w", err)
+				}
+			}
+			dirTree, err := g.generateDirectoryStructureString()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", path, err) // Log to stderr
-				return nil                                                         // Don't stop walking because of one error
+				return nil, err
 			}
-			if !info.IsDir() {
-				relPath, err := filepath.Rel(g.startPath, path)
-				if err != nil {
-					return fmt.Errorf("error getting relative path: %w", err)
-				}
-
-				if g.isExcluded(relPath) {
-					return nil
-				}
-
-				if len(g.fileTypes) == 0 { // if fileTypes is nil or empty, process all files
-					if err := g.processFile(path, relPath); err != nil {
-						fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", relPath, err) // Log to stderr
-					}
-				} else { // Otherwise check file extensions
-					for _, ext := range g.fileTypes {
-						if strings.HasSuffix(info.Name(), ext) {
-							if err := g.processFile(path, relPath); err != nil {
-								fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", relPath, err) // Log to stderr
-							}
-							return nil // processed the file, no need to check other extensions
-						}
-					}
+			trees = append(trees, dirTree)
+			if _, err := fmt.Fprint(w, dirTree); err != nil {
+				return nil, fmt.Errorf("error writing to output file: %w", err)
+			}
+			for _, e := range g.emitters {
+				if err := e.writeTree(dirTree); err != nil {
+					return nil, err
 				}
 			}
-			return nil
-		})
-	}
-	if err != nil {
-		return fmt.Errorf("error scanning directory: %w", err)
-	}
-	if g.countTokens {
-		fmt.Fprintf(os.Stderr, "Total tokens: %d\n", g.tokens)
+		}
 	}
 
-	return nil
-}
-
-// scanDirectory scans a single directory non-recursively
-func (g *Git2LLM) scanDirectory(dirPath string) error {
-	entries, err := g.fs.ReadDir(dirPath)
-	if err != nil {
-		return fmt.Errorf("error reading directory: %w", err)
+	if roots[0].codeMap {
+		if err := writeCodeMap(w, roots); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue // Skip directories in non-recursive mode
+	// With a table of contents, the contents are spooled until the table is written
+	var toc *tableOfContents
+	if roots[0].tableOfContents {
+		var err error
+		if toc, err = newTableOfContents(); err != nil {
+			return nil, err
 		}
-
-		entryName := entry.Name()
-		fullPath := filepath.Join(dirPath, entryName)
-		relPath, err := filepath.Rel(g.startPath, fullPath)
-		if err != nil {
-			return fmt.Errorf("error getting relative path: %w", err)
+		defer toc.close()
+		for _, g := range roots {
+			defer func(out io.Writer) {
+				g.outputWriter, g.toc = out, nil
+			}(g.outputWriter)
+			g.outputWriter, g.toc = toc, toc
 		}
+	} else if err := writeContentsHeader(w); err != nil {
+		return nil, err
+	}
+	for _, g := range roots {
+		defer func(out io.Writer) {
+			g.outputWriter, g.budget = out, nil
+		}(g.outputWriter)
+		g.outputWriter, g.budget = withBudget(g.outputWriter, budget), budget
+	}
 
-		if g.isExcluded(relPath) {
-			continue
+	// Duplicates are found and personal data is numbered across all roots
+	for _, g := range roots[1:] {
+		if g.contentHashes != nil && roots[0].contentHashes != nil {
+			g.contentHashes = roots[0].contentHashes
 		}
+		if g.pii != nil && roots[0].pii != nil {
+			g.pii = roots[0].pii
+		}
+	}
 
-		if len(g.fileTypes) == 0 { // if fileTypes is nil or empty, process all files
-			if err := g.processFile(fullPath, relPath); err != nil {
-				fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", relPath, err) // Log to stderr
-			}
-		} else { // Otherwise check file extensions
-			for _, ext := range g.fileTypes {
-				if strings.HasSuffix(entryName, ext) {
-					if err := g.processFile(fullPath, relPath); err != nil {
-						fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", relPath, err) // Log to stderr
-					}
-					break // processed the file, no need to check other extensions
-				}
-			}
+	var totalTokens int
+	countTokens := false
+	for _, g := range roots {
+		if err := g.scanContents(); err != nil {
+			return nil, fmt.Errorf("error scanning directory: %w", err)
 		}
+		totalTokens += int(g.tokens.Load())
+		countTokens = countTokens || g.countTokens
 	}
+	w = out
+	if toc != nil {
+		if err := toc.writeTo(w, head.n); err != nil {
+			return nil, err
+		}
+	}
+	interrupted := false
+	for _, g := range roots {
+		interrupted = interrupted || g.interrupted > 0
+	}
+	if interrupted {
+		if err := writeInterrupted(w, roots); err != nil {
+			return nil, err
+		}
+	} else {
+		diffTokens, err := writeWorkingDiff(w, roots)
+		if err != nil {
+			return nil, err
+		}
+		totalTokens += diffTokens
+	}
+	if meta != nil {
+		for _, g := range roots {
+			meta.Files += g.files
+		}
+		if meta.Model != "" {
+			meta.Tokens = totalTokens
+		}
+		front, err := meta.yaml()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(output, front); err != nil {
+			return nil, fmt.Errorf("error writing to output file: %w", err)
+		}
+		if err := frontSpool.copyTo(output); err != nil {
+			return nil, err
+		}
+	}
+	logger := roots[0].logger
+	if countTokens {
+		info, known := tokens.Lookup(roots[0].model)
+		attrs := []any{"tokens", totalTokens}
+		if info.InputPrice > 0 {
+			attrs = append(attrs, "cost", fmt.Sprintf("$%.4f", info.Cost(totalTokens)))
+		}
+		if r := roots[0].resumeSkip; r != nil {
+			// The files written before the scan was resumed are not counted again
+			attrs = append(attrs, "resumed_after", r.after)
+		}
+		var files []FileResult
+		for _, g := range roots {
+			for _, f := range g.results {
+				files = append(files, *f)
+			}
+		}
+		logTokenBreakdown(logger, files, roots[0].typeOf)
+		logger.Info("Total tokens", attrs...)
+		if known && info.ContextWindow > 0 && totalTokens > info.ContextWindow {
+			logger.Warn("The output exceeds the context window of the model", "model", info.Name, "tokens", totalTokens, "context_window", info.ContextWindow)
+		}
+	}
+	for _, g := range roots {
+		if g.counter == nil {
+			continue
+		}
+		if chunks, err := g.counter.Fallbacks(); chunks > 0 {
+			logger.Warn("Token counting failed, some text was estimated instead", "model", g.model, "chunks", chunks, "error", err)
+		}
+	}
+	logSkipSummary(logger, skippedFiles(roots))
+
+	saved := make(map[*tokenCache]bool)
+	for _, g := range roots {
+		if g.tokenCache == nil || saved[g.tokenCache] {
+			continue
+		}
+		saved[g.tokenCache] = true
+		if err := g.tokenCache.save(); err != nil {
+			logger.Error("Error saving token cache", "error", err)
+		}
+	}
+
+	result := newScanResult(roots, strings.Join(trees, "\n"), totalTokens)
+	for _, e := range roots[0].emitters {
+		if err := e.finish(result, meta); err != nil {
+			return nil, err
+		}
+	}
+	if interrupted {
+		return result, ErrInterrupted
+	}
+	return result, policyError(roots)
+}
+
+// manifestEntry is a file selected for the content section.
+type manifestEntry struct {
+	path    string // path used to access the file
+	relPath string // path relative to the start path
+	size    int64
+}
+
+// writeContentsHeader writes the heading above the file contents.
+func writeContentsHeader(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "\n\nFile Contents:"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, "--------------"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// scanContents writes the contents of all included files below the start path.
+func (g *Git2LLM) scanContents() error {
+	files, err := g.contentFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		g.contents = nil // The next scan collects the files again
+	}()
+	if g.countTokens {
+		// Files are read and written in order while tokenization runs on all cores.
+		g.pool = g.counter.NewPool(runtime.NumCPU())
+		defer func() {
+			g.pool.Close()
+			g.pool = nil
+		}()
+	}
+	progress := g.newProgress(files)
+	for _, f := range files {
+		if r := g.resumeSkip; r != nil && !r.passed {
+			r.passed = g.displayPath(f.relPath) == r.after
+			progress.update(f.size, int(g.tokens.Load()))
+			continue
+		}
+		if g.isInterrupted() {
+			// Not checkpointed, so a resumed scan writes them
+			g.interrupted++
+			g.skip(g.displayPath(f.relPath), SkipInterrupted, "the scan was interrupted")
+			continue
+		}
+		var start int
+		if g.toc != nil {
+			start = g.toc.lines.n
+		}
+		if g.budget != nil && !g.budget.fits(0) {
+			g.budget.omit(g, g.displayPath(f.relPath))
+			progress.update(f.size, int(g.tokens.Load()))
+			continue
+		}
+		written := len(g.results)
+		process := g.processFile
+		if g.budget != nil {
+			process = g.processWithinBudget
+		}
+		if err := process(f.path, f.relPath); err != nil {
+			g.logger.Error("Error processing file", "path", f.relPath, "error", err)
+		}
+		if len(g.results) > written {
+			g.results[written].Size = f.size
+		}
+		// Files dropped without a trace are not listed
+		if g.toc != nil && g.toc.lines.n > start {
+			g.toc.add(g.displayPath(f.relPath), start)
+		}
+		if g.checkpoint != nil {
+			g.checkpoint(g.displayPath(f.relPath))
+		}
+		progress.update(f.size, int(g.tokens.Load()))
+	}
+	progress.done()
 	return nil
 }
 
+// collectFiles returns the files below the start path that pass all path filters, in output order.
+func (g *Git2LLM) collectFiles() ([]manifestEntry, error) {
+	if g.noRecurse {
+		// Non-recursive mode: only scan files in the start directory
+		return g.collectDirectory(g.startPath)
+	}
+	// Recursive mode: walk the tree through the file system abstraction
+	entries, err := g.fs.ReadDir(g.startPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+	var files []manifestEntry
+	var walk func(dirPath string, entries []os.DirEntry) error
+	walk = func(dirPath string, entries []os.DirEntry) error {
+		for _, entry := range entries {
+			path := filepath.Join(dirPath, entry.Name())
+			relPath, err := g.relPath(path)
+			if err != nil {
+				return err
+			}
+			if !g.inScope(relPath, entry.IsDir()) {
+				continue
+			}
+			if entry.IsDir() {
+				if g.maxDepth > 0 && pathDepth(relPath) >= g.maxDepth {
+					continue
+				}
+				if g.isVendoredDir(entry.Name()) {
+					continue // Dependencies can be huge, so they are not walked at all
+				}
+				if g.isExcluded(relPath) {
+					// Excluded directories aren't walked either. Like vendored ones,
+					// those only excluded by default, such as .git, aren't recorded
+					// as skipped, as every run leaves them out.
+					if !g.excludedByDefault(relPath) {
+						g.skip(g.displayPath(relPath), SkipExcluded, "directory")
+					}
+					continue
+				}
+				subEntries, err := g.fs.ReadDir(path)
+				if err != nil {
+					g.skip(g.displayPath(relPath), SkipUnreadable, err.Error())
+					continue // Don't stop walking because of one error
+				}
+				if err := walk(path, subEntries); err != nil {
+					return err
+				}
+				continue
+			}
+			if !g.matchesFileType(path, entry.Name()) {
+				continue
+			}
+			if g.isExcluded(relPath) {
+				g.skip(g.displayPath(relPath), SkipExcluded, "")
+				continue
+			}
+			if kind := specialFileKind(entry.Type()); kind != "" {
+				g.skip(g.displayPath(relPath), SkipSpecial, kind)
+				continue
+			}
+			var size int64
+			if info, err := entry.Info(); err == nil {
+				size = info.Size()
+			}
+			files = append(files, manifestEntry{path: path, relPath: relPath, size: size})
+		}
+		return nil
+	}
+	if err := walk(g.startPath, entries); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// excludedByDefault reports whether the excluded directory at relPath is only
+// left out by the default rules: the dotfile rule and the default patterns.
+func (g *Git2LLM) excludedByDefault(relPath string) bool {
+	if g.isGitIgnored(relPath) {
+		return false
+	}
+	if attr, _ := g.excludingAttribute(relPath); attr != "" {
+		return false
+	}
+	parts := strings.Split(relPath, "/")
+	for pattern := range g.exclusionPatterns {
+		if g.patternSource(pattern) != "default" && matchPattern(pattern, relPath, parts) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFileType reports whether the file at filePath, named name, passes the
+// file type filter. If no file types are given, all files match. A file type
+// matches names ending with it, and a type without a dot also matches the name
+// in any case, e.g. Makefile and makefile. Files without an extension match the
+// extension of the interpreter in their shebang line, e.g. .py for #!/usr/bin/env python3.
+func (g *Git2LLM) matchesFileType(filePath, name string) bool {
+	if len(g.fileTypes) == 0 {
+		return true
+	}
+	for _, ext := range g.fileTypes {
+		if strings.HasSuffix(name, ext) || (!strings.Contains(ext, ".") && strings.EqualFold(name, ext)) {
+			return true
+		}
+	}
+	if strings.Contains(name, ".") {
+		return false
+	}
+	ext := g.shebangExtension(filePath)
+	return ext != "" && slices.Contains(g.fileTypes, ext)
+}
+
+// pathDepth returns the number of components in a slash separated relative path.
+func pathDepth(relPath string) int {
+	return strings.Count(relPath, "/") + 1
+}
+
+// collectDirectory lists the files of a single directory non-recursively
+func (g *Git2LLM) collectDirectory(dirPath string) ([]manifestEntry, error) {
+	entries, err := g.fs.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	var files []manifestEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue // Skip directories in non-recursive mode
+		}
+
+		entryName := entry.Name()
+		fullPath := filepath.Join(dirPath, entryName)
+		relPath, err := g.relPath(fullPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !g.inScope(relPath, false) || !g.matchesFileType(fullPath, entryName) {
+			continue
+		}
+		if g.isExcluded(relPath) {
+			g.skip(g.displayPath(relPath), SkipExcluded, "")
+			continue
+		}
+		if kind := specialFileKind(entry.Type()); kind != "" {
+			g.skip(g.displayPath(relPath), SkipSpecial, kind)
+			continue
+		}
+
+		var size int64
+		if info, err := entry.Info(); err == nil {
+			size = info.Size()
+		}
+		files = append(files, manifestEntry{path: fullPath, relPath: relPath, size: size})
+	}
+	return files, nil
+}
+
+// displayPath returns the path of a file as shown in the output.
+func (g *Git2LLM) displayPath(relPath string) string {
+	if g.pathPrefix == "" {
+		return relPath
+	}
+	return g.pathPrefix + "/" + relPath
+}
+
 func (g *Git2LLM) processFile(filePath string, relPath string) error {
+	forceText := g.isForcedText(relPath)
+	startRelPath := relPath // Before the prefix is added
+	relPath = g.displayPath(relPath)
 	if g.isSymlink(filePath) {
-		fmt.Fprintf(os.Stderr, "Skipping symlink: %s\n", relPath) // Log to stderr
-		if _, err := fmt.Fprintf(g.outputWriter, "File: %s (Symlink - skipped content)\n", relPath); err != nil {
-			return fmt.Errorf("error writing to output file: %w", err)
-		}
-		if _, err := fmt.Fprintln(g.outputWriter, strings.Repeat("-", 50)); err != nil {
-			return fmt.Errorf("error writing to output file: %w", err)
+		target := g.symlinkTarget(filePath)
+		g.skip(relPath, SkipSymlink, target)
+		label := "Symlink"
+		if target != "" {
+			label += " to " + target
 		}
-		if _, err := fmt.Fprintf(g.outputWriter, "Content of %s: (Skipped - Symlink)\n\n\n", relPath); err != nil {
-			return fmt.Errorf("error writing to output file: %w", err)
+		return g.writePlaceholder(relPath, label, label) // Skip symlinks content but not an error for overall process
+	}
+	if rule := g.matchContentRule(filePath); rule != "" {
+		g.skip(relPath, SkipExcluded, rule)
+		return nil
+	}
+	if g.maxFileSize > 0 {
+		if info, err := g.fs.Stat(filePath); err == nil && info.Size() > g.maxFileSize {
+			detail := fmt.Sprintf("%d bytes, over the limit of %d", info.Size(), g.maxFileSize)
+			if g.enforce(relPath, CategoryOversized, detail) != PolicyWarn {
+				g.skip(relPath, SkipTooLarge, detail)
+				return g.writePlaceholder(relPath, "Too large", detail)
+			}
 		}
-		return nil // Skip symlinks content but not an error for overall process
 	}
 	reason := g.isForbiddenFile(filePath)
-	if reason != "" {
-		fmt.Fprintf(os.Stderr, "Skipping forbidden (%q) file: %s\n", reason, relPath) // Log to stderr
-		if _, err := fmt.Fprintf(g.outputWriter, "File: %s (Binary - skipped content)\n", relPath); err != nil {
+	if (reason == "binary" || reason == "private key") && g.isAllowed(filePath, startRelPath) {
+		g.logger.Debug("Detection suppressed by the allowlist", "path", relPath, "reason", reason)
+		reason = ""
+	}
+	if reason == "binary" && forceText {
+		reason = ""
+	}
+	redactKeys := false
+	if reason == "private key" {
+		switch g.enforce(relPath, CategorySecrets, reason) {
+		case PolicyWarn:
+			reason = ""
+		case PolicyRedact:
+			reason, redactKeys = "", true
+		}
+	}
+	if reason == "binary" && g.dataSchemas {
+		if schema, ok := g.dataSchema(filePath); ok {
+			g.skip(relPath, forbiddenReason(reason), "schema only")
+			if _, err := fmt.Fprintf(g.outputWriter, "%s (Data file - schema only)\n", g.fileHeader(relPath)); err != nil {
+				return fmt.Errorf("error writing to output file: %w", err)
+			}
+			if err := g.writeSeparator(g.outputWriter); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(g.outputWriter, "%s (Schema)\n%s\n\n", g.contentHeader(relPath), schema); err != nil {
+				return fmt.Errorf("error writing to output file: %w", err)
+			}
+			return nil
+		}
+	}
+	if reason == "binary" && g.isImage(relPath) {
+		return g.writeImage(filePath, relPath)
+	}
+	binaryAction := PolicySkip
+	if reason == "binary" {
+		binaryAction = g.enforce(relPath, CategoryBinaries, reason)
+	}
+	if reason != "" && (g.hasBinaryMetadata(reason) || binaryAction == PolicyRedact) {
+		g.skip(relPath, forbiddenReason(reason), reason)
+		if _, err := fmt.Fprintf(g.outputWriter, "%s (Binary - metadata only)\n", g.fileHeader(relPath)); err != nil {
 			return fmt.Errorf("error writing to output file: %w", err)
 		}
-		if _, err := fmt.Fprintln(g.outputWriter, strings.Repeat("-", 50)); err != nil {
+		if err := g.writeSeparator(g.outputWriter); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(g.outputWriter, "%s (Binary File)\n", g.contentHeader(relPath)); err != nil {
 			return fmt.Errorf("error writing to output file: %w", err)
 		}
-		if _, err := fmt.Fprintf(g.outputWriter, "Content of %s: (Skipped - Binary File)\n\n\n", relPath); err != nil {
+		if err := g.writeBinaryMetadata(g.outputWriter, filePath); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(g.outputWriter, "\n\n"); err != nil {
 			return fmt.Errorf("error writing to output file: %w", err)
 		}
-		return nil // Skip binary files content but not an error for overall process
+		return nil
+	}
+	if reason != "" {
+		g.skip(relPath, forbiddenReason(reason), reason)
+		return g.writePlaceholder(relPath, "Binary", "Binary File") // Skip binary files content but not an error for overall process
 	}
 
-	if g.verbose {
-		fmt.Fprintf(os.Stderr, "Processing: %s ", relPath) // Log to stderr
+	if g.maxFileTokens > 0 {
+		n, err := g.fileTokens(filePath)
+		if err != nil {
+			g.skip(relPath, SkipUnreadable, err.Error())
+			return nil // Reported in the skip summary
+		}
+		if n > g.maxFileTokens {
+			detail := fmt.Sprintf("%d tokens, over the limit of %d", n, g.maxFileTokens)
+			g.logger.Info("Skipping file over the token limit", "path", relPath, "tokens", n)
+			g.skip(relPath, SkipTooLarge, detail)
+			return g.writePlaceholder(relPath, "Too many tokens", detail)
+		}
 	}
 
-	if _, err := fmt.Fprintf(g.outputWriter, "File: %s\n", relPath); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	// Secrets are redacted and personal data is masked before transformers see
+	// the content, as they may pass it on, e.g. to an LLM with --summarize-over.
+	transformers := g.transformersFor(relPath)
+	var content io.Reader
+	var transformed []byte
+	readContent := func() ([]byte, error) {
+		if transformed != nil {
+			return transformed, nil
+		}
+		return g.fs.ReadFile(filePath)
 	}
-	if _, err := fmt.Fprintln(g.outputWriter, strings.Repeat("-", 50)); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	if redactKeys {
+		data, err := readContent()
+		if err != nil {
+			g.skip(relPath, SkipUnreadable, err.Error())
+			return nil // Reported in the skip summary
+		}
+		transformed = redactPrivateKeys(data)
+		content = bytes.NewReader(transformed)
+	}
+	redacted := g.shouldRedact(relPath)
+	redactedAlready := false
+	if redacted && len(transformers) > 0 {
+		data, err := readContent()
+		if err != nil {
+			g.skip(relPath, SkipUnreadable, err.Error())
+			return nil // Reported in the skip summary
+		}
+		var values bytes.Buffer
+		if err := redact(&values, bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("error redacting file %s: %w", relPath, err)
+		}
+		transformed, redactedAlready = values.Bytes(), true
+		content = bytes.NewReader(transformed)
 	}
 
-	content, err := g.fs.ReadFile(filePath)
-	if err != nil {
-		if _, errWrite := fmt.Fprintf(g.outputWriter, "Error reading file: %s. Content skipped.\n", err); errWrite != nil {
-			return fmt.Errorf("error writing error message to output file: %w (original error: %v)", errWrite, err)
+	piiMasked := false
+	if g.pii != nil {
+		data, err := readContent()
+		if err != nil {
+			g.skip(relPath, SkipUnreadable, err.Error())
+			return nil // Reported in the skip summary
+		}
+		if matches := findPII(data); len(matches) > 0 {
+			detail := describePII(matches)
+			switch g.enforce(relPath, CategoryPII, detail) {
+			case PolicyRedact:
+				data, piiMasked = g.pii.mask(data, matches), true
+			case PolicySkip, PolicyFail:
+				g.skip(relPath, SkipPII, detail)
+				return g.writePlaceholder(relPath, "Personal data", detail)
+			}
 		}
-		return fmt.Errorf("error reading file %s: %w", relPath, err) // Still return an error for logging in scanFolder
+		transformed = data
+		content = bytes.NewReader(data)
 	}
-	var newTokens int
-	if g.countTokens {
-		var err error
-		newTokens, err = g.counter.Count(string(content))
+
+	// Data files are sampled as they are read, before transformers see them
+	if comma := g.dataComma(relPath); comma != 0 {
+		r := content
+		if r == nil {
+			file, err := g.fs.Open(filePath)
+			if err != nil {
+				g.skip(relPath, SkipUnreadable, err.Error())
+				return nil // Reported in the skip summary
+			}
+			defer file.Close()
+			r = file
+		}
+		data, err := sampleData(r, comma, g.sampleRows)
 		if err != nil {
-			return fmt.Errorf("g.counter.Count: %w", err)
+			g.skip(relPath, SkipUnreadable, err.Error())
+			return nil // Reported in the skip summary
 		}
-		g.tokens = g.tokens + newTokens
+		transformed = data
+		content = bytes.NewReader(data)
 	}
 
-	if g.verbose {
-		// count the number of lines in the file
-		lineCount := strings.Count(string(content), "\n")
-		switch g.countTokens {
-		case true:
-			fmt.Fprintf(os.Stderr, "(%d tokens, %d lines)\n", newTokens, lineCount) // Log to stderr
-		default:
-			fmt.Fprintf(os.Stderr, "(%d lines)\n", lineCount) // Log to stderr
+	// Transformers see the whole file and may drop it, so run them before anything is written.
+	if len(transformers) > 0 {
+		if data, err := readContent(); err == nil {
+			data, keep, err := transform(transformers, relPath, data)
+			if err != nil {
+				g.skip(relPath, SkipFiltered, err.Error())
+				return nil // Reported in the skip summary
+			}
+			if !keep {
+				g.skip(relPath, SkipFiltered, "dropped by transformer")
+				return nil
+			}
+			content = bytes.NewReader(data)
+			transformed = data
 		}
+	}
 
+	if g.contentHashes != nil {
+		if original, ok := g.duplicateOf(filePath, relPath, transformed); ok {
+			g.skip(relPath, SkipDuplicate, original)
+			return g.writeDuplicate(relPath, original)
+		}
 	}
-	if _, err := fmt.Fprintf(g.outputWriter, "Content of %s:\n", relPath); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+
+	header := g.fileHeader(relPath)
+	annotation, script := g.scriptAnnotation(filePath, path.Base(relPath))
+	lang := g.language(relPath)
+	if lang == "" && script != "" {
+		lang = g.language("script." + script)
 	}
-	if _, err := g.outputWriter.Write(content); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	switch {
+	case redacted:
+		header += " (Values redacted)"
+	case redactKeys:
+		header += " (Private key redacted)"
+	case piiMasked:
+		header += " (Personal data masked)"
+	case annotation != "":
+		header += " (" + annotation + ")"
 	}
-	if _, err := fmt.Fprintln(g.outputWriter); err != nil {
-		return fmt.Errorf("error writing to output file: %w", err)
+	var commit *CommitInfo
+	if g.commits != nil {
+		var annotation string
+		annotation, commit = g.commitAnnotation(startRelPath)
+		header += " (" + annotation + ")"
+	}
+	var sum string
+	if g.fileHashes {
+		var err error
+		if sum, err = g.hashFile(filePath); err != nil {
+			g.logger.Debug("Error hashing file", "path", relPath, "error", err) // Reported when the content is read
+		} else {
+			header += " (sha256:" + sum + ")"
+		}
 	}
-	if _, err := fmt.Fprintln(g.outputWriter); err != nil {
+	if _, err := fmt.Fprintln(g.outputWriter, header); err != nil {
 		return fmt.Errorf("error writing to output file: %w", err)
 	}
-	if g.countTokens {
-		newTokens, err := g.counter.Count(string(content))
+	if err := g.writeSeparator(g.outputWriter); err != nil {
+		return err
+	}
+
+	if content == nil {
+		file, err := g.fs.Open(filePath)
 		if err != nil {
-			return fmt.Errorf("g.counter.Count: %w", err)
+			g.skip(relPath, SkipUnreadable, err.Error())
+			if _, errWrite := fmt.Fprintf(g.outputWriter, "Error reading file: %s. Content skipped.\n", err); errWrite != nil {
+				return fmt.Errorf("error writing error message to output file: %w (original error: %v)", errWrite, err)
+			}
+			return nil // Reported in the skip summary
 		}
-		g.tokens = g.tokens + newTokens
+		defer file.Close()
+		content = file
 	}
-	return nil
-}
 
-func printUsage() {
-	fmt.Printf("Usage: %s [options] <start_path> [file_extensions...]\n\n", os.Args[0])
-	fmt.Println("Options:")
-	flag.PrintDefaults()
-	fmt.Println("\nArguments:")
-	fmt.Println("  start_path             Path to the directory to scan")
-	fmt.Println("  file_extensions        Optional file extensions to include (e.g., .go .js)")
-}
+	if _, err := fmt.Fprintln(g.outputWriter, g.contentHeader(relPath)); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
 
-func main() {
-	// Define flags
-	var excludeTests bool
-	flag.BoolVar(&excludeTests, "t", false, "Exclude test files from known languages")
-	flag.BoolVar(&excludeTests, "exclude-tests", false, "Exclude test files from known languages")
+	// Reuse the cached token count if the file hasn't changed since the last run.
+	// Transformed content can change without the file changing, so it is never cached.
+	var newTokens int
+	var cached bool
+	var info os.FileInfo
+	var err error
+	if g.countTokens && g.tokenCache != nil && !redacted && !redactKeys && !piiMasked && len(transformers) == 0 {
+		info, err = g.fs.Stat(filePath)
+		if err == nil {
+			newTokens, cached = g.tokenCache.get(g.model, g.cacheVariant(), filePath, info)
+			if cached {
+				g.logger.Log(context.Background(), levelTrace, "Token cache hit", "path", relPath, "tokens", newTokens)
+			}
+		}
+	}
 
-	var verbose bool
-	flag.BoolVar(&verbose, "v", false, "Enable verbose output")
-	flag.BoolVar(&verbose, "verbose", false, "Enable verbose output")
+	// Stream the content to the output, counting lines and tokens on the way through.
+	lines := &lineCounter{}
+	var output io.Writer = g.outputWriter
+	var escape *separatorEscaper
+	if separator := g.frame().Separator; separator != "" {
+		escape = newSeparatorEscaper(output, separator)
+		output = escape
+	}
+	writers := []io.Writer{output, lines}
+	var tokenWriter *tokens.Writer
+	if g.countTokens && !cached {
+		if g.pool != nil {
+			tokenWriter = g.pool.NewWriter()
+		} else {
+			tokenWriter = g.counter.NewWriter()
+		}
+		tokenWriter.SetFile(relPath)
+		writers = append(writers, tokenWriter)
+	}
+	for _, e := range g.emitters {
+		w, err := e.beginFile(relPath, lang)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, w)
+	}
+	out := io.MultiWriter(writers...)
+	var truncate *lineTruncator
+	if g.maxLineLength > 0 {
+		truncate = newLineTruncator(out, g.maxLineLength)
+		out = truncate
+	}
+	var clean *sanitizer
+	if g.sanitize {
+		clean = newSanitizer(out)
+		out = clean
+	}
+	var newlines *crlfNormalizer
+	if !g.keepCRLF {
+		newlines = newCRLFNormalizer(out)
+		out = newlines
+	}
+	if redacted && !redactedAlready {
+		err = redact(out, content)
+	} else {
+		_, err = io.Copy(out, content)
+	}
+	if err == nil && newlines != nil {
+		err = newlines.Flush()
+	}
+	if err == nil && clean != nil {
+		err = clean.Flush()
+	}
+	if err == nil && truncate != nil {
+		err = truncate.Flush()
+	}
+	if err == nil && escape != nil {
+		err = escape.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("error copying file %s: %w", relPath, err)
+	}
+	// The stanza is complete before the file is recorded, see processWithinBudget
+	if _, err := fmt.Fprint(g.outputWriter, "\n\n"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if truncate != nil && truncate.truncated > 0 {
+		g.logger.Debug("Truncated long lines", "path", relPath, "lines", truncate.truncated)
+	}
+	if escape != nil && escape.escaped > 0 {
+		g.logger.Debug("Escaped separator lines", "path", relPath, "lines", escape.escaped)
+	}
+	for _, e := range g.emitters {
+		if err := e.endFile(); err != nil {
+			return err
+		}
+	}
+	if clean != nil && clean.removed > 0 {
+		g.logger.Debug("Sanitized content", "path", relPath, "bytes", clean.removed)
+	}
+	if newlines != nil && newlines.replaced > 0 {
+		g.logger.Debug("Normalized CRLF line endings", "path", relPath, "lines", newlines.replaced)
+	}
+	result := &FileResult{Path: relPath, Lines: lines.n, SHA256: sum, Commit: commit}
+	g.results = append(g.results, result)
+	if tokenWriter != nil {
+		record := func(n int, err error) {
+			if err != nil {
+				g.logger.Error("Error counting tokens", "path", relPath, "error", err)
+				return
+			}
+			result.Tokens = n // Read after the pool is closed
+			g.tokens.Add(int64(n))
+			if g.tokenCache != nil && info != nil && !redacted {
+				g.tokenCache.put(g.model, g.cacheVariant(), filePath, info, n)
+			}
+		}
+		if g.logger.Enabled(context.Background(), slog.LevelDebug) {
+			// The per-file count is logged right away, so wait for it
+			newTokens, err = tokenWriter.Wait()
+			record(newTokens, err)
+		} else {
+			tokenWriter.Finish(record)
+		}
+	} else {
+		result.Tokens = newTokens
+		g.tokens.Add(int64(newTokens))
+	}
 
-	var countTokens bool
-	flag.BoolVar(&countTokens, "c", false, "Count tokens in the output")
+	if g.countTokens {
+		g.logger.Debug("Processed file", "path", relPath, "lines", lines.n, "tokens", newTokens)
+	} else {
+		g.logger.Debug("Processed file", "path", relPath, "lines", lines.n)
+	}
+	g.files++
+	return nil
+}
 
-	var excludePatterns stringSliceFlag
-	flag.Var(&excludePatterns, "e", "Add pattern to exclude (e.g., vendor)")
+// lineCounter is an io.Writer that counts the newlines written to it.
+type lineCounter struct {
+	n int
+}
 
-	var model string
-	flag.StringVar(&model, "m", "cl100k_base", "Model to use (OpenAI or Gemini models)")
+func (l *lineCounter) Write(p []byte) (int, error) {
+	l.n += bytes.Count(p, []byte{'\n'})
+	return len(p), nil
+}
 
-	var noRecurse bool
-	flag.BoolVar(&noRecurse, "R", false, "Do not recurse into subdirectories")
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ask":
+			os.Exit(runAsk(os.Args[2:]))
+		case "apply":
+			os.Exit(runApply(os.Args[2:]))
+		case "explain":
+			os.Exit(runExplain(os.Args[2:]))
+		case "count":
+			os.Exit(runCount(os.Args[2:]))
+		case "version":
+			fmt.Print(versionInfo())
+			os.Exit(0)
+		}
+	}
 
-	var help bool
-	flag.BoolVar(&help, "h", false, "Display this help message")
-	flag.BoolVar(&help, "help", false, "Display this help message")
+	var cfg cliConfig
+	cfg.registerFlags(flag.CommandLine)
+
+	var outputName, compress string
+	flag.StringVar(&outputName, "o", "", "Write the output to this file instead of stdout")
+	flag.StringVar(&compress, "compress", "", "Compress the file given with -o (gzip or zstd); the extension is added if missing")
+	var emits stringSliceFlag
+	flag.Var(&emits, "emit", "Also write the output in another format from the same scan, as FORMAT=FILE with FORMAT text, md or json (comma separated or repeated)")
+
+	var failOverTokens int
+	var summaryPath string
+	flag.IntVar(&failOverTokens, "fail-over-tokens", 0, "Exit with status 3 if the output has more than N tokens (implies -c)")
+	flag.StringVar(&summaryPath, "summary", "", "Write a JSON summary (files, skipped files, tokens, limit) to this file")
+	var ifChanged string
+	flag.StringVar(&ifChanged, "if-changed", "", "Exit with status 4 without writing anything if the fingerprint of the files equals this one")
+	var showVersion bool
+	flag.BoolVar(&showVersion, "version", false, "Print the version, the commit it was built from and the Go version")
+	var githubActions bool
+	flag.BoolVar(&githubActions, "github-actions", false, "Run as a GitHub Actions step: pack the changes of a pull request, write the pack to $RUNNER_TEMP and set the token_count, file_count and pack_path outputs")
+	var resumeScan bool
+	flag.BoolVar(&resumeScan, "resume", false, "Record the progress of the scan next to the file given with -o, and continue an interrupted scan from the last file written when run again with the same arguments")
+	var pprofAddr string
+	flag.StringVar(&pprofAddr, "pprof", "", "Serve runtime profiles (net/http/pprof) on this address while running, e.g. :6060")
 
 	// Override default usage function
 	flag.Usage = printUsage
@@ -619,64 +1763,247 @@ func main() {
 	flag.Parse()
 
 	// Check if help flag is set
-	if help {
+	if cfg.help {
 		printUsage()
 		os.Exit(0)
 	}
+	if showVersion {
+		fmt.Print(versionInfo())
+		os.Exit(0)
+	}
+	if err := cfg.applyConfig(flag.CommandLine); err != nil {
+		cfg.logger().Error(err.Error())
+		os.Exit(1)
+	}
 
 	// Get remaining arguments after flags
 	args := flag.Args()
-	if len(args) < 1 {
+	if len(args) < 1 && cfg.remote() == "" && cfg.fromURLs == "" {
 		printUsage()
 		os.Exit(1)
 	}
 
-	startPath := args[0]
-
-	if verbose {
-		fmt.Fprintf(os.Stderr, "Version: %s\n", embeddedVersion)
+	logger := cfg.logger()
+	if pprofAddr != "" {
+		if err := servePprof(pprofAddr, logger); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
 	}
-
-	var fileTypes []string
-	if len(args) > 1 {
-		fileTypes = args[1:]
+	if failOverTokens < 0 {
+		logger.Error("--fail-over-tokens must be positive")
+		os.Exit(1)
 	}
-
-	if verbose {
-		if fileTypes != nil {
-			fmt.Fprintf(os.Stderr, "Scanning for file types: %v\n", fileTypes)
-		} else {
-			fmt.Fprintf(os.Stderr, "No file types specified. Scanning all files.\n")
+	if failOverTokens > 0 {
+		cfg.countTokens = true
+	}
+	targets, err := parseEmitTargets(emits)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if githubActions {
+		cfg.countTokens = true // For the token_count output
+		if cfg.changed == "" && cfg.remote() == "" && len(args) > 0 {
+			if cfg.changed, err = actionsBase(args[0]); err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+			if cfg.changed != "" {
+				logger.Info("Packing the changes of the pull request", "base", os.Getenv("GITHUB_BASE_REF"), "commit", cfg.changed)
+			}
 		}
+		if outputName == "" && len(targets) == 0 {
+			outputName = actionsPackPath()
+		}
+	}
+	if compress != "" && outputName == "" {
+		logger.Error("--compress requires -o")
+		os.Exit(1)
+	}
+	if resumeScan && outputName == "" {
+		logger.Error("--resume requires -o")
+		os.Exit(1)
+	}
+	if resumeScan && (compress != "" || len(targets) > 0 || cfg.toc || cfg.frontMatter || cfg.maxBytes > 0 || cfg.dedup) {
+		logger.Error("--resume can't be combined with --compress, --emit, --toc, --front-matter, --max-bytes or --dedup")
+		os.Exit(1)
 	}
 
-	// Create Git2LLM instance
-	git2llm, err := NewGit2LLM(startPath, fileTypes, nil, os.Stdout, verbose, excludeTests, countTokens, excludePatterns, model, noRecurse)
+	// The roots are created before the outputs, so an unchanged scan leaves
+	// the previous output files in place
+	roots, err := cfg.newRoots(args, os.Stdout)
 	if err != nil {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Error initializing git2llm: %v\n", err)
-		}
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
+	if ifChanged != "" {
+		fingerprint, err := Fingerprint(roots...)
+		if err != nil {
+			logger.Error("Error taking the fingerprint", "error", err)
+			os.Exit(1)
+		}
+		if fingerprint == ifChanged {
+			logger.Info("Unchanged", "fingerprint", fingerprint)
+			os.Exit(exitUnchanged)
+		}
+		logger.Info("Changed", "fingerprint", fingerprint, "previous", ifChanged)
+	}
 
-	// Add patterns from -e flags
-	if len(excludePatterns) > 0 && verbose {
-		fmt.Fprintf(os.Stderr, "Added %d custom exclusion patterns\n", len(excludePatterns))
+	var output io.Writer = os.Stdout
+	if len(targets) > 0 {
+		output = io.Discard // Only the formats asked for are written
+	}
+	var emitFiles []*outputFile
+	for _, t := range targets {
+		if t.format == "text" {
+			if outputName != "" {
+				logger.Error("--emit text can't be combined with -o")
+				os.Exit(1)
+			}
+			outputName = t.path
+			continue
+		}
+		f, err := createOutput(t.path, "")
+		if err != nil {
+			logger.Error("Error creating output file", "error", err)
+			os.Exit(1)
+		}
+		emitFiles = append(emitFiles, f)
+		cfg.emitters = append(cfg.emitters, emitFormats[t.format](f))
+		logger.Debug("Writing output", "path", t.path, "format", t.format)
+	}
+	var outFile *outputFile
+	var packPath string
+	var resume *resumeState
+	var resumeAt resumeCheckpoint
+	if outputName != "" {
+		path, err := outputPath(outputName, compress)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		packPath = path
+		if resumeScan {
+			outFile, resume, resumeAt, err = startResumable(path, os.Args[1:])
+			if resumeAt.Path != "" {
+				logger.Info("Resuming the interrupted scan", "after", resumeAt.Path, "offset", resumeAt.Offset)
+			}
+		} else {
+			outFile, err = createOutput(path, compress)
+		}
+		if err != nil {
+			logger.Error("Error creating output file", "error", err)
+			os.Exit(1)
+		}
+		output = outFile
+		logger.Debug("Writing output", "path", path)
 	}
 
-	if !excludeTests && verbose {
-		fmt.Fprintf(os.Stderr, "Including all files.\n")
+	// Files are read while the output is written by another goroutine
+	async := newAsyncWriter(output)
+	var sink io.Writer = async
+	var resumeOpts []Option
+	if resume != nil {
+		// Checkpoints record the size of the output after every file
+		counter := &countingWriter{w: async, n: resumeAt.Offset}
+		sink = counter
+		resumeOpts = append(resumeOpts, WithCheckpoint(func(path string) {
+			resume.checkpoint(path, counter.n)
+		}))
+		if resumeAt.Path != "" {
+			resumeOpts = append(resumeOpts, WithResumeAfter(resumeAt.Path))
+		}
+	}
+	// The first SIGINT or SIGTERM finishes the current file and ends the
+	// output with a marker, the second one kills the process
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		logger.Warn("Interrupted, finishing the current file")
+		close(stop)
+	}()
+	for _, g := range roots {
+		g.outputWriter = sink
+		withEmitters(cfg.emitters...)(g)
+		WithInterrupt(stop)(g)
+		for _, opt := range resumeOpts {
+			opt(g)
+		}
 	}
 
-	err = git2llm.ScanRepository()
-	if err != nil {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+	err = ScanRepositories(roots...)
+	interrupted := errors.Is(err, ErrInterrupted)
+	if interrupted {
+		err = nil // The output is complete up to the marker
+	}
+	if closeErr := async.Close(); closeErr != nil {
+		logger.Error("Error writing output", "error", closeErr)
+		os.Exit(1)
+	}
+	for _, f := range append(emitFiles, outFile) {
+		if f == nil {
+			continue
+		}
+		if closeErr := f.Close(); closeErr != nil {
+			logger.Error("Error writing output file", "error", closeErr)
+			os.Exit(1)
+		}
+	}
+	var policyErr *PolicyError
+	if resume != nil && !interrupted && (err == nil || errors.As(err, &policyErr)) {
+		if err := resume.remove(); err != nil {
+			logger.Error(err.Error())
+		}
+	}
+	if errors.As(err, &policyErr) {
+		for _, v := range policyErr.Violations {
+			logger.Error("Policy violation", "path", v.Path, "category", v.Category, "detail", v.Detail)
 		}
+		os.Exit(exitPolicy)
+	}
+	if err != nil {
+		logger.Error("Scan failed", "error", err)
 		os.Exit(1)
 	}
+	cfg.warnMissingSymbols()
 
-	if verbose {
-		fmt.Fprintf(os.Stderr, "Scan complete.")
+	if cfg.skipReport != "" {
+		if err := writeSkipReport(cfg.skipReport, skippedFiles(roots)); err != nil {
+			logger.Error("Error writing skip report", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	summary := summarize(roots, failOverTokens)
+	if summaryPath != "" {
+		if err := writeSummary(summaryPath, summary); err != nil {
+			logger.Error("Error writing summary", "error", err)
+			os.Exit(1)
+		}
+	}
+	if githubActions {
+		outputs := [][2]string{
+			{"token_count", strconv.Itoa(summary.Tokens)},
+			{"file_count", strconv.Itoa(summary.Files)},
+			{"pack_path", packPath},
+		}
+		if err := writeActionsOutputs(outputs); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+	if interrupted {
+		logger.Warn("The scan was interrupted, the output is incomplete", "files", summary.Files)
+		os.Exit(exitInterrupted)
 	}
+	if summary.OverLimit {
+		logger.Error("Token limit exceeded", "tokens", summary.Tokens, "limit", summary.TokenLimit)
+		os.Exit(exitOverTokens)
+	}
+
+	logger.Debug("Scan complete.")
 }
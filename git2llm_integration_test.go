@@ -357,3 +357,191 @@ func TestGit2LLMErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+// TestGit2LLMContentExclusion tests content-based exclusion rules from flags
+func TestGit2LLMContentExclusion(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFiles := map[string]string{
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"zz_generated.go": "// Code generated by controller-gen. DO NOT EDIT.\n\npackage main\n",
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, nil, false, false, false, []string{"content-regex:^// Code generated"}, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm.outputWriter = &output
+
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	if !strings.Contains(result, "main.go") {
+		t.Error("Expected main.go to be included")
+	}
+	if strings.Contains(result, "zz_generated.go") {
+		t.Errorf("Did not expect zz_generated.go to be included. Result:\n%s", result)
+	}
+}
+
+// TestGit2LLMMaxDepth tests limiting the scan to a number of directory levels
+func TestGit2LLMMaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFiles := map[string]string{
+		"root.go":         "package root",
+		"a/level1.go":     "package a",
+		"a/b/level2.go":   "package b",
+		"a/b/c/level3.go": "package c",
+	}
+	for filePath, content := range testFiles {
+		fullPath := filepath.Join(tempDir, filePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", filePath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", filePath, err)
+		}
+	}
+
+	testCases := []struct {
+		maxDepth    int
+		expected    []string
+		notExpected []string
+	}{
+		{1, []string{"root.go", "a/"}, []string{"level1.go", "b/", "level2.go"}},
+		{2, []string{"root.go", "a/level1.go", "b/"}, []string{"level2.go", "c/"}},
+		{0, []string{"root.go", "a/level1.go", "a/b/level2.go", "a/b/c/level3.go"}, nil},
+	}
+
+	for _, tc := range testCases {
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, nil, false, false, false, nil, "", false, WithMaxDepth(tc.maxDepth))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+
+		var output strings.Builder
+		git2llm.outputWriter = &output
+
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+
+		result := output.String()
+		for _, expected := range tc.expected {
+			if !strings.Contains(result, expected) {
+				t.Errorf("max-depth %d: expected %s in output. Result:\n%s", tc.maxDepth, expected, result)
+			}
+		}
+		for _, notExpected := range tc.notExpected {
+			if strings.Contains(result, notExpected) {
+				t.Errorf("max-depth %d: did not expect %s in output. Result:\n%s", tc.maxDepth, notExpected, result)
+			}
+		}
+	}
+}
+
+// TestScanRepositoriesMultipleRoots tests merging several start paths into one output
+func TestScanRepositoriesMultipleRoots(t *testing.T) {
+	tempDir := t.TempDir()
+
+	testFiles := map[string]string{
+		"service-a/main.go":     "package main // service a",
+		"shared-lib/lib.go":     "package lib // shared",
+		"shared-lib/.llmignore": "*.tmp\n",
+		"shared-lib/skip.tmp":   "temporary",
+	}
+	for filePath, content := range testFiles {
+		fullPath := filepath.Join(tempDir, filePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", filePath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", filePath, err)
+		}
+	}
+
+	var output strings.Builder
+	var roots []*Git2LLM
+	for _, name := range []string{"service-a", "shared-lib"} {
+		git2llm, err := NewGit2LLM(filepath.Join(tempDir, name), nil, nil, &output, false, false, false, nil, "", false, WithPathPrefix(name))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		roots = append(roots, git2llm)
+	}
+
+	if err := ScanRepositories(roots...); err != nil {
+		t.Fatalf("ScanRepositories failed: %v", err)
+	}
+
+	result := output.String()
+	for _, expected := range []string{"service-a/\n", "shared-lib/\n", "File: service-a/main.go", "File: shared-lib/lib.go"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "skip.tmp") {
+		t.Errorf("Expected the root's own .llmignore to apply. Result:\n%s", result)
+	}
+	if strings.Count(result, "Directory Structure:") != 1 || strings.Count(result, "File Contents:") != 1 {
+		t.Errorf("Expected a single tree and contents section. Result:\n%s", result)
+	}
+}
+
+func TestGit2LLMIgnoreFileLocation(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":    "package main\n",
+		"notes.txt":  "notes\n",
+		"build.log":  "log\n",
+		".llmignore": "*.txt\n",
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+	otherIgnore := filepath.Join(t.TempDir(), "ignore")
+	if err := os.WriteFile(otherIgnore, []byte("*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	// The .llmignore of the start path applies regardless of the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd failed: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("os.Chdir failed: %v", err)
+	}
+	defer os.Chdir(wd)
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, nil, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if !git2llm.isExcluded("notes.txt") || git2llm.isExcluded("build.log") {
+		t.Errorf("Expected the .llmignore of the start path to be used")
+	}
+
+	git2llm, err = NewGit2LLM(tempDir, nil, nil, nil, false, false, false, nil, "", false, WithIgnoreFile(otherIgnore))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if git2llm.isExcluded("notes.txt") || !git2llm.isExcluded("build.log") {
+		t.Errorf("Expected --ignore-file to replace the .llmignore of the start path")
+	}
+
+	if _, err := NewGit2LLM(tempDir, nil, nil, nil, false, false, false, nil, "", false, WithIgnoreFile(filepath.Join(tempDir, "missing"))); err == nil {
+		t.Errorf("Expected an error for a missing ignore file")
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/perbu/git2llm/tokens"
 )
 
 func TestStringSliceFlag(t *testing.T) {
@@ -373,6 +377,42 @@ func TestGit2LLMLoadExclusionPatterns(t *testing.T) {
 	}
 }
 
+func TestGit2LLMDefaultExcludes(t *testing.T) {
+	defaults := filepath.Join(t.TempDir(), "defaults")
+	if err := os.WriteFile(defaults, []byte(".git\n*.lock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name     string
+		opt      Option
+		excluded map[string]bool
+	}{
+		{"built-in", WithDefaultExcludes(true), map[string]bool{"go.sum": true, ".idea": true, "*.lock": false, "extra": true}},
+		{"disabled", WithDefaultExcludes(false), map[string]bool{"go.sum": false, ".idea": false, "*.lock": false, "extra": true}},
+		{"file", WithDefaultExcludesFile(defaults), map[string]bool{"go.sum": false, ".git": true, "*.lock": true, "extra": true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			git2llm := &Git2LLM{fs: &MockFS{FileContent: "extra\n"}}
+			tc.opt(git2llm)
+			if err := git2llm.loadExclusionPatterns(".llmignore"); err != nil {
+				t.Fatalf("loadExclusionPatterns failed: %v", err)
+			}
+			for pattern, expected := range tc.excluded {
+				if git2llm.exclusionPatterns[pattern] != expected {
+					t.Errorf("Pattern %q: expected %v, got %v", pattern, expected, git2llm.exclusionPatterns[pattern])
+				}
+			}
+		})
+	}
+
+	git2llm := &Git2LLM{fs: &MockFS{}}
+	WithDefaultExcludesFile(filepath.Join(t.TempDir(), "missing"))(git2llm)
+	if err := git2llm.loadExclusionPatterns(""); err == nil {
+		t.Error("Expected an error for a missing default excludes file")
+	}
+}
+
 func TestGit2LLMDirectoryStructureGeneration(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -431,6 +471,22 @@ func TestGit2LLMDirectoryStructureGeneration(t *testing.T) {
 	}
 }
 
+func TestGit2LLMASCIITree(t *testing.T) {
+	mockFS := &MockFS{DirStructure: map[string][]string{
+		".":    {"dir1", "main.go"},
+		"dir1": {"a.go", "b.go"},
+	}}
+	git2llm := &Git2LLM{fs: mockFS, startPath: ".", exclusionPatterns: defaultPatterns()}
+	WithASCIITree(true)(git2llm)
+	result, err := git2llm.generateDirectoryStructureString()
+	if err != nil {
+		t.Fatalf("generateDirectoryStructureString failed: %v", err)
+	}
+	if expected := "/ \n|-- dir1/\n|   |-- a.go\n|   `-- b.go\n`-- main.go\n"; result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
 func TestGit2LLMNonRecursiveMode(t *testing.T) {
 	mockFS := &MockFS{
 		DirStructure: map[string][]string{
@@ -541,3 +597,466 @@ func TestGit2LLMFileTypeFiltering(t *testing.T) {
 		t.Error("Did not expect config.json to be included")
 	}
 }
+
+func TestGit2LLMContentRules(t *testing.T) {
+	mockFS := &MockFS{
+		FileContentMap: map[string]string{
+			".llmignore":   "content:DO NOT EDIT\ncontent-regex:^// Code generated\n*.log\n",
+			"gen.go":       "package main\n// Code generated by stringer. DO NOT EDIT.\n",
+			"regex_gen.go": "package main\n\n// Code generated by hand\n",
+			"manual.go":    "package main\n// Edit me freely. Code generated nowhere.\n",
+		},
+	}
+	git2llm := &Git2LLM{fs: mockFS}
+
+	if err := git2llm.loadExclusionPatterns(".llmignore"); err != nil {
+		t.Fatalf("loadExclusionPatterns failed: %v", err)
+	}
+	if len(git2llm.contentRules) != 2 {
+		t.Fatalf("Expected 2 content rules, got %d", len(git2llm.contentRules))
+	}
+	if !git2llm.exclusionPatterns["*.log"] {
+		t.Error("Expected path pattern *.log to be present")
+	}
+	if git2llm.exclusionPatterns["content:DO NOT EDIT"] {
+		t.Error("Did not expect content rule to be stored as a path pattern")
+	}
+
+	testCases := []struct {
+		path   string
+		expect string
+	}{
+		{"gen.go", "content:DO NOT EDIT"},
+		{"regex_gen.go", "content-regex:^// Code generated"},
+		{"manual.go", ""},
+	}
+	for _, tc := range testCases {
+		if rule := git2llm.matchContentRule(tc.path); rule != tc.expect {
+			t.Errorf("For '%s', expected rule %q, got %q", tc.path, tc.expect, rule)
+		}
+	}
+
+	if err := git2llm.addPattern("content-regex:(", "-e"); err == nil {
+		t.Error("Expected an error for an invalid content regex")
+	}
+}
+
+func TestGit2LLMProcessFileStreaming(t *testing.T) {
+	// Larger than the io.Copy buffer so the content is streamed in several chunks
+	content := strings.Repeat("id,name,value\n", 20000)
+	mockFS := &MockFS{FileContentMap: map[string]string{"fixture.csv": content}}
+
+	var output strings.Builder
+	git2llm := &Git2LLM{fs: mockFS, outputWriter: &output, logger: newLogger(io.Discard, slog.LevelInfo, false)}
+
+	if err := git2llm.processFile("fixture.csv", "fixture.csv"); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	expected := "File: fixture.csv\n" + strings.Repeat("-", 50) + "\nContent of fixture.csv:\n" + content + "\n\n"
+	if output.String() != expected {
+		t.Errorf("Unexpected output: got %d bytes, expected %d bytes", output.Len(), len(expected))
+	}
+}
+
+func TestGit2LLMProcessFileStreamingTokens(t *testing.T) {
+	// Several chunks of the token writer, counted while the file is streamed
+	var b strings.Builder
+	for i := 0; b.Len() < 3*64*1024; i++ {
+		fmt.Fprintf(&b, "func handler%d(w http.ResponseWriter) { fmt.Fprintln(w, \"row %d\") }\n", i, i)
+	}
+	content := b.String()
+	counter, err := tokens.New("cl100k_base")
+	if err != nil {
+		t.Fatalf("tokens.New failed: %v", err)
+	}
+	expected, err := counter.Count(content)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+
+	for _, workers := range []int{0, 4} {
+		mockFS := &MockFS{FileContentMap: map[string]string{"handlers.go": content}}
+		var output strings.Builder
+		git2llm := &Git2LLM{fs: mockFS, outputWriter: &output, logger: newLogger(io.Discard, slog.LevelInfo, false), countTokens: true, counter: counter}
+		if workers > 0 {
+			git2llm.pool = counter.NewPool(workers)
+		}
+		if err := git2llm.processFile("handlers.go", "handlers.go"); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+		if git2llm.pool != nil {
+			git2llm.pool.Close()
+		}
+		if !strings.Contains(output.String(), content) {
+			t.Errorf("With %d workers, expected the content to be streamed in full", workers)
+		}
+		if n := git2llm.tokens.Load(); n != int64(expected) || git2llm.results[0].Tokens != expected {
+			t.Errorf("With %d workers, expected %d tokens, got %d (file: %d)", workers, expected, n, git2llm.results[0].Tokens)
+		}
+	}
+}
+
+func TestLineCounter(t *testing.T) {
+	lines := &lineCounter{}
+	for _, chunk := range []string{"one\ntwo", "\nthree\n", "", "four"} {
+		if _, err := lines.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if lines.n != 3 {
+		t.Errorf("Expected 3 lines, got %d", lines.n)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tempDir := t.TempDir()
+	other := filepath.Join(tempDir, "other")
+	if err := os.Mkdir(other, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	startPaths, fileTypes := splitArgs([]string{tempDir, other, ".go", ".js"})
+	if len(startPaths) != 2 || startPaths[0] != tempDir || startPaths[1] != other {
+		t.Errorf("Expected start paths [%s %s], got %v", tempDir, other, startPaths)
+	}
+	if len(fileTypes) != 2 || fileTypes[0] != ".go" || fileTypes[1] != ".js" {
+		t.Errorf("Expected file types [.go .js], got %v", fileTypes)
+	}
+
+	// The first argument is always a start path, even if it doesn't exist
+	startPaths, fileTypes = splitArgs([]string{"/non/existent", ".go"})
+	if len(startPaths) != 1 || len(fileTypes) != 1 {
+		t.Errorf("Expected one start path and one file type, got %v and %v", startPaths, fileTypes)
+	}
+
+	// Further start paths need a separator, whatever exists in the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd failed: %v", err)
+	}
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("os.Chdir failed: %v", err)
+	}
+	defer os.Chdir(wd)
+	startPaths, fileTypes = splitArgs([]string{".", "other", "./other", "../api.zip", "Makefile"})
+	if len(startPaths) != 3 || startPaths[1] != "./other" || startPaths[2] != "../api.zip" {
+		t.Errorf("Expected start paths [. ./other ../api.zip], got %v", startPaths)
+	}
+	if len(fileTypes) != 2 || fileTypes[0] != "other" || fileTypes[1] != "Makefile" {
+		t.Errorf("Expected file types [other Makefile], got %v", fileTypes)
+	}
+}
+
+func TestRootPrefixes(t *testing.T) {
+	prefixes, err := rootPrefixes([]string{"/src/service-a", "/src/shared-lib/"})
+	if err != nil {
+		t.Fatalf("rootPrefixes failed: %v", err)
+	}
+	if prefixes[0] != "service-a" || prefixes[1] != "shared-lib" {
+		t.Errorf("Expected [service-a shared-lib], got %v", prefixes)
+	}
+
+	if _, err := rootPrefixes([]string{"/a/lib", "/b/lib"}); err == nil {
+		t.Error("Expected an error for start paths with the same name")
+	}
+}
+
+func TestCliConfigPathPrefixAndRootLabel(t *testing.T) {
+	start := filepath.Join(t.TempDir(), "backend")
+	if err := os.MkdirAll(start, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(start, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	testCases := []struct {
+		cfg      cliConfig
+		rootLine string
+		file     string
+	}{
+		{cliConfig{}, "/ \n", "File: main.go\n"},
+		{cliConfig{pathPrefix: "services/api/"}, "services/api/\n", "File: services/api/main.go\n"},
+		{cliConfig{rootLabel: "."}, "backend/\n", "File: main.go\n"},
+		{cliConfig{rootLabel: "api", pathPrefix: "api"}, "api/\n", "File: api/main.go\n"},
+	}
+	for _, tc := range testCases {
+		var output strings.Builder
+		tc.cfg.noCache = true
+		tc.cfg.log = slog.New(slog.NewTextHandler(io.Discard, nil))
+		roots, err := tc.cfg.newRoots([]string{start}, &output)
+		if err != nil {
+			t.Fatalf("newRoots failed: %v", err)
+		}
+		if err := ScanRepositories(roots...); err != nil {
+			t.Fatalf("ScanRepositories failed: %v", err)
+		}
+		result := output.String()
+		if !strings.Contains(result, "Directory Structure:\n-------------------\n"+tc.rootLine) || !strings.Contains(result, tc.file) {
+			t.Errorf("Expected root line %q and %q. Result:\n%s", tc.rootLine, tc.file, result)
+		}
+	}
+
+	cfg := cliConfig{rootLabel: "api", noCache: true, log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if _, err := cfg.newRoots([]string{start, t.TempDir()}, io.Discard); err == nil {
+		t.Error("Expected an error for a root label with several start paths")
+	}
+}
+
+func TestGit2LLMIsExcludedSeparators(t *testing.T) {
+	git2llm := &Git2LLM{
+		exclusionPatterns: make(map[string]bool),
+		includeVendored:   true, // Only the patterns are tested
+	}
+	// Written with the separator of the platform, as on the command line
+	for _, pattern := range []string{"temp/", "/config/", "/build", "*.log", "vendor", "*_test.go", "gen/out/"} {
+		if err := git2llm.addPattern(filepath.FromSlash(pattern), "-e"); err != nil {
+			t.Fatalf("addPattern failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		path   string
+		expect bool
+	}{
+		{"directory pattern", "temp/file.txt", true},
+		{"anchored directory pattern", "config/app.ini", true},
+		{"anchored pattern", "build/out.bin", true},
+		{"glob in subdirectory", "logs/app.log", true},
+		{"component pattern", "src/vendor/lib.go", true},
+		{"nested dotfolder", "src/.cache/data", true},
+		{"nested test file", "pkg/foo_test.go", true},
+		{"nested directory pattern", "gen/out/app.go", true},
+		{"not excluded", "src/main.go", false},
+		{"anchored pattern only at root", "src/build/out.bin", false},
+	}
+	if filepath.Separator == '/' {
+		// A backslash is part of a name, not a separator
+		testCases = append(testCases, []struct {
+			name   string
+			path   string
+			expect bool
+		}{
+			{"backslash in a name", `temp\file.txt`, false},
+			{"backslash in a directory name", `my\vendor/lib.go`, false},
+		}...)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if excluded := git2llm.isExcluded(tc.path); excluded != tc.expect {
+				t.Errorf("For path '%s', expected excluded: %v, got: %v", tc.path, tc.expect, excluded)
+			}
+		})
+	}
+}
+
+func TestGit2LLMRelPath(t *testing.T) {
+	git2llm := &Git2LLM{startPath: filepath.Join("repo", "root")}
+	relPath, err := git2llm.relPath(filepath.Join("repo", "root", "src", "pkg", "main.go"))
+	if err != nil {
+		t.Fatalf("relPath failed: %v", err)
+	}
+	if relPath != "src/pkg/main.go" {
+		t.Errorf("Expected forward slashes, got %s", relPath)
+	}
+	if depth := pathDepth(relPath); depth != 3 {
+		t.Errorf("Expected depth 3, got %d", depth)
+	}
+}
+
+func TestGit2LLMRelPathOutsideStart(t *testing.T) {
+	git2llm := &Git2LLM{startPath: filepath.Join("repo", "root")}
+	if relPath, err := git2llm.relPath(filepath.Join("repo", "other", "main.go")); err == nil {
+		t.Errorf("Expected an error for a path outside the start path, got %s", relPath)
+	}
+}
+
+func TestGit2LLMSymlinkedStartPath(t *testing.T) {
+	target := t.TempDir()
+	for _, name := range []string{"src/main.go", "gen/out.go"} {
+		path := filepath.Join(target, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+	link := filepath.Join(t.TempDir(), "work")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+
+	var output strings.Builder
+	g, err := NewGit2LLM(link, nil, nil, &output, false, false, false, []string{"gen/"}, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := g.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	if !strings.Contains(result, "File: src/main.go\n") {
+		t.Errorf("Expected src/main.go relative to the resolved start path, got:\n%s", result)
+	}
+	if strings.Contains(result, "out.go") {
+		t.Errorf("Expected gen/ to be excluded, got:\n%s", result)
+	}
+}
+
+func TestGit2LLMDotfileIncludes(t *testing.T) {
+	git2llm := &Git2LLM{
+		exclusionPatterns: defaultPatterns(),
+		dotfileIncludes:   []string{".github/workflows/**", ".golangci.yml"},
+	}
+
+	testCases := []struct {
+		path   string
+		expect bool
+	}{
+		{".github", false},
+		{".github/workflows", false},
+		{".github/workflows/ci.yml", false},
+		{".github/workflows/nested/release.yml", false},
+		{".github/CODEOWNERS", true},
+		{".golangci.yml", false},
+		{"sub/.golangci.yml", true},
+		{".env", true},
+		{".git/config", true},
+		{"main.go", false},
+	}
+	for _, tc := range testCases {
+		if excluded := git2llm.isExcluded(tc.path); excluded != tc.expect {
+			t.Errorf("For path '%s', expected excluded: %v, got: %v", tc.path, tc.expect, excluded)
+		}
+	}
+
+	git2llm.includeDotfiles = true
+	if git2llm.isExcluded(".env") {
+		t.Errorf("Expected .env to be included with include-dotfiles")
+	}
+	if !git2llm.isExcluded(".git/config") {
+		t.Errorf("Expected .git to stay excluded with include-dotfiles")
+	}
+}
+
+func TestGit2LLMDefaultDotfiles(t *testing.T) {
+	git2llm := &Git2LLM{exclusionPatterns: defaultPatterns()}
+
+	testCases := []struct {
+		path   string
+		expect bool
+	}{
+		{".github/workflows/ci.yml", false},
+		{".github/dependabot.yml", true},
+		{".gitlab-ci.yml", false},
+		{".editorconfig", false},
+		{".env.example", false},
+		{".env", true},
+		{".git/config", true},
+	}
+	for _, tc := range testCases {
+		if excluded := git2llm.isExcluded(tc.path); excluded != tc.expect {
+			t.Errorf("For path '%s', expected excluded: %v, got: %v", tc.path, tc.expect, excluded)
+		}
+	}
+
+	WithDefaultDotfiles(false)(git2llm)
+	if !git2llm.isExcluded(".github/workflows/ci.yml") || !git2llm.isExcluded(".editorconfig") {
+		t.Error("Expected the default dotfiles to be excluded when disabled")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern, name string
+		expect        bool
+	}{
+		{"**/*.yml", "ci.yml", true},
+		{"**/*.yml", "a/b/ci.yml", true},
+		{"a/**", "a", true},
+		{"a/**/c", "a/b/b/c", true},
+		{"a/*/c", "a/b/b/c", false},
+		{"a/b", "a/b/c", false},
+	}
+	for _, tc := range testCases {
+		if matched := matchGlob(tc.pattern, tc.name); matched != tc.expect {
+			t.Errorf("matchGlob(%q, %q) = %v, expected %v", tc.pattern, tc.name, matched, tc.expect)
+		}
+	}
+}
+
+// readDirFS records the directories read through it.
+type readDirFS struct {
+	OSFS
+	read []string
+}
+
+func (f *readDirFS) ReadDir(name string) ([]os.DirEntry, error) {
+	f.read = append(f.read, name)
+	return f.OSFS.ReadDir(name)
+}
+
+func TestCollectFilesPrunesExcludedDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{".git/objects/ab/cdef", "gen/out/app.go", "src/main.go"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	fs := &readDirFS{}
+	g, err := NewGit2LLM(tempDir, nil, fs, io.Discard, false, false, false, []string{"gen/"}, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	files, err := g.collectFiles()
+	if err != nil {
+		t.Fatalf("collectFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].relPath != "src/main.go" {
+		t.Errorf("Expected only src/main.go, got %+v", files)
+	}
+	for _, dir := range fs.read {
+		if rel, _ := filepath.Rel(tempDir, dir); strings.HasPrefix(rel, ".git") || strings.HasPrefix(rel, "gen") {
+			t.Errorf("Expected the excluded directory %s not to be walked", rel)
+		}
+	}
+	// .git is left out by default, so only gen is recorded
+	if len(g.skipped) != 1 || g.skipped[0].Path != "gen" || g.skipped[0].Detail != "directory" {
+		t.Errorf("Expected only gen to be skipped as a directory, got %+v", g.skipped)
+	}
+}
+
+func TestParsePatternLine(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{"vendor/", "vendor/"},
+		{"  *.log  ", "*.log"},
+		{"# comment", ""},
+		{"   # indented comment", ""},
+		{"", ""},
+		{"vendor/ # third party", "vendor/"},
+		{"vendor/\t# third party", "vendor/"},
+		{"issue#1.txt", "issue#1.txt"},
+		{`\#notes.md`, "#notes.md"},
+		{`a\ #b`, "a #b"},
+		{`trailing\ `, "trailing "},
+		{"crlf.txt\r", "crlf.txt"},
+		{`*.\[ch\]`, `*.\[ch\]`},
+	}
+	for _, tc := range testCases {
+		if got := parsePatternLine(tc.line); got != tc.expected {
+			t.Errorf("parsePatternLine(%q) = %q, expected %q", tc.line, got, tc.expected)
+		}
+	}
+}
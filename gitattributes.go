@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitAttributesFile marks paths with git attributes. Its patterns are relative
+// to the directory it is in, and deeper files take precedence.
+const gitAttributesFile = ".gitattributes"
+
+// excludingAttributes are the git attributes that exclude a path: generated
+// code as marked for GitHub's linguist, and files left out of git archive.
+var excludingAttributes = []string{"linguist-generated", "export-ignore"}
+
+// WithGitAttributes controls whether paths marked linguist-generated or
+// export-ignore in the .gitattributes files of the tree are excluded, which
+// they are by default.
+func WithGitAttributes(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.noGitAttributes = !enabled
+	}
+}
+
+// attributeRule is a line of a .gitattributes file: a pattern and the
+// excluding attributes it sets (true), unsets (false) or makes unspecified.
+type attributeRule struct {
+	pattern string
+	attrs   map[string]*bool
+}
+
+// parseGitAttributes parses a .gitattributes file, keeping the lines that
+// change one of the excludingAttributes. Macro definitions and quoted
+// patterns are ignored, as are patterns ending with a slash, which git
+// doesn't match either.
+func parseGitAttributes(content string) []attributeRule {
+	var rules []attributeRule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[") ||
+			strings.HasPrefix(fields[0], "\"") || strings.HasPrefix(fields[0], "!") || strings.HasSuffix(fields[0], "/") {
+			continue
+		}
+		rule := attributeRule{pattern: fields[0], attrs: make(map[string]*bool)}
+		for _, field := range fields[1:] {
+			name, value, hasValue := strings.Cut(field, "=")
+			var set *bool
+			switch {
+			case strings.HasPrefix(name, "-"):
+				name, set = name[1:], new(bool)
+			case strings.HasPrefix(name, "!"):
+				name = name[1:]
+			default:
+				set = new(bool)
+				*set = !hasValue || value != "false"
+			}
+			for _, attr := range excludingAttributes {
+				if name == attr {
+					rule.attrs[name] = set
+				}
+			}
+		}
+		if len(rule.attrs) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matches reports whether the rule matches relPath, relative to the directory
+// of its .gitattributes. Patterns without a slash match the name in any
+// directory, like in .gitignore.
+func (r attributeRule) matches(relPath string) bool {
+	if !strings.Contains(r.pattern, "/") {
+		matched, _ := path.Match(r.pattern, path.Base(relPath))
+		return matched
+	}
+	return matchGlob(strings.TrimPrefix(r.pattern, "/"), relPath)
+}
+
+// gitAttributes returns the rules of the .gitattributes in dir, relative to
+// the start path, reading it on first use.
+func (g *Git2LLM) gitAttributes(dir string) []attributeRule {
+	if rules, ok := g.attributeRules[dir]; ok {
+		return rules
+	}
+	if g.attributeRules == nil {
+		g.attributeRules = make(map[string][]attributeRule)
+	}
+	var rules []attributeRule
+	if content, err := g.fs.ReadFile(filepath.Join(g.startPath, filepath.FromSlash(dir), gitAttributesFile)); err == nil {
+		rules = parseGitAttributes(string(content))
+	}
+	g.attributeRules[dir] = rules
+	return rules
+}
+
+// excludingAttribute returns the attribute that excludes relPath, along with
+// the .gitattributes file setting it, or "" if there is none. The last
+// matching line of the deepest file wins, as in git.
+func (g *Git2LLM) excludingAttribute(relPath string) (attr, source string) {
+	if g.noGitAttributes || g.fs == nil {
+		return "", ""
+	}
+	set := make(map[string]string) // Attribute to the file setting it
+	var dirs []string
+	for dir := parentDir(relPath); ; dir = parentDir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "" {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		rel := relPath
+		if dir != "" {
+			rel = relPath[len(dir)+1:]
+		}
+		for _, rule := range g.gitAttributes(dir) {
+			if !rule.matches(rel) {
+				continue
+			}
+			for name, value := range rule.attrs {
+				if value != nil && *value {
+					set[name] = path.Join(dir, gitAttributesFile)
+				} else {
+					delete(set, name)
+				}
+			}
+		}
+	}
+	for _, attr := range excludingAttributes {
+		if source, ok := set[attr]; ok {
+			return attr, source
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExcludingAttribute(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		".gitattributes": {Data: []byte("# Generated code\n" +
+			"*.pb.go linguist-generated=true\n" +
+			"/docs export-ignore\n" +
+			"api/gen/** linguist-generated\n" +
+			"api/gen/keep.go -linguist-generated\n" +
+			"*.txt text eol=lf\n" +
+			"legacy/ export-ignore\n")},
+		"web/.gitattributes": {Data: []byte("dist.js linguist-generated\nschema.pb.go linguist-generated=false\n")},
+	}}
+	g := &Git2LLM{fs: fsys, startPath: "."}
+	testCases := []struct {
+		relPath, attr, source string
+	}{
+		{"main.go", "", ""},
+		{"api/api.pb.go", "linguist-generated", ".gitattributes"},
+		{"api/gen/client.go", "linguist-generated", ".gitattributes"},
+		{"api/gen/keep.go", "", ""},
+		{"docs", "export-ignore", ".gitattributes"},
+		{"pkg/docs", "", ""},
+		{"notes.txt", "", ""},
+		{"legacy", "", ""},
+		{"web/dist.js", "linguist-generated", "web/.gitattributes"},
+		{"dist.js", "", ""},
+		{"web/schema.pb.go", "", ""},
+	}
+	for _, tc := range testCases {
+		attr, source := g.excludingAttribute(tc.relPath)
+		if attr != tc.attr || source != tc.source {
+			t.Errorf("excludingAttribute(%q): expected %q from %q, got %q from %q", tc.relPath, tc.attr, tc.source, attr, source)
+		}
+	}
+
+	WithGitAttributes(false)(g)
+	if attr, _ := g.excludingAttribute("api/api.pb.go"); attr != "" {
+		t.Errorf("Expected no attribute with .gitattributes disabled, got %q", attr)
+	}
+}
+
+func TestGit2LLMGitAttributes(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		".gitattributes":   {Data: []byte("gen/** linguist-generated\n")},
+		"main.go":          {Data: []byte("package main\n")},
+		"gen/generated.go": {Data: []byte("package gen\n")},
+	}}
+	for _, enabled := range []bool{true, false} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(".", []string{".go"}, fsys, &output, false, false, false, nil, "", false, WithGitAttributes(enabled))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		result := output.String()
+		if !strings.Contains(result, "File: main.go") {
+			t.Errorf("Expected main.go in the output, got:\n%s", result)
+		}
+		if included := strings.Contains(result, "generated.go"); included == enabled {
+			t.Errorf("With .gitattributes %v, expected generated.go included %v, got:\n%s", enabled, !enabled, result)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	githubAPIURL = "https://api.github.com"
+	githubRawURL = "https://raw.githubusercontent.com"
+)
+
+// githubFS implements FS on top of a GitHub repository, so remote repositories
+// can be scanned without a local clone. The tree is fetched with a single API
+// call; file contents are downloaded on first access and kept in memory.
+// Paths are relative to the repository root, which is ".".
+type githubFS struct {
+	*treeFS
+	owner, repo, ref string
+	token            string
+	apiURL, rawURL   string
+	client           *http.Client
+	logger           *slog.Logger
+}
+
+// parseGitHubSpec splits "owner/repo[#ref]" into its parts. The ref defaults to HEAD.
+func parseGitHubSpec(spec string) (owner, repo, ref string, err error) {
+	spec, ref, _ = strings.Cut(spec, "#")
+	owner, repo, ok := strings.Cut(spec, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", "", fmt.Errorf("invalid GitHub repository %q, expected owner/repo[#ref]", spec)
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return owner, strings.TrimSuffix(repo, ".git"), ref, nil
+}
+
+// newGitHubFS fetches the tree of a GitHub repository given as "owner/repo[#ref]".
+// token is optional and needed for private repositories and higher rate limits.
+func newGitHubFS(spec, token string, logger *slog.Logger) (*githubFS, error) {
+	owner, repo, ref, err := parseGitHubSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	g := &githubFS{
+		owner:  owner,
+		repo:   repo,
+		ref:    ref,
+		token:  token,
+		apiURL: githubAPIURL,
+		rawURL: githubRawURL,
+		client: &http.Client{Timeout: time.Minute},
+		logger: logger,
+	}
+	return g, g.loadTree()
+}
+
+func (g *githubFS) loadTree() error {
+	u := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1", g.apiURL, g.owner, g.repo, url.PathEscape(g.ref))
+	body, err := g.get(u, "application/vnd.github+json")
+	if err != nil {
+		return fmt.Errorf("error fetching tree: %w", err)
+	}
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Mode string `json:"mode"`
+			Type string `json:"type"`
+			Sha  string `json:"sha"`
+			Size int64  `json:"size"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.Unmarshal(body, &tree); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if tree.Truncated && g.logger != nil {
+		g.logger.Warn("GitHub truncated the tree, some files are missing", "repository", g.owner+"/"+g.repo)
+	}
+
+	var entries []*treeEntry
+	for _, item := range tree.Tree {
+		switch item.Type {
+		case "blob", "tree":
+		default:
+			continue // Submodules have no content in this repository
+		}
+		entries = append(entries, &treeEntry{path: item.Path, mode: item.Mode, sha: item.Sha, size: item.Size, isDir: item.Type == "tree"})
+	}
+	g.treeFS = newTreeFS(entries, g.fetch)
+	return nil
+}
+
+// get performs a GET request and returns the body, turning rate limiting into a readable error.
+func (g *githubFS) get(u, accept string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http.Do: %w", err)
+	}
+	defer resp.Body.Close()
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		msg := "GitHub API rate limit exceeded"
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			msg += fmt.Sprintf(", resets at %s", time.Unix(reset, 0).Format(time.Kitchen))
+		}
+		if g.token == "" {
+			msg += " (set GITHUB_TOKEN for a higher limit)"
+		}
+		return nil, fmt.Errorf("%s", msg)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// fetch downloads the content of a file.
+func (g *githubFS) fetch(e *treeEntry) ([]byte, error) {
+	if g.token != "" {
+		// raw.githubusercontent.com doesn't serve private repositories, the blob API does
+		u := fmt.Sprintf("%s/repos/%s/%s/git/blobs/%s", g.apiURL, g.owner, g.repo, e.sha)
+		return g.get(u, "application/vnd.github.raw")
+	}
+	u := fmt.Sprintf("%s/%s/%s/%s/%s", g.rawURL, g.owner, g.repo, url.PathEscape(g.ref), escapePath(e.path))
+	return g.get(u, "*/*")
+}
+
+// escapePath escapes every element of a slash separated path for use in a URL.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
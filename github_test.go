@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGitHubSpec(t *testing.T) {
+	testCases := []struct {
+		spec             string
+		owner, repo, ref string
+		expectErr        bool
+	}{
+		{"perbu/git2llm", "perbu", "git2llm", "HEAD", false},
+		{"perbu/git2llm#v0.7.1", "perbu", "git2llm", "v0.7.1", false},
+		{"perbu/git2llm.git#main", "perbu", "git2llm", "main", false},
+		{"git2llm", "", "", "", true},
+		{"perbu/git2llm/extra", "", "", "", true},
+	}
+	for _, tc := range testCases {
+		owner, repo, ref, err := parseGitHubSpec(tc.spec)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("For %q, expected error: %v, got: %v", tc.spec, tc.expectErr, err)
+			continue
+		}
+		if owner != tc.owner || repo != tc.repo || ref != tc.ref {
+			t.Errorf("For %q, expected %s/%s#%s, got %s/%s#%s", tc.spec, tc.owner, tc.repo, tc.ref, owner, repo, ref)
+		}
+	}
+}
+
+func newTestGitHubServer(t *testing.T) *httptest.Server {
+	files := map[string]string{
+		"README.md":       "# Remote",
+		"cmd/app/main.go": "package main",
+		"logo.png":        "\x89PNG\x00\x00",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/demo/git/trees/main":
+			fmt.Fprint(w, `{"tree": [
+				{"path": "README.md", "mode": "100644", "type": "blob", "sha": "a1", "size": 8},
+				{"path": "cmd", "mode": "040000", "type": "tree", "sha": "t1"},
+				{"path": "cmd/app", "mode": "040000", "type": "tree", "sha": "t2"},
+				{"path": "cmd/app/main.go", "mode": "100644", "type": "blob", "sha": "b1", "size": 12},
+				{"path": "logo.png", "mode": "100644", "type": "blob", "sha": "c1", "size": 6},
+				{"path": "vendored", "mode": "160000", "type": "commit", "sha": "d1"}
+			], "truncated": false}`)
+		case strings.HasPrefix(r.URL.Path, "/raw/octo/demo/main/"):
+			content, ok := files[strings.TrimPrefix(r.URL.Path, "/raw/octo/demo/main/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestGitHubFSScan(t *testing.T) {
+	server := newTestGitHubServer(t)
+	defer server.Close()
+
+	fsys := &githubFS{owner: "octo", repo: "demo", ref: "main", apiURL: server.URL, rawURL: server.URL + "/raw", client: server.Client()}
+	if err := fsys.loadTree(); err != nil {
+		t.Fatalf("loadTree failed: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	for _, expected := range []string{"cmd/\n", "app/\n", "Content of README.md:\n# Remote", "Content of cmd/app/main.go:\npackage main", "File: logo.png (Binary - skipped content)"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "vendored") {
+		t.Errorf("Did not expect submodule in output. Result:\n%s", result)
+	}
+}
+
+func TestGitHubFSRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	fsys := &githubFS{owner: "octo", repo: "demo", ref: "HEAD", apiURL: server.URL, client: server.Client()}
+	err := fsys.loadTree()
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded") || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("Expected a rate limit error mentioning GITHUB_TOKEN, got %v", err)
+	}
+}
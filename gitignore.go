@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// WithGitIgnored excludes the given paths, relative to the start path, as if
+// they matched an exclusion pattern. Directories exclude everything below them.
+func WithGitIgnored(paths []string) Option {
+	return func(g *Git2LLM) {
+		g.gitIgnored = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			g.gitIgnored[strings.TrimSuffix(filepath.ToSlash(p), "/")] = true
+		}
+	}
+}
+
+// isGitIgnored reports whether relPath or one of its parent directories is ignored by git.
+func (g *Git2LLM) isGitIgnored(relPath string) bool {
+	if len(g.gitIgnored) == 0 {
+		return false
+	}
+	for p := relPath; p != "." && p != ""; p = parentDir(p) {
+		if g.gitIgnored[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func parentDir(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// gitIgnoredFiles returns the untracked files and directories below dir that git
+// ignores, relative to dir. Git applies every .gitignore, .git/info/exclude and
+// the global core.excludesFile, so the result matches what git status shows.
+// Ignored directories are listed once, with a trailing slash.
+func gitIgnoredFiles(dir string) ([]string, error) {
+	return runGit(dir, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGitIgnoredFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	repo := filepath.Join(tempDir, "repo")
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// The global excludes file is configured in a private global config
+	globalExcludes := filepath.Join(tempDir, "excludes")
+	write(globalExcludes, "*.swp\n")
+	globalConfig := filepath.Join(tempDir, "gitconfig")
+	write(globalConfig, "[core]\n\texcludesFile = "+filepath.ToSlash(globalExcludes)+"\n")
+	t.Setenv("GIT_CONFIG_GLOBAL", globalConfig)
+
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	git("init", "-q")
+	write(filepath.Join(repo, ".gitignore"), "*.log\nbuild/\n")
+	write(filepath.Join(repo, ".git", "info", "exclude"), "local.txt\n")
+	write(filepath.Join(repo, "main.go"), "package main\n")
+	write(filepath.Join(repo, "tracked.log"), "tracked despite the pattern\n")
+	git("add", "-f", "main.go", "tracked.log", ".gitignore")
+	git("commit", "-q", "-m", "initial")
+	write(filepath.Join(repo, "debug.log"), "ignored\n")
+	write(filepath.Join(repo, "build", "out.txt"), "ignored\n")
+	write(filepath.Join(repo, "local.txt"), "ignored\n")
+	write(filepath.Join(repo, "main.go.swp"), "ignored\n")
+	write(filepath.Join(repo, "new.go"), "package main\n")
+
+	ignored, err := gitIgnoredFiles(repo)
+	if err != nil {
+		t.Fatalf("gitIgnoredFiles failed: %v", err)
+	}
+	sort.Strings(ignored)
+	if expected := []string{"build/", "debug.log", "local.txt", "main.go.swp"}; !reflect.DeepEqual(ignored, expected) {
+		t.Errorf("Expected %v, got %v", expected, ignored)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(repo, nil, nil, &output, false, false, false, nil, "", false, WithGitIgnored(ignored))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, name := range []string{"main.go", "new.go", "tracked.log"} {
+		if !strings.Contains(result, "File: "+name+"\n") {
+			t.Errorf("Expected %s in the output", name)
+		}
+	}
+	for _, name := range ignored {
+		if strings.Contains(result, strings.TrimSuffix(name, "/")) {
+			t.Errorf("Expected %s to be left out, got:\n%s", name, result)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// gitIndexFS implements FS on the working tree, but lists directories from the
+// git index instead of reading them. Only tracked files are seen, so untracked
+// build output is skipped without being walked, and large trees on network file
+// systems are listed by git from its index. Files are read from disk.
+type gitIndexFS struct {
+	OSFS
+	dir      string
+	children map[string][]os.DirEntry // Slash separated directory relative to dir -> sorted entries
+}
+
+// newGitIndexFS lists the files below dir, which is inside a git repository,
+// from its index. Files deleted from the working tree and submodules are left out.
+func newGitIndexFS(dir string) (*gitIndexFS, error) {
+	out, err := gitOutput(dir, "ls-files", "-z", "--stage")
+	if err != nil {
+		return nil, fmt.Errorf("error listing the git index: %w", err)
+	}
+	deleted, err := gitOutput(dir, "ls-files", "-z", "--deleted")
+	if err != nil {
+		return nil, fmt.Errorf("error listing the git index: %w", err)
+	}
+	gone := make(map[string]bool)
+	for _, name := range bytes.Split(deleted, []byte{0}) {
+		gone[string(name)] = true
+	}
+
+	f := &gitIndexFS{dir: dir, children: map[string][]os.DirEntry{".": nil}}
+	seen := make(map[string]bool)
+	for _, record := range bytes.Split(out, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+		// <mode> SP <object> SP <stage> TAB <path>, with a stage per side of a conflict
+		meta, name, ok := strings.Cut(string(record), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected ls-files output %q", record)
+		}
+		if fields[0] == "160000" || gone[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		f.add(name, fields[0], false)
+		// Add the parent directories the first time they are seen
+		for dir := path.Dir(name); dir != "." && !seen[dir+"/"]; dir = path.Dir(dir) {
+			seen[dir+"/"] = true
+			f.add(dir, "", true)
+		}
+	}
+	for _, children := range f.children {
+		sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+	}
+	return f, nil
+}
+
+func (f *gitIndexFS) add(name, mode string, isDir bool) {
+	parent := path.Dir(name)
+	f.children[parent] = append(f.children[parent], indexEntry{
+		path:  filepath.Join(f.dir, filepath.FromSlash(name)),
+		mode:  mode,
+		isDir: isDir,
+	})
+	if isDir && f.children[name] == nil {
+		f.children[name] = []os.DirEntry{}
+	}
+}
+
+func (f *gitIndexFS) ReadDir(name string) ([]os.DirEntry, error) {
+	rel, err := filepath.Rel(f.dir, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	children, ok := f.children[filepath.ToSlash(rel)]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(children), nil // Callers sort and filter in place, as os.ReadDir allows
+}
+
+// indexEntry implements os.DirEntry for a file or directory of the git index.
+// The file info is read from disk when asked for.
+type indexEntry struct {
+	path  string
+	mode  string // git file mode, e.g. 100644; empty for directories
+	isDir bool
+}
+
+func (e indexEntry) Name() string { return filepath.Base(e.path) }
+func (e indexEntry) IsDir() bool  { return e.isDir }
+func (e indexEntry) Type() fs.FileMode {
+	switch {
+	case e.isDir:
+		return fs.ModeDir
+	case e.mode == "120000":
+		return fs.ModeSymlink
+	}
+	return 0
+}
+func (e indexEntry) Info() (fs.FileInfo, error) { return os.Lstat(e.path) }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitIndexFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "-q")
+	write("main.go", "package main\n")
+	write("pkg/api/handler.go", "package api\n")
+	write("pkg/gone.go", "package pkg\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	write("build/out.go", "package build\n")
+	write("new.go", "package main\n")
+	if err := os.Remove(filepath.Join(tempDir, "pkg", "gone.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	indexFS, err := newGitIndexFS(tempDir)
+	if err != nil {
+		t.Fatalf("newGitIndexFS failed: %v", err)
+	}
+	entries, err := indexFS.ReadDir(filepath.Join(tempDir, "pkg"))
+	if err != nil || len(entries) != 1 || entries[0].Name() != "api" || !entries[0].IsDir() {
+		t.Errorf("Expected pkg to list only the api directory, got %v (%v)", entries, err)
+	}
+
+	var output strings.Builder
+	g, err := NewGit2LLM(tempDir, nil, indexFS, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := g.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, name := range []string{"main.go", "pkg/api/handler.go"} {
+		if !strings.Contains(result, "File: "+name+"\n") {
+			t.Errorf("Expected the tracked file %s in the output. Result:\n%s", name, result)
+		}
+	}
+	for _, name := range []string{"build", "out.go", "new.go", "gone.go"} {
+		if strings.Contains(result, name) {
+			t.Errorf("Expected %s to be left out. Result:\n%s", name, result)
+		}
+	}
+}
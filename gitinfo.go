@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// commitLinePrefix starts the lines of git log describing a commit, as opposed
+// to the names of the files it changed.
+const commitLinePrefix = "\x01"
+
+// CommitInfo describes the last commit that changed a file.
+type CommitInfo struct {
+	Hash   string    `json:"hash"`
+	Author string    `json:"author"`
+	Date   time.Time `json:"date"`
+}
+
+// WithFileGitInfo adds the last commit of every file whose content is written
+// to its header and to its record in the JSON output, from commits by path
+// relative to the start path, as returned by lastCommits. Files without a
+// commit are marked uncommitted.
+func WithFileGitInfo(commits map[string]CommitInfo) Option {
+	return func(g *Git2LLM) {
+		g.commits = commits
+	}
+}
+
+// commitAnnotation returns the annotation of the header of the file at
+// relPath, relative to the start path, such as
+// "commit 1a2b3c4d5e6f, Jane Doe, 2024-05-01", and its commit, if any.
+func (g *Git2LLM) commitAnnotation(relPath string) (string, *CommitInfo) {
+	c, ok := g.commits[relPath]
+	if !ok {
+		return "uncommitted", nil
+	}
+	hash := c.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	return fmt.Sprintf("commit %s, %s, %s", hash, c.Author, c.Date.Format(time.DateOnly)), &c
+}
+
+// lastCommits returns the last commit of ref, or HEAD if empty, that changed
+// each file below dir, by path relative to dir. The whole history is read
+// with a single git log, newest first.
+func lastCommits(dir, ref string) (map[string]CommitInfo, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	lines, err := runGit(dir, "log", "--format="+commitLinePrefix+"%H%x09%an%x09%aI", "--name-only", "--relative", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]CommitInfo)
+	var current CommitInfo
+	for _, line := range lines {
+		if meta, ok := strings.CutPrefix(line, commitLinePrefix); ok {
+			fields := strings.SplitN(meta, "\t", 3)
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("unexpected git log output %q", line)
+			}
+			date, err := time.Parse(time.RFC3339, fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected git log date %q: %w", fields[2], err)
+			}
+			current = CommitInfo{Hash: fields[0], Author: fields[1], Date: date}
+			continue
+		}
+		if _, seen := commits[line]; !seen && current.Hash != "" {
+			commits[line] = current
+		}
+	}
+	return commits, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLastCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(author, date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir, "-c", "user.name=" + author, "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	git("Jane Doe", "2024-01-01T10:00:00Z", "init", "-q")
+	write("old.go", "package main\n")
+	write("pkg/edited.go", "package pkg\n")
+	git("Jane Doe", "2024-01-01T10:00:00Z", "add", ".")
+	git("Jane Doe", "2024-01-01T10:00:00Z", "commit", "-q", "-m", "initial")
+	write("pkg/edited.go", "package pkg\n\nfunc F() {}\n")
+	git("John Roe", "2024-05-01T12:00:00Z", "commit", "-q", "-a", "-m", "edit")
+
+	commits, err := lastCommits(tempDir, "")
+	if err != nil {
+		t.Fatalf("lastCommits failed: %v", err)
+	}
+	testCases := []struct {
+		path, author string
+		date         time.Time
+	}{
+		{"old.go", "Jane Doe", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{"pkg/edited.go", "John Roe", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		c, ok := commits[tc.path]
+		if !ok {
+			t.Errorf("Expected a commit for %s, got %v", tc.path, commits)
+			continue
+		}
+		if c.Author != tc.author || !c.Date.Equal(tc.date) || len(c.Hash) != 40 {
+			t.Errorf("%s: expected a commit by %s on %v, got %+v", tc.path, tc.author, tc.date, c)
+		}
+	}
+	if commits["old.go"].Hash == commits["pkg/edited.go"].Hash {
+		t.Errorf("Expected different commits, got %v", commits)
+	}
+
+	// A subdirectory has paths relative to it, and a ref its own history
+	commits, err = lastCommits(filepath.Join(tempDir, "pkg"), "HEAD~1")
+	if err != nil {
+		t.Fatalf("lastCommits failed: %v", err)
+	}
+	if c := commits["edited.go"]; c.Author != "Jane Doe" || len(commits) != 1 {
+		t.Errorf("Expected edited.go committed by Jane Doe at HEAD~1, got %v", commits)
+	}
+}
+
+func TestGit2LLMFileGitInfo(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		"main.go": {Data: []byte("package main\n")},
+		"new.go":  {Data: []byte("package main\n")},
+	}}
+	commits := map[string]CommitInfo{
+		"main.go": {Hash: "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d", Author: "Jane Doe", Date: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", []string{".go"}, fsys, &output, false, false, false, nil, "", false,
+		WithFileGitInfo(commits), WithPathPrefix("app"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{
+		"File: app/main.go (commit 1a2b3c4d5e6f, Jane Doe, 2024-05-01)\n",
+		"File: app/new.go (uncommitted)\n",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, result)
+		}
+	}
+	if c := git2llm.results[0].Commit; c == nil || c.Hash != commits["main.go"].Hash {
+		t.Errorf("Expected the commit of main.go in its result, got %+v", c)
+	}
+	if c := git2llm.results[1].Commit; c != nil {
+		t.Errorf("Expected no commit for new.go, got %+v", c)
+	}
+}
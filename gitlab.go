@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const gitlabURL = "https://gitlab.com"
+
+// gitlabFS implements FS on top of a GitLab project, on gitlab.com or a
+// self-hosted instance, like githubFS. The tree is listed a page at a time;
+// file contents are downloaded on first access and kept in memory. GitLab
+// doesn't list the sizes of files, so a file is downloaded when its size is
+// needed.
+type gitlabFS struct {
+	*treeFS
+	project, ref string // ref is empty for the default branch
+	token        string
+	baseURL      string
+	client       *http.Client
+}
+
+// parseRemoteSpec splits "namespace/repo[#ref]" into the path of the
+// repository and the ref, which is empty if not given. With nested, the
+// namespace may have several levels, as GitLab groups do.
+func parseRemoteSpec(spec, service string, nested bool) (repo, ref string, err error) {
+	repo, ref, _ = strings.Cut(spec, "#")
+	repo = strings.TrimSuffix(repo, ".git")
+	parts := strings.Split(repo, "/")
+	valid := len(parts) == 2 || nested && len(parts) > 2
+	for _, part := range parts {
+		valid = valid && part != ""
+	}
+	if !valid {
+		return "", "", fmt.Errorf("invalid %s repository %q, expected namespace/repo[#ref]", service, spec)
+	}
+	return repo, ref, nil
+}
+
+// newGitLabFS fetches the tree of a GitLab project given as
+// "group[/subgroup]/project[#ref]" from the instance at baseURL, gitlab.com if
+// empty. token is optional and needed for private projects.
+func newGitLabFS(spec, baseURL, token string) (*gitlabFS, error) {
+	project, ref, err := parseRemoteSpec(spec, "GitLab", true)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = gitlabURL
+	}
+	g := &gitlabFS{
+		project: project,
+		ref:     ref,
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: time.Minute},
+	}
+	return g, g.loadTree()
+}
+
+// api returns the URL of an API endpoint of the project.
+func (g *gitlabFS) api(endpoint string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s/%s", g.baseURL, url.PathEscape(g.project), endpoint)
+}
+
+func (g *gitlabFS) loadTree() error {
+	query := url.Values{"recursive": {"true"}, "per_page": {"100"}}
+	if g.ref != "" {
+		query.Set("ref", g.ref)
+	}
+	var entries []*treeEntry
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		body, header, err := g.get(g.api("repository/tree?" + query.Encode()))
+		if err != nil {
+			return fmt.Errorf("error fetching tree: %w", err)
+		}
+		var items []struct {
+			ID   string `json:"id"`
+			Path string `json:"path"`
+			Type string `json:"type"`
+			Mode string `json:"mode"`
+		}
+		if err := json.Unmarshal(body, &items); err != nil {
+			return fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		for _, item := range items {
+			switch item.Type {
+			case "blob", "tree":
+			default:
+				continue // Submodules have no content in this project
+			}
+			entries = append(entries, &treeEntry{path: item.Path, mode: item.Mode, sha: item.ID, size: -1, isDir: item.Type == "tree"})
+		}
+		page = header.Get("X-Next-Page")
+	}
+	g.treeFS = newTreeFS(entries, g.fetch)
+	return nil
+}
+
+// get performs a GET request and returns the body and the response headers.
+func (g *gitlabFS) get(u string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("http.Do: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, nil, fmt.Errorf("GitLab API rate limit exceeded, retry after %ss", resp.Header.Get("Retry-After"))
+	case resp.StatusCode == http.StatusNotFound && g.token == "":
+		return nil, nil, fmt.Errorf("GET %s: %s (set GITLAB_TOKEN for private projects)", u, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	return body, resp.Header, err
+}
+
+// fetch downloads the content of a file.
+func (g *gitlabFS) fetch(e *treeEntry) ([]byte, error) {
+	body, _, err := g.get(g.api("repository/blobs/" + e.sha + "/raw"))
+	return body, err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRemoteSpec(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		nested    bool
+		repo, ref string
+		expectErr bool
+	}{
+		{"octo/demo", false, "octo/demo", "", false},
+		{"octo/demo.git#v1.0", false, "octo/demo", "v1.0", false},
+		{"group/sub/demo#main", true, "group/sub/demo", "main", false},
+		{"group/sub/demo", false, "", "", true},
+		{"demo", true, "", "", true},
+		{"octo//demo", true, "", "", true},
+	}
+	for _, tc := range testCases {
+		repo, ref, err := parseRemoteSpec(tc.spec, "test", tc.nested)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("For %q, expected error: %v, got: %v", tc.spec, tc.expectErr, err)
+			continue
+		}
+		if repo != tc.repo || ref != tc.ref {
+			t.Errorf("For %q, expected %s#%s, got %s#%s", tc.spec, tc.repo, tc.ref, repo, ref)
+		}
+	}
+}
+
+func TestGitLabFSScan(t *testing.T) {
+	blobs := map[string]string{
+		"a1": "# Remote",
+		"b1": "package main",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/api/v4/projects/group/sub/demo/repository/"
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case r.URL.Path == prefix+"tree" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[
+				{"id": "a1", "path": "README.md", "type": "blob", "mode": "100644"},
+				{"id": "t1", "path": "cmd", "type": "tree", "mode": "040000"}
+			]`)
+		case r.URL.Path == prefix+"tree" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `[
+				{"id": "b1", "path": "cmd/main.go", "type": "blob", "mode": "100644"},
+				{"id": "d1", "path": "vendored", "type": "commit", "mode": "160000"}
+			]`)
+		case strings.HasPrefix(r.URL.Path, prefix+"blobs/"):
+			content, ok := blobs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix+"blobs/"), "/raw")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fsys, err := newGitLabFS("group/sub/demo", server.URL+"/", "secret")
+	if err != nil {
+		t.Fatalf("newGitLabFS failed: %v", err)
+	}
+	info, err := fsys.Stat("cmd/main.go")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != int64(len("package main")) {
+		t.Errorf("Expected the size of the downloaded file, got %d", info.Size())
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	for _, expected := range []string{"cmd/\n", "Content of README.md:\n# Remote", "Content of cmd/main.go:\npackage main"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "vendored") {
+		t.Errorf("Did not expect submodule in output. Result:\n%s", result)
+	}
+}
+
+func TestGitLabFSNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := newGitLabFS("group/private", server.URL, "")
+	if err == nil || !strings.Contains(err.Error(), "GITLAB_TOKEN") {
+		t.Errorf("Expected an error mentioning GITLAB_TOKEN, got %v", err)
+	}
+}
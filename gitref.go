@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// gitRefFS implements FS on the tree of a git ref (a branch, tag or commit), read
+// from the object database instead of the working tree. This works for bare
+// repositories and leaves the worktree untouched. It runs the git command, as
+// do the other git options. Paths are relative to the directory the FS was
+// created for, which is ".".
+type gitRefFS struct {
+	*treeFS
+	dir string
+	ref string
+}
+
+// newGitRefFS lists the tree of ref below dir, which is inside a git repository.
+func newGitRefFS(dir, ref string) (*gitRefFS, error) {
+	out, err := gitOutput(dir, "ls-tree", "-r", "-t", "-l", "-z", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", ref, err)
+	}
+	g := &gitRefFS{dir: dir, ref: ref}
+	var entries []*treeEntry
+	for _, record := range bytes.Split(out, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+		// <mode> SP <type> SP <object> SP <size> TAB <path>
+		meta, name, ok := strings.Cut(string(record), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected ls-tree output %q", record)
+		}
+		switch fields[1] {
+		case "blob", "tree":
+		default:
+			continue // Submodules have no content in this repository
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64) // "-" for trees
+		entries = append(entries, &treeEntry{path: name, mode: fields[0], sha: fields[2], size: size, isDir: fields[1] == "tree"})
+	}
+	g.treeFS = newTreeFS(entries, g.fetch)
+	g.treeFS.localFetch = true
+	return g, nil
+}
+
+// fetch reads the content of a file from the object database.
+func (g *gitRefFS) fetch(e *treeEntry) ([]byte, error) {
+	return gitOutput(g.dir, "cat-file", "blob", e.sha)
+}
+
+// gitOutput runs git in dir and returns its output.
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("git %s: %w (the git options need git installed)", args[0], err)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitRefFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	repoDir := filepath.Join(tempDir, "repo")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repoDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	if err := os.Mkdir(repoDir, 0755); err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	git(repoDir, "init", "-q")
+	write("main.go", "package main // v1\n")
+	write("pkg/lib.go", "package pkg\n")
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-q", "-m", "v1")
+	git(repoDir, "tag", "v1")
+	write("main.go", "package main // work in progress\n")
+	write("new.go", "package main\n")
+	git(repoDir, "clone", "-q", "--bare", repoDir, filepath.Join(tempDir, "bare.git"))
+
+	for _, dir := range []string{repoDir, filepath.Join(tempDir, "bare.git")} {
+		fsys, err := newGitRefFS(dir, "v1")
+		if err != nil {
+			t.Fatalf("newGitRefFS(%s) failed: %v", dir, err)
+		}
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false)
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		result := output.String()
+		if !strings.Contains(result, "Content of main.go:\npackage main // v1\n") {
+			t.Errorf("Expected main.go as of v1 in %s, got:\n%s", dir, result)
+		}
+		if !strings.Contains(result, "File: pkg/lib.go") {
+			t.Errorf("Expected pkg/lib.go in %s", dir)
+		}
+		if strings.Contains(result, "new.go") {
+			t.Errorf("Expected the untracked new.go to be absent from the v1 tree in %s", dir)
+		}
+		// The sizes are listed, so no content is kept for the rest of the scan
+		if len(fsys.contents) != 0 {
+			t.Errorf("Expected no contents to be kept in %s, got %d", dir, len(fsys.contents))
+		}
+	}
+
+	if _, err := newGitRefFS(repoDir, "no-such-ref"); err == nil {
+		t.Errorf("Expected an error for an unknown ref")
+	}
+}
@@ -4,6 +4,7 @@ go 1.23.5
 
 require (
 	cloud.google.com/go/vertexai v0.13.4
+	github.com/klauspost/compress v1.18.0
 	github.com/tiktoken-go/tokenizer v0.6.2
 )
 
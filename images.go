@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/perbu/git2llm/llm"
+)
+
+const (
+	// imageDescribeMaxSize is the largest image sent to a vision model.
+	imageDescribeMaxSize = 5 << 20
+	// imageDescribeTimeout limits how long a single image description may take.
+	imageDescribeTimeout = 2 * time.Minute
+)
+
+// imageExtensions are the raster images --images includes, by extension.
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+// imageStreamer is the part of llm.Client used for image descriptions.
+type imageStreamer interface {
+	StreamImage(ctx context.Context, prompt string, image llm.Image, w io.Writer) error
+	Model() string
+}
+
+// WithImages includes images instead of skipping them as binary: raster
+// images with their size, format and dimensions and, if describer is not nil,
+// a one-line description written by a vision model; SVG images with their
+// dimensions and text instead of their markup. Screenshots and diagrams in
+// documentation often carry context the code doesn't.
+func WithImages(enabled bool, describer imageStreamer) Option {
+	return func(g *Git2LLM) {
+		g.images = enabled
+		g.imageDescriber = describer
+		if enabled {
+			g.transformers = append(g.transformers, svgText{})
+		}
+	}
+}
+
+// isImage reports whether the file at relPath is a raster image included by WithImages.
+func (g *Git2LLM) isImage(relPath string) bool {
+	return g.images && imageExtensions[strings.ToLower(path.Ext(relPath))]
+}
+
+// writeImage writes the stanza of the image at filePath: its metadata and,
+// with a describer, its description.
+func (g *Git2LLM) writeImage(filePath, relPath string) error {
+	g.skip(relPath, SkipBinary, "image")
+	var description string
+	if g.imageDescriber != nil {
+		var err error
+		if description, err = g.describeImage(filePath); err != nil {
+			g.logger.Warn("Error describing image", "path", relPath, "error", err)
+		}
+	}
+	annotation := "Image - metadata only"
+	if description != "" {
+		annotation = "Image - described"
+	}
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (%s)\n", g.fileHeader(relPath), annotation); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if err := g.writeSeparator(g.outputWriter); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (Image)\n", g.contentHeader(relPath)); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if err := g.writeBinaryMetadata(g.outputWriter, filePath); err != nil {
+		return err
+	}
+	if description != "" {
+		if _, err := fmt.Fprintf(g.outputWriter, "Description (written by %s): %s\n", g.imageDescriber.Model(), description); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	if _, err := fmt.Fprint(g.outputWriter, "\n\n"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// describeImage returns a one-line description of the image at filePath.
+func (g *Git2LLM) describeImage(filePath string) (string, error) {
+	info, err := g.fs.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > imageDescribeMaxSize {
+		return "", fmt.Errorf("image of %d bytes is larger than the limit of %d bytes", info.Size(), imageDescribeMaxSize)
+	}
+	data, err := g.fs.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), imageDescribeTimeout)
+	defer cancel()
+	var answer bytes.Buffer
+	image := llm.Image{MIMEType: http.DetectContentType(data), Data: data}
+	if err := g.imageDescriber.StreamImage(ctx, imageDescribePrompt, image, &answer); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(answer.String()), " "), nil
+}
+
+// imageDescribePrompt asks for a one-line description of an image from a repository.
+const imageDescribePrompt = "This image is part of a software repository. Describe in one sentence what it shows, " +
+	"such as the components of a diagram or the screen of a user interface, naming any legible labels. " +
+	"Answer with the sentence only."
+
+// svgText is a Transformer replacing SVG images by their dimensions and the
+// text of their title, desc and text elements. It only applies to SVG files,
+// and files that fail to parse are kept as they are.
+type svgText struct{}
+
+func (svgText) appliesTo(relPath string) bool {
+	return strings.EqualFold(path.Ext(relPath), ".svg")
+}
+
+func (s svgText) Transform(relPath string, content []byte) ([]byte, bool, error) {
+	if !s.appliesTo(relPath) {
+		return content, true, nil
+	}
+	var size, title, desc string
+	var texts []string
+	var stack []string
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return content, true, nil
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if t.Name.Local == "svg" && size == "" {
+				size = svgSize(t.Attr)
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			text := strings.Join(strings.Fields(string(t)), " ")
+			if text == "" || len(stack) == 0 {
+				continue
+			}
+			switch stack[len(stack)-1] {
+			case "title":
+				title = strings.TrimSpace(title + " " + text)
+			case "desc":
+				desc = strings.TrimSpace(desc + " " + text)
+			case "text", "tspan", "textPath":
+				texts = append(texts, text)
+			}
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[SVG image%s; the markup is not included]\n", size)
+	if title != "" {
+		fmt.Fprintf(&out, "Title: %s\n", title)
+	}
+	if desc != "" {
+		fmt.Fprintf(&out, "Description: %s\n", desc)
+	}
+	if len(texts) > 0 {
+		fmt.Fprintf(&out, "Text: %s\n", strings.Join(texts, " | "))
+	}
+	return out.Bytes(), true, nil
+}
+
+// svgSize returns the dimensions given by the attributes of an svg element,
+// e.g. ", 120x40", or "" if there are none.
+func svgSize(attrs []xml.Attr) string {
+	var width, height, viewBox string
+	for _, a := range attrs {
+		switch a.Name.Local {
+		case "width":
+			width = a.Value
+		case "height":
+			height = a.Value
+		case "viewBox":
+			viewBox = a.Value
+		}
+	}
+	switch {
+	case width != "" && height != "":
+		return ", " + width + "x" + height
+	case viewBox != "":
+		return ", viewBox " + viewBox
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perbu/git2llm/llm"
+)
+
+// fakeDescriber answers every image with a fixed description, or fails.
+type fakeDescriber struct {
+	answer string
+	err    error
+	mime   string
+}
+
+func (f *fakeDescriber) StreamImage(_ context.Context, _ string, image llm.Image, w io.Writer) error {
+	f.mime = image.MIMEType
+	if f.err != nil {
+		return f.err
+	}
+	_, err := io.WriteString(w, f.answer)
+	return err
+}
+
+func (f *fakeDescriber) Model() string {
+	return "vision-test"
+}
+
+func TestScanImages(t *testing.T) {
+	tempDir := t.TempDir()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	testFiles := map[string][]byte{
+		"docs/arch.png": img.Bytes(),
+		"docs/flow.svg": []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="120" height="40"><title>Flow</title><rect/><text x="1">API</text><text><tspan>Queue</tspan></text></svg>`),
+		"blob.bin":      {0, 1, 2},
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	describer := &fakeDescriber{answer: "A diagram of\nthe API.\n"}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithImages(true, describer))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{
+		"File: docs/arch.png (Image - described)\n",
+		"Image: png, 4x3\n",
+		"Description (written by vision-test): A diagram of the API.\n",
+		"Content of docs/flow.svg:\n[SVG image, 120x40; the markup is not included]\nTitle: Flow\nText: API | Queue\n",
+		"File: blob.bin (Binary - skipped content)\n",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, result)
+		}
+	}
+	if describer.mime != "image/png" {
+		t.Errorf("Expected the image to be sent as image/png, got %q", describer.mime)
+	}
+
+	// Without a description the metadata is still included
+	output.Reset()
+	git2llm, err = NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithImages(true, &fakeDescriber{err: errors.New("unavailable")}))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "File: docs/arch.png (Image - metadata only)\n") || strings.Contains(output.String(), "Description") {
+		t.Errorf("Expected only the metadata of the image. Result:\n%s", output.String())
+	}
+}
+
+func TestSVGTextInvalid(t *testing.T) {
+	content := []byte("<svg><text>unclosed")
+	got, keep, err := svgText{}.Transform("a.svg", content)
+	if err != nil || !keep || !bytes.Equal(got, content) {
+		t.Errorf("Expected an invalid SVG to be kept as it is, got %q, %v, %v", got, keep, err)
+	}
+}
+
+func TestSVGTextScope(t *testing.T) {
+	git2llm := &Git2LLM{}
+	WithImages(true, nil)(git2llm)
+	if got := git2llm.transformersFor("main.go"); len(got) != 0 {
+		t.Errorf("Expected other files to stream without transformers, got %v", got)
+	}
+	if got := git2llm.transformersFor("docs/Flow.SVG"); len(got) != 1 {
+		t.Errorf("Expected svgText to apply to SVG files, got %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// exitInterrupted is the exit status when the scan was stopped by SIGINT or
+// SIGTERM, as a shell reports a process killed by SIGINT.
+const exitInterrupted = 130
+
+// ErrInterrupted is returned by a scan stopped with the channel of
+// WithInterrupt. The output is complete up to the marker ending it.
+var ErrInterrupted = errors.New("the scan was interrupted")
+
+// WithInterrupt stops the scan once stop is closed, e.g. on SIGINT: the file
+// being written is finished, the remaining files are left out and recorded as
+// skipped, and the output ends with a marker instead of in the middle of a
+// file. The skip summary, the token count and the other formats still cover
+// the files written so far.
+func WithInterrupt(stop <-chan struct{}) Option {
+	return func(g *Git2LLM) {
+		g.stop = stop
+	}
+}
+
+// isInterrupted reports whether the channel of WithInterrupt is closed.
+func (g *Git2LLM) isInterrupted() bool {
+	select {
+	case <-g.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeInterrupted ends the output of an interrupted scan of roots with a
+// marker telling how many files were left out.
+func writeInterrupted(w io.Writer, roots []*Git2LLM) error {
+	omitted := 0
+	for _, g := range roots {
+		omitted += g.interrupted
+	}
+	if _, err := fmt.Fprintf(w, "Output truncated: the scan was interrupted, %d more files were left out.\n", omitted); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGit2LLMInterrupt(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		"a.go": {Data: []byte("package a\n")},
+		"b.go": {Data: []byte("package b\n")},
+		"c.go": {Data: []byte("package c\n")},
+	}}
+	stop := make(chan struct{})
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", []string{".go"}, fsys, &output, false, false, false, nil, "", false,
+		WithInterrupt(stop), WithCheckpoint(func(path string) {
+			if path == "a.go" {
+				close(stop) // Interrupted while a.go is written
+			}
+		}))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Expected ErrInterrupted, got %v", err)
+	}
+	out := output.String()
+	if !strings.Contains(out, "File: a.go\n") || strings.Contains(out, "File: b.go") {
+		t.Errorf("Expected only a.go to be written, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "package a\n\n\nOutput truncated: the scan was interrupted, 2 more files were left out.\n") {
+		t.Errorf("Expected the output to end with the marker after a.go, got:\n%s", out)
+	}
+	if len(result.Files) != 1 || len(result.Skipped) != 2 || result.Skipped[0].Reason != SkipInterrupted {
+		t.Errorf("Expected 1 file and 2 interrupted, got %+v and %+v", result.Files, result.Skipped)
+	}
+	if summary := summarize([]*Git2LLM{git2llm}, 0); !summary.Interrupted || summary.Files != 1 {
+		t.Errorf("Expected an interrupted summary with 1 file, got %+v", summary)
+	}
+}
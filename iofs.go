@@ -0,0 +1,59 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IOFS adapts an io/fs file system, such as an embed.FS, a zip.Reader or an
+// fstest.MapFS, to FS. It is scanned with the start path ".". As io/fs has no
+// Lstat, Lstat returns what Stat does: a zip.Reader reports symlinks, other
+// file systems may follow them.
+type IOFS struct {
+	FS fs.FS
+}
+
+// name returns the io/fs name of a path built with filepath.Join from ".".
+func (IOFS) name(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func (f IOFS) Open(name string) (File, error) {
+	return f.FS.Open(f.name(name))
+}
+
+func (f IOFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return fs.ReadDir(f.FS, f.name(name))
+}
+
+func (f IOFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.FS, f.name(name))
+}
+
+func (f IOFS) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.FS, f.name(name))
+}
+
+func (f IOFS) Lstat(name string) (os.FileInfo, error) {
+	return f.Stat(name)
+}
+
+// isZipArchive reports whether startPath is a zip file to scan instead of a directory.
+func isZipArchive(startPath string) bool {
+	info, err := os.Stat(startPath)
+	return err == nil && info.Mode().IsRegular() && strings.EqualFold(filepath.Ext(startPath), ".zip")
+}
+
+// newZipFS opens the zip archive at path. It stays open until the program exits.
+func newZipFS(path string) (IOFS, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return IOFS{}, fmt.Errorf("error opening zip archive: %w", err)
+	}
+	return IOFS{FS: r}, nil
+}
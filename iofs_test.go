@@ -0,0 +1,111 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanIOFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":         {Data: []byte("package main\n")},
+		"pkg/util.go":     {Data: []byte("package pkg\n")},
+		"pkg/image.png":   {Data: []byte("\x89PNG\x00\x00")},
+		".git/config":     {Data: []byte("[core]\n")},
+		"docs/readme.txt": {Data: []byte("docs\n")},
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", []string{".go"}, IOFS{FS: fsys}, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{"├── pkg/\n│   └── util.go\n└── main.go\n", "Content of main.go:\npackage main\n", "Content of pkg/util.go:\npackage pkg\n"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, ".git") || strings.Contains(result, "image.png") {
+		t.Errorf("Expected .git and the image to be left out. Result:\n%s", result)
+	}
+}
+
+func TestScanZipArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "src.zip")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	zw := zip.NewWriter(file)
+	for name, content := range map[string]string{"app/main.go": "package main\n", "README.md": "# App\n"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	file.Close()
+
+	if !isZipArchive(archive) || isZipArchive(filepath.Dir(archive)) {
+		t.Fatalf("Expected only the file to be a zip archive")
+	}
+	zipFS, err := newZipFS(archive)
+	if err != nil {
+		t.Fatalf("newZipFS failed: %v", err)
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, zipFS, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	for _, expected := range []string{"Content of README.md:\n# App\n", "Content of app/main.go:\npackage main\n"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, output.String())
+		}
+	}
+}
+
+// TestScanIOFSAllPasses checks that the passes reading more than the listed
+// files go through the injected FS as well: the overview, the dependencies,
+// shebang detection and the table of contents.
+func TestScanIOFSAllPasses(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":          {Data: []byte("module example.com/app\n\ngo 1.23\n\nrequire github.com/pkg/errors v0.9.1\n")},
+		"main.go":         {Data: []byte("package main\n")},
+		"main_test.go":    {Data: []byte("package main\n")},
+		"scripts/release": {Data: []byte("#!/bin/sh\necho release\n"), Mode: 0755},
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", []string{".go", ".sh"}, IOFS{FS: fsys}, &output, false, false, false, nil, "", false,
+		WithOverview(true), WithDependencies(true), WithTableOfContents(true), WithOrder(OrderGrouped))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{
+		"Go module example.com/app\n",
+		"go.mod:\n  github.com/pkg/errors v0.9.1\n",
+		"│   └── release\n",
+		"main.go (line 32)\n",
+		"File: scripts/release (executable sh script)\n",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, result)
+		}
+	}
+}
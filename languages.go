@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// languageExtensions map file extensions, in lower case, to the language used
+// as the syntax hint of Markdown fences and in the token breakdown. The names
+// are those understood by common Markdown highlighters. Extensions with
+// several dots, such as .gradle.kts, take precedence over the last one.
+var languageExtensions = map[string]string{
+	".go":           "go",
+	".mod":          "go-mod",
+	".c":            "c",
+	".h":            "c",
+	".cc":           "cpp",
+	".cpp":          "cpp",
+	".cxx":          "cpp",
+	".hh":           "cpp",
+	".hpp":          "cpp",
+	".hxx":          "cpp",
+	".ino":          "cpp",
+	".cs":           "csharp",
+	".csx":          "csharp",
+	".fs":           "fsharp",
+	".fsx":          "fsharp",
+	".vb":           "vbnet",
+	".java":         "java",
+	".kt":           "kotlin",
+	".kts":          "kotlin",
+	".gradle":       "groovy",
+	".gradle.kts":   "kotlin",
+	".groovy":       "groovy",
+	".scala":        "scala",
+	".sc":           "scala",
+	".sbt":          "scala",
+	".clj":          "clojure",
+	".cljs":         "clojure",
+	".cljc":         "clojure",
+	".edn":          "clojure",
+	".js":           "javascript",
+	".mjs":          "javascript",
+	".cjs":          "javascript",
+	".jsx":          "jsx",
+	".ts":           "typescript",
+	".mts":          "typescript",
+	".cts":          "typescript",
+	".d.ts":         "typescript",
+	".tsx":          "tsx",
+	".vue":          "vue",
+	".svelte":       "svelte",
+	".astro":        "astro",
+	".html":         "html",
+	".htm":          "html",
+	".xhtml":        "html",
+	".css":          "css",
+	".scss":         "scss",
+	".sass":         "sass",
+	".less":         "less",
+	".styl":         "stylus",
+	".py":           "python",
+	".pyi":          "python",
+	".pyw":          "python",
+	".pyx":          "cython",
+	".ipynb":        "json",
+	".rb":           "ruby",
+	".rake":         "ruby",
+	".gemspec":      "ruby",
+	".erb":          "erb",
+	".php":          "php",
+	".blade.php":    "blade",
+	".pl":           "perl",
+	".pm":           "perl",
+	".t":            "perl",
+	".lua":          "lua",
+	".r":            "r",
+	".rmd":          "rmarkdown",
+	".jl":           "julia",
+	".m":            "objectivec",
+	".mm":           "objectivec",
+	".swift":        "swift",
+	".rs":           "rust",
+	".zig":          "zig",
+	".nim":          "nim",
+	".d":            "d",
+	".dart":         "dart",
+	".ex":           "elixir",
+	".exs":          "elixir",
+	".heex":         "heex",
+	".erl":          "erlang",
+	".hrl":          "erlang",
+	".hs":           "haskell",
+	".lhs":          "haskell",
+	".elm":          "elm",
+	".ml":           "ocaml",
+	".mli":          "ocaml",
+	".re":           "reason",
+	".purs":         "purescript",
+	".lisp":         "lisp",
+	".el":           "elisp",
+	".scm":          "scheme",
+	".rkt":          "racket",
+	".sol":          "solidity",
+	".v":            "verilog",
+	".sv":           "systemverilog",
+	".vhd":          "vhdl",
+	".vhdl":         "vhdl",
+	".asm":          "asm",
+	".s":            "asm",
+	".wat":          "wasm",
+	".cob":          "cobol",
+	".f90":          "fortran",
+	".f":            "fortran",
+	".pas":          "pascal",
+	".ada":          "ada",
+	".tcl":          "tcl",
+	".awk":          "awk",
+	".sed":          "sed",
+	".sh":           "bash",
+	".bash":         "bash",
+	".zsh":          "zsh",
+	".ksh":          "bash",
+	".fish":         "fish",
+	".ps1":          "powershell",
+	".psm1":         "powershell",
+	".psd1":         "powershell",
+	".bat":          "batch",
+	".cmd":          "batch",
+	".sql":          "sql",
+	".psql":         "sql",
+	".prisma":       "prisma",
+	".graphql":      "graphql",
+	".gql":          "graphql",
+	".proto":        "protobuf",
+	".thrift":       "thrift",
+	".avsc":         "json",
+	".json":         "json",
+	".jsonc":        "jsonc",
+	".json5":        "json5",
+	".jsonl":        "json",
+	".ndjson":       "json",
+	".geojson":      "json",
+	".yaml":         "yaml",
+	".yml":          "yaml",
+	".toml":         "toml",
+	".ini":          "ini",
+	".cfg":          "ini",
+	".conf":         "ini",
+	".properties":   "properties",
+	".env":          "dotenv",
+	".xml":          "xml",
+	".xsd":          "xml",
+	".xsl":          "xml",
+	".xslt":         "xml",
+	".plist":        "xml",
+	".csproj":       "xml",
+	".fsproj":       "xml",
+	".vbproj":       "xml",
+	".props":        "xml",
+	".targets":      "xml",
+	".svg":          "xml",
+	".tf":           "hcl",
+	".tfvars":       "hcl",
+	".hcl":          "hcl",
+	".nomad":        "hcl",
+	".bicep":        "bicep",
+	".nix":          "nix",
+	".dhall":        "dhall",
+	".cue":          "cue",
+	".jsonnet":      "jsonnet",
+	".libsonnet":    "jsonnet",
+	".rego":         "rego",
+	".bzl":          "starlark",
+	".bazel":        "starlark",
+	".star":         "starlark",
+	".cmake":        "cmake",
+	".mk":           "makefile",
+	".mak":          "makefile",
+	".dockerfile":   "dockerfile",
+	".md":           "markdown",
+	".markdown":     "markdown",
+	".mdx":          "mdx",
+	".rst":          "rst",
+	".adoc":         "asciidoc",
+	".asciidoc":     "asciidoc",
+	".org":          "org",
+	".tex":          "latex",
+	".sty":          "latex",
+	".bib":          "bibtex",
+	".txt":          "text",
+	".csv":          "csv",
+	".tsv":          "tsv",
+	".diff":         "diff",
+	".patch":        "diff",
+	".tmpl":         "gotemplate",
+	".gotmpl":       "gotemplate",
+	".hbs":          "handlebars",
+	".handlebars":   "handlebars",
+	".mustache":     "mustache",
+	".j2":           "jinja",
+	".jinja":        "jinja",
+	".jinja2":       "jinja",
+	".twig":         "twig",
+	".liquid":       "liquid",
+	".pug":          "pug",
+	".haml":         "haml",
+	".slim":         "slim",
+	".glsl":         "glsl",
+	".hlsl":         "hlsl",
+	".wgsl":         "wgsl",
+	".cu":           "cuda",
+	".vim":          "vim",
+	".http":         "http",
+	".gitignore":    "gitignore",
+	".dockerignore": "gitignore",
+	".editorconfig": "editorconfig",
+}
+
+// languageNames map file names, in lower case, to their language.
+var languageNames = map[string]string{
+	"dockerfile":     "dockerfile",
+	"containerfile":  "dockerfile",
+	"makefile":       "makefile",
+	"gnumakefile":    "makefile",
+	"bsdmakefile":    "makefile",
+	"justfile":       "just",
+	"cmakelists.txt": "cmake",
+	"jenkinsfile":    "groovy",
+	"vagrantfile":    "ruby",
+	"gemfile":        "ruby",
+	"rakefile":       "ruby",
+	"podfile":        "ruby",
+	"brewfile":       "ruby",
+	"fastfile":       "ruby",
+	"guardfile":      "ruby",
+	"build":          "starlark",
+	"workspace":      "starlark",
+	"tiltfile":       "starlark",
+	"go.sum":         "text",
+	"go.work":        "go-mod",
+	"cargo.lock":     "toml",
+	"pipfile":        "toml",
+	"caddyfile":      "caddyfile",
+	"nginx.conf":     "nginx",
+	".bashrc":        "bash",
+	".zshrc":         "zsh",
+	".profile":       "bash",
+	".npmrc":         "ini",
+	".gitconfig":     "ini",
+	".gitattributes": "gitattributes",
+	"license":        "text",
+	"copying":        "text",
+}
+
+// WithLanguages overrides the languages of files, by extension such as ".tpl"
+// or by file name such as "Tiltfile", e.g. from --lang .tpl=gotemplate.
+func WithLanguages(languages map[string]string) Option {
+	return func(g *Git2LLM) {
+		g.languages = make(map[string]string, len(languages))
+		for key, language := range languages {
+			g.languages[strings.ToLower(key)] = language
+		}
+	}
+}
+
+// language returns the language of the file at relPath, or "" if it is unknown.
+func (g *Git2LLM) language(relPath string) string {
+	return languageOf(relPath, g.languages)
+}
+
+// languageOf returns the language of the file at relPath, or "" if it is
+// unknown. The overrides, as set by WithLanguages, come first, then the file
+// name, then the longest known extension, e.g. .gradle.kts before .kts.
+// Dockerfile.dev and the like are Dockerfiles, .env.local and the like dotenv
+// files.
+func languageOf(relPath string, overrides map[string]string) string {
+	name := strings.ToLower(path.Base(relPath))
+	for _, m := range []map[string]string{overrides, languageNames} {
+		if language, ok := m[name]; ok {
+			return language
+		}
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] != '.' {
+			continue
+		}
+		for _, m := range []map[string]string{overrides, languageExtensions} {
+			if language, ok := m[name[i:]]; ok {
+				return language
+			}
+		}
+	}
+	base, _, _ := strings.Cut(name, ".")
+	switch {
+	case strings.HasPrefix(name, ".env"):
+		return "dotenv"
+	case base == "dockerfile" || base == "containerfile":
+		return "dockerfile"
+	}
+	return ""
+}
+
+// typeOf returns the type of the file at relPath for the token breakdown: its
+// language, or else its extension or name as returned by fileType.
+func (g *Git2LLM) typeOf(relPath string) string {
+	if language := g.language(relPath); language != "" {
+		return language
+	}
+	return fileType(relPath)
+}
+
+// parseLanguages parses the values of --lang: comma separated KEY=LANGUAGE
+// pairs, with KEY an extension starting with a dot or a file name. The keys
+// are returned in lower case.
+func parseLanguages(values []string) (map[string]string, error) {
+	languages := make(map[string]string)
+	for _, value := range values {
+		for _, spec := range strings.Split(value, ",") {
+			key, language, ok := strings.Cut(strings.TrimSpace(spec), "=")
+			if !ok || key == "" || language == "" || strings.Contains(key, "/") {
+				return nil, fmt.Errorf("invalid --lang %q: expected .EXT=LANGUAGE or NAME=LANGUAGE", spec)
+			}
+			languages[strings.ToLower(key)] = language
+		}
+	}
+	return languages, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	g := &Git2LLM{}
+	WithLanguages(map[string]string{".tpl": "gotemplate", "Tiltfile": "python", ".kts": "kts"})(g)
+	testCases := []struct {
+		path   string
+		expect string
+	}{
+		{"main.go", "go"},
+		{"web/App.TSX", "tsx"},
+		{"types/index.d.ts", "typescript"},
+		{"build.gradle.kts", "kotlin"},
+		{"settings.kts", "kts"},
+		{"Dockerfile", "dockerfile"},
+		{"deploy/Dockerfile.dev", "dockerfile"},
+		{"Makefile", "makefile"},
+		{"CMakeLists.txt", "cmake"},
+		{".env.local", "dotenv"},
+		{"charts/values.tpl", "gotemplate"},
+		{"Tiltfile", "python"},
+		{"notes", ""},
+		{"data.xyz", ""},
+	}
+	for _, tc := range testCases {
+		if got := g.language(tc.path); got != tc.expect {
+			t.Errorf("For %s, expected %q, got %q", tc.path, tc.expect, got)
+		}
+	}
+	if got := g.typeOf("data.xyz"); got != ".xyz" {
+		t.Errorf("Expected the extension for an unknown language, got %q", got)
+	}
+}
+
+func TestParseLanguages(t *testing.T) {
+	got, err := parseLanguages([]string{".tpl=gotemplate, Tiltfile=python", ".x=y"})
+	if err != nil {
+		t.Fatalf("parseLanguages: %v", err)
+	}
+	expected := map[string]string{".tpl": "gotemplate", "tiltfile": "python", ".x": "y"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	for _, value := range []string{".tpl", "=go", ".tpl=", "dir/file=go"} {
+		if _, err := parseLanguages([]string{value}); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithMaxLineLength truncates lines longer than n characters, ending them with
+// a marker giving the number of characters left out. It is meant for embedded
+// base64 data and minified code, which take many tokens and tell little.
+// 0 disables it.
+func WithMaxLineLength(n int) Option {
+	return func(g *Git2LLM) {
+		g.maxLineLength = n
+	}
+}
+
+// lineTruncator is an io.Writer passing text on to w with lines cut after max
+// characters. Flush must be called after the last write.
+type lineTruncator struct {
+	w         io.Writer
+	max       int
+	column    int // Characters in the current line
+	dropped   int // Characters left out of the current line
+	truncated int // Lines truncated
+	out       []byte
+}
+
+func newLineTruncator(w io.Writer, max int) *lineTruncator {
+	return &lineTruncator{w: w, max: max}
+}
+
+func (t *lineTruncator) Write(p []byte) (int, error) {
+	t.out = t.out[:0]
+	for _, b := range p {
+		if b == '\n' {
+			t.endLine()
+			t.out = append(t.out, b)
+			continue
+		}
+		if b&0xc0 != 0x80 { // Continuation bytes are part of the previous character
+			t.column++
+		}
+		if t.column > t.max {
+			if b&0xc0 != 0x80 {
+				t.dropped++
+			}
+			continue
+		}
+		t.out = append(t.out, b)
+	}
+	if _, err := t.w.Write(t.out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// endLine appends the marker if the current line was truncated.
+func (t *lineTruncator) endLine() {
+	if t.dropped > 0 {
+		t.out = fmt.Appendf(t.out, "… [%d characters truncated]", t.dropped)
+		t.truncated++
+	}
+	t.column, t.dropped = 0, 0
+}
+
+// Flush ends a truncated last line without a newline and resets the state for the next file.
+func (t *lineTruncator) Flush() error {
+	t.out = t.out[:0]
+	t.endLine()
+	if len(t.out) == 0 {
+		return nil
+	}
+	_, err := t.w.Write(t.out)
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineTruncator(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  []string // Written one by one
+		expect string
+	}{
+		{"short lines", []string{"abc\nde\n"}, "abc\nde\n"},
+		{"long line", []string{"abcdefgh\nxy\n"}, "abcde… [3 characters truncated]\nxy\n"},
+		{"split writes", []string{"abc", "defg", "h\n"}, "abcde… [3 characters truncated]\n"},
+		{"no final newline", []string{"abcdefgh"}, "abcde… [3 characters truncated]"},
+		{"multibyte characters", []string{"äöüßéèà\n"}, "äöüßé… [2 characters truncated]\n"},
+		{"exact length", []string{"abcde\n"}, "abcde\n"},
+	}
+	for _, tc := range testCases {
+		var out strings.Builder
+		truncate := newLineTruncator(&out, 5)
+		for _, s := range tc.input {
+			if _, err := truncate.Write([]byte(s)); err != nil {
+				t.Fatalf("%s: Write failed: %v", tc.name, err)
+			}
+		}
+		if err := truncate.Flush(); err != nil {
+			t.Fatalf("%s: Flush failed: %v", tc.name, err)
+		}
+		if out.String() != tc.expect {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expect, out.String())
+		}
+	}
+}
+
+func TestGit2LLMMaxLineLength(t *testing.T) {
+	tempDir := t.TempDir()
+	blob := strings.Repeat("QUJD", 1000)
+	if err := os.WriteFile(filepath.Join(tempDir, "data.js"), []byte("const a = 1;\nconst img = \""+blob+"\";\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithMaxLineLength(20))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "const a = 1;\nconst img = \"QUJDQUJ… [3995 characters truncated]\n") {
+		t.Errorf("Expected the long line to be truncated. Result:\n%s", output.String())
+	}
+}
@@ -0,0 +1,265 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Client sends a prompt to an LLM provider and streams the answer.
+type Client struct {
+	provider string
+	model    string
+	apiKey   string
+	baseURL  string
+	http     *http.Client
+}
+
+type providerInfo struct {
+	baseURL      string
+	apiKeyEnv    string
+	defaultModel string
+	smallModel   string // fast and cheap model for bulk work such as summaries
+}
+
+var providers = map[string]providerInfo{
+	"openai":    {"https://api.openai.com", "OPENAI_API_KEY", "gpt-4o", "gpt-4o-mini"},
+	"anthropic": {"https://api.anthropic.com", "ANTHROPIC_API_KEY", "claude-sonnet-4-20250514", "claude-3-5-haiku-latest"},
+	"gemini":    {"https://generativelanguage.googleapis.com", "GEMINI_API_KEY", "gemini-2.0-flash", "gemini-2.0-flash-lite"},
+}
+
+// maxTokens is the answer length limit sent to providers that require one.
+const maxTokens = 8192
+
+// New returns a client for provider ("openai", "anthropic" or "gemini"). If model
+// is empty the provider's default is used. The API key is read from the provider's
+// environment variable (OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY).
+func New(provider, model string) (*Client, error) {
+	info, ok := providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (expected openai, anthropic or gemini)", provider)
+	}
+	apiKey := os.Getenv(info.apiKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s is not set", info.apiKeyEnv)
+	}
+	if model == "" {
+		model = info.defaultModel
+	}
+	return &Client{
+		provider: provider,
+		model:    model,
+		apiKey:   apiKey,
+		baseURL:  info.baseURL,
+		http:     http.DefaultClient,
+	}, nil
+}
+
+// SmallModel returns the fast and cheap model of provider, or "" for unknown providers.
+func SmallModel(provider string) string {
+	return providers[provider].smallModel
+}
+
+// Model returns the model the client talks to.
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Image is an image sent along with a prompt.
+type Image struct {
+	MIMEType string // e.g. image/png
+	Data     []byte
+}
+
+// Stream sends prompt as a single user message and writes the answer to w as it arrives.
+func (c *Client) Stream(ctx context.Context, prompt string, w io.Writer) error {
+	return c.stream(ctx, prompt, nil, w)
+}
+
+// StreamImage works like Stream, sending image along with the prompt. The
+// model must accept images.
+func (c *Client) StreamImage(ctx context.Context, prompt string, image Image, w io.Writer) error {
+	return c.stream(ctx, prompt, &image, w)
+}
+
+func (c *Client) stream(ctx context.Context, prompt string, image *Image, w io.Writer) error {
+	req, err := c.newRequest(ctx, prompt, image)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("http.Do: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %s: %s", c.provider, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return readEvents(resp.Body, func(data []byte) (bool, error) {
+		text, done, err := c.parseEvent(data)
+		if err != nil {
+			return false, err
+		}
+		if text != "" {
+			if _, err := io.WriteString(w, text); err != nil {
+				return false, fmt.Errorf("error writing answer: %w", err)
+			}
+		}
+		return done, nil
+	})
+}
+
+func (c *Client) newRequest(ctx context.Context, prompt string, image *Image) (*http.Request, error) {
+	var url string
+	var body any
+	header := http.Header{"Content-Type": {"application/json"}}
+	switch c.provider {
+	case "openai":
+		url = c.baseURL + "/v1/chat/completions"
+		header.Set("Authorization", "Bearer "+c.apiKey)
+		var content any = prompt
+		if image != nil {
+			content = []map[string]any{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]string{"url": "data:" + image.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)}},
+			}
+		}
+		body = map[string]any{
+			"model":    c.model,
+			"stream":   true,
+			"messages": []map[string]any{{"role": "user", "content": content}},
+		}
+	case "anthropic":
+		url = c.baseURL + "/v1/messages"
+		header.Set("x-api-key", c.apiKey)
+		header.Set("anthropic-version", "2023-06-01")
+		var content any = prompt
+		if image != nil {
+			content = []map[string]any{
+				{"type": "image", "source": map[string]string{"type": "base64", "media_type": image.MIMEType, "data": base64.StdEncoding.EncodeToString(image.Data)}},
+				{"type": "text", "text": prompt},
+			}
+		}
+		body = map[string]any{
+			"model":      c.model,
+			"stream":     true,
+			"max_tokens": maxTokens,
+			"messages":   []map[string]any{{"role": "user", "content": content}},
+		}
+	case "gemini":
+		url = c.baseURL + "/v1beta/models/" + c.model + ":streamGenerateContent?alt=sse"
+		header.Set("x-goog-api-key", c.apiKey)
+		parts := []map[string]any{{"text": prompt}}
+		if image != nil {
+			parts = append([]map[string]any{{"inline_data": map[string]string{"mime_type": image.MIMEType, "data": base64.StdEncoding.EncodeToString(image.Data)}}}, parts...)
+		}
+		body = map[string]any{
+			"contents": []map[string]any{{"role": "user", "parts": parts}},
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest: %w", err)
+	}
+	req.Header = header
+	return req, nil
+}
+
+// parseEvent extracts the answer text from one server-sent event and reports whether the stream is complete.
+func (c *Client) parseEvent(data []byte) (string, bool, error) {
+	switch c.provider {
+	case "openai":
+		if string(data) == "[DONE]" {
+			return "", true, nil
+		}
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return "", false, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		if len(event.Choices) == 0 {
+			return "", false, nil
+		}
+		return event.Choices[0].Delta.Content, false, nil
+	case "anthropic":
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Text string `json:"text"`
+			} `json:"delta"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return "", false, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			return event.Delta.Text, false, nil
+		case "message_stop":
+			return "", true, nil
+		case "error":
+			return "", false, fmt.Errorf("anthropic: %s", event.Error.Message)
+		}
+		return "", false, nil
+	default: // gemini
+		var event struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"candidates"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return "", false, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+		var text strings.Builder
+		for _, candidate := range event.Candidates {
+			for _, part := range candidate.Content.Parts {
+				text.WriteString(part.Text)
+			}
+		}
+		return text.String(), false, nil
+	}
+}
+
+// readEvents calls fn with the data of every server-sent event in r until fn reports completion or r ends.
+func readEvents(r io.Reader, fn func(data []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue // Event names, comments and blank separators
+		}
+		done, err := fn(bytes.TrimSpace(data))
+		if err != nil || done {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stream: %w", err)
+	}
+	return nil
+}
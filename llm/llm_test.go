@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	testCases := []struct {
+		provider string
+		path     string
+		events   string
+	}{
+		{
+			provider: "openai",
+			path:     "/v1/chat/completions",
+			events: "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\", world\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+		},
+		{
+			provider: "anthropic",
+			path:     "/v1/messages",
+			events: "event: message_start\ndata: {\"type\":\"message_start\"}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\", world\"}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+		},
+		{
+			provider: "gemini",
+			path:     "/v1beta/models/gemini-2.0-flash:streamGenerateContent",
+			events: "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hello\"}]}}]}\n\n" +
+				"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\", world\"}]}}]}\n\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.path {
+					t.Errorf("Expected path %s, got %s", tc.path, r.URL.Path)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if !strings.Contains(string(body), "what does main do?") {
+					t.Errorf("Expected prompt in request body, got %s", body)
+				}
+				if !json.Valid(body) {
+					t.Errorf("Expected a JSON request body, got %s", body)
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				io.WriteString(w, tc.events)
+			}))
+			defer server.Close()
+
+			t.Setenv(providers[tc.provider].apiKeyEnv, "test-key")
+			client, err := New(tc.provider, "")
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			client.baseURL = server.URL
+
+			var answer strings.Builder
+			if err := client.Stream(context.Background(), "what does main do?", &answer); err != nil {
+				t.Fatalf("Stream failed: %v", err)
+			}
+			if answer.String() != "Hello, world" {
+				t.Errorf("Expected 'Hello, world', got %q", answer.String())
+			}
+		})
+	}
+}
+
+func TestStreamImage(t *testing.T) {
+	image := Image{MIMEType: "image/png", Data: []byte("\x89PNG")}
+	encoded := base64.StdEncoding.EncodeToString(image.Data)
+	for provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				for _, expected := range []string{"describe it", encoded, "image/png"} {
+					if !strings.Contains(string(body), expected) {
+						t.Errorf("Expected %q in the request body, got %s", expected, body)
+					}
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+			}))
+			defer server.Close()
+
+			t.Setenv(providers[provider].apiKeyEnv, "test-key")
+			client, err := New(provider, "")
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			client.baseURL = server.URL
+			if err := client.StreamImage(context.Background(), "describe it", image, io.Discard); err != nil {
+				t.Fatalf("StreamImage failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "bad-key")
+	client, err := New("openai", "gpt-4o-mini")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	err = client.Stream(context.Background(), "hi", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("Expected error with response body, got %v", err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New("mystery", ""); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := New("anthropic", ""); err == nil {
+		t.Error("Expected an error when the API key is missing")
+	}
+}
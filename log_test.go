@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextHandler(t *testing.T) {
+	var output strings.Builder
+	logger := newLogger(&output, slog.LevelInfo, false)
+	logger.Debug("hidden")
+	logger.Info("Total tokens", "tokens", 42)
+	logger.With("path", "a b.go").Warn("Odd file")
+	logger.WithGroup("cache").Error("Failed", "error", "disk full")
+
+	expected := "Total tokens tokens=42\n" +
+		"Warning: Odd file path=\"a b.go\"\n" +
+		"Error: Failed cache.error=\"disk full\"\n"
+	if output.String() != expected {
+		t.Errorf("Unexpected log output:\n%s\nexpected:\n%s", output.String(), expected)
+	}
+}
+
+func TestGit2LLMWithLogger(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "logo.png"), []byte("\x89PNG\x00\x00"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var logs strings.Builder
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false,
+		WithLogger(newLogger(&logs, slog.LevelDebug, true)))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record struct {
+			Msg  string `json:"msg"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Log line is not JSON: %q", line)
+		}
+		messages = append(messages, record.Msg+" "+record.Path)
+	}
+	joined := strings.Join(messages, "\n")
+	for _, expected := range []string{"Processed file main.go", "Skipped 1 files (1 binary) ", "Skipped file logo.png"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Expected log message %q, got:\n%s", expected, joined)
+		}
+	}
+}
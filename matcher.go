@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// patternMatcher matches a path against a set of exclusion patterns, with the
+// same result as calling matchPattern for each of them. The patterns are
+// sorted by kind once, so most of them are found by map lookups of the path
+// and its elements instead of being tried one by one.
+type patternMatcher struct {
+	prefixes     map[string]bool     // "/x", "x/" and "/x/": the path x and everything below it
+	names        map[string]bool     // Literal patterns: the whole path or any of its elements
+	suffixes     map[string][]string // "*.pb.go" by extension (".go"): elements ending in ".pb.go"
+	elementGlobs []string            // Other globs without a slash, tried on every element
+	pathGlobs    []string            // Globs with a slash or a class, which may match one, tried on the whole path
+}
+
+// compilePatterns sorts patterns by kind. Invalid globs are left out, as they
+// never match.
+func compilePatterns(patterns map[string]bool) *patternMatcher {
+	m := &patternMatcher{
+		prefixes: make(map[string]bool),
+		names:    make(map[string]bool),
+		suffixes: make(map[string][]string),
+	}
+	for pattern := range patterns {
+		switch {
+		case strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") && len(pattern) > 1:
+			m.prefixes[pattern[1:len(pattern)-1]] = true
+		case strings.HasSuffix(pattern, "/"):
+			m.prefixes[pattern[:len(pattern)-1]] = true
+		case strings.HasPrefix(pattern, "/"):
+			m.prefixes[pattern[1:]] = true
+		case !strings.ContainsAny(pattern, `*?[\`):
+			m.names[pattern] = true
+		default:
+			if _, err := path.Match(pattern, ""); err != nil {
+				continue
+			}
+			rest, isSuffix := strings.CutPrefix(pattern, "*")
+			switch {
+			case strings.Contains(pattern, "/"):
+				m.pathGlobs = append(m.pathGlobs, pattern)
+			case isSuffix && strings.Contains(rest, ".") && !strings.ContainsAny(rest, `*?[\`):
+				ext := path.Ext(rest)
+				m.suffixes[ext] = append(m.suffixes[ext], rest)
+			default:
+				m.elementGlobs = append(m.elementGlobs, pattern)
+				if strings.Contains(pattern, "[") {
+					m.pathGlobs = append(m.pathGlobs, pattern)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// match reports whether a pattern matches relPath, whose elements are parts.
+func (m *patternMatcher) match(relPath string, parts []string) bool {
+	if m.prefixes[relPath] || m.names[relPath] {
+		return true
+	}
+	for i := 0; i < len(relPath); i++ {
+		if relPath[i] == '/' && m.prefixes[relPath[:i]] {
+			return true
+		}
+	}
+	for _, pattern := range m.pathGlobs {
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	for _, part := range parts {
+		if m.names[part] {
+			return true
+		}
+		for _, suffix := range m.suffixes[path.Ext(part)] {
+			if strings.HasSuffix(part, suffix) {
+				return true
+			}
+		}
+		for _, pattern := range m.elementGlobs {
+			if matched, _ := path.Match(pattern, part); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
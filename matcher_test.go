@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatternMatcher(t *testing.T) {
+	patterns := []string{
+		".git", "go.sum", "generated", "cmd/tool", "/build", "dist/", "/docs/", "a/b/",
+		"*.log", "*.pb.go", "*_mock.go", "test_*", "file?.txt", "[Tt]mp", "x[^y]z", "src/*.gen.go", "[", "*.",
+	}
+	paths := []string{
+		"main.go", ".git", ".git/config", "go.sum", "sub/go.sum", "generated", "pkg/generated/x.go", "cmd/tool",
+		"cmd/tool/main.go", "cmd/tools", "build", "build/out", "sub/build", "builder", "dist", "dist/app.js", "sub/dist/a",
+		"docs/index.md", "docs", "a/b", "a/b/c", "a/bc", "app.log", "logs/app.log", "log", "api/v1/x.pb.go", ".pb.go",
+		"x.pb.gox", "db_mock.go", "test_main.py", "file1.txt", "file12.txt", "tmp/x", "Tmp", "xaz", "x/z", "src/a.gen.go",
+		"src/sub/a.gen.go", "a.", "a.b",
+	}
+	set := make(map[string]bool)
+	for _, p := range patterns {
+		set[p] = true
+	}
+	m := compilePatterns(set)
+	for _, relPath := range paths {
+		parts := strings.Split(relPath, "/")
+		expected := false
+		for _, p := range patterns {
+			expected = expected || matchPattern(p, relPath, parts)
+		}
+		if got := m.match(relPath, parts); got != expected {
+			t.Errorf("match(%q) = %v, expected %v as with matchPattern", relPath, got, expected)
+		}
+		for _, p := range patterns {
+			if got, expected := compilePatterns(map[string]bool{p: true}).match(relPath, parts), matchPattern(p, relPath, parts); got != expected {
+				t.Errorf("Pattern %q: match(%q) = %v, expected %v", p, relPath, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+)
+
+// WithNormalizeNewlines replaces CRLF line endings in file contents with LF, so
+// the output and its token count don't depend on the platform a file was
+// checked out on. It is enabled by default.
+func WithNormalizeNewlines(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.keepCRLF = !enabled
+	}
+}
+
+// crlfNormalizer is an io.Writer passing text on to w with every CRLF replaced
+// by LF. Other carriage returns are kept. A carriage return ending a write is
+// held back until the next byte is known; Flush must be called after the last
+// write.
+type crlfNormalizer struct {
+	w         io.Writer
+	pendingCR bool
+	out       []byte
+	replaced  int // Line endings replaced
+}
+
+func newCRLFNormalizer(w io.Writer) *crlfNormalizer {
+	return &crlfNormalizer{w: w}
+}
+
+func (n *crlfNormalizer) Write(p []byte) (int, error) {
+	n.out = n.out[:0]
+	for _, b := range p {
+		if n.pendingCR {
+			n.pendingCR = false
+			if b == '\n' {
+				n.replaced++
+			} else {
+				n.out = append(n.out, '\r')
+			}
+		}
+		if b == '\r' {
+			n.pendingCR = true
+			continue
+		}
+		n.out = append(n.out, b)
+	}
+	if len(n.out) > 0 {
+		if _, err := n.w.Write(n.out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes a carriage return held back at the end of the file and resets
+// the state for the next file.
+func (n *crlfNormalizer) Flush() error {
+	if !n.pendingCR {
+		return nil
+	}
+	n.pendingCR = false
+	_, err := n.w.Write([]byte{'\r'})
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCRLFNormalizer(t *testing.T) {
+	var out strings.Builder
+	n := newCRLFNormalizer(&out)
+	for _, chunk := range []string{"one\r\ntwo\r", "\nthree\rfour\r\n", "five\r"} {
+		if _, err := n.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := n.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if expected := "one\ntwo\nthree\rfour\nfive\r"; out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if n.replaced != 3 {
+		t.Errorf("Expected 3 replaced line endings, got %d", n.replaced)
+	}
+}
+
+func TestScanNormalizeNewlines(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "win.txt"), []byte("first\r\nsecond\r\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	for _, keep := range []bool{false, true} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithNormalizeNewlines(!keep))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		expected := "first\nsecond\n"
+		if keep {
+			expected = "first\r\nsecond\r\n"
+		}
+		if !strings.Contains(output.String(), "Content of win.txt:\n"+expected) {
+			t.Errorf("Expected %q with keep=%v, got:\n%q", expected, keep, output.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "strings"
+
+// WithOnlyTests restricts the file contents to test files, the files matching
+// the test patterns excluded by -t. With withTested, the files the tests are
+// named after, such as parser.go for parser_test.go, are kept as well. The
+// directory tree still shows everything.
+func WithOnlyTests(enabled, withTested bool) Option {
+	return func(g *Git2LLM) {
+		g.onlyTests = enabled
+		g.withTested = enabled && withTested
+	}
+}
+
+// filterTests drops the files other than tests if WithOnlyTests is set.
+func (g *Git2LLM) filterTests(files []manifestEntry) []manifestEntry {
+	if !g.onlyTests {
+		return files
+	}
+	m := compilePatterns(g.testPatterns())
+	tests := make(map[string]bool)
+	tested := make(map[string]bool)
+	for _, f := range files {
+		if m.match(f.relPath, strings.Split(f.relPath, "/")) {
+			tests[f.relPath] = true
+			for _, p := range testedFiles(f.relPath) {
+				tested[p] = g.withTested
+			}
+		}
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if tests[f.relPath] || tested[f.relPath] {
+			kept = append(kept, f)
+		}
+	}
+	g.logger.Debug("Kept test files", "tests", len(tests), "files", len(kept))
+	return kept
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMOnlyTests(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"README.md":        "# Project\n",
+		"main.go":          "package main\n",
+		"parser.go":        "package main\n\nfunc parse() {}\n",
+		"parser_test.go":   "package main\n\nfunc TestParse(t *testing.T) {}\n",
+		"web/foo.ts":       "export const foo = 1;\n",
+		"web/foo.test.ts":  "test('foo', () => {});\n",
+		"tests/test_db.py": "def test_db(): pass\n",
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		withTested bool
+		included   []string
+	}{
+		{"tests", false, []string{"parser_test.go", "tests/test_db.py", "web/foo.test.ts"}},
+		{"with tested", true, []string{"parser.go", "parser_test.go", "tests/test_db.py", "web/foo.test.ts", "web/foo.ts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+			git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithOnlyTests(true, tt.withTested))
+			if err != nil {
+				t.Fatalf("NewGit2LLM failed: %v", err)
+			}
+			result, err := Scan(git2llm)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			var included []string
+			for _, f := range result.Files {
+				included = append(included, f.Path)
+			}
+			if strings.Join(included, ",") != strings.Join(tt.included, ",") {
+				t.Errorf("Expected %v, got %v", tt.included, included)
+			}
+			// The tree still shows everything
+			if !strings.Contains(output.String(), "main.go\n") {
+				t.Errorf("Expected main.go in the tree. Result:\n%s", output.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Orders of the file contents.
+const (
+	OrderPath    = "path"    // Sorted by path, the default
+	OrderGrouped = "grouped" // Sorted by path, with tests right after the files they test
+)
+
+// WithOrder sets the order of the file contents, OrderPath or OrderGrouped.
+func WithOrder(order string) Option {
+	return func(g *Git2LLM) {
+		g.order = order
+	}
+}
+
+// WithDocsFirst moves the documentation ahead of the other files, keeping the
+// order within both, so the orientation material comes early in the prompt.
+// See docRank for what counts as documentation.
+func WithDocsFirst(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.docsFirst = enabled
+	}
+}
+
+// docPrefixes are the name prefixes of documentation files, in lower case.
+var docPrefixes = []string{"readme", "architecture", "contributing"}
+
+// docRank ranks the file at relPath for WithDocsFirst: 0 for the README of the
+// start path, 1 for other documentation (README*, ARCHITECTURE* and
+// CONTRIBUTING* files anywhere and everything in a docs directory at the top),
+// 2 for all other files.
+func docRank(relPath string) int {
+	dir, name := path.Split(relPath)
+	if dir == "" && strings.HasPrefix(strings.ToLower(name), "readme") {
+		return 0
+	}
+	if isDocName(name) {
+		return 1
+	}
+	if first, _, _ := strings.Cut(dir, "/"); strings.EqualFold(first, "docs") || strings.EqualFold(first, "doc") {
+		return 1
+	}
+	return 2
+}
+
+// isDocName reports whether name is that of a README*, ARCHITECTURE* or
+// CONTRIBUTING* file.
+func isDocName(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range docPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// docsFirst sorts files by docRank, keeping their order within each rank.
+func docsFirst(files []manifestEntry) []manifestEntry {
+	sort.SliceStable(files, func(i, j int) bool {
+		return docRank(files[i].relPath) < docRank(files[j].relPath)
+	})
+	return files
+}
+
+// testSuffixes map the name endings of test files to the endings of the files
+// they test, e.g. foo_test.go tests foo.go and foo.spec.ts tests foo.ts.
+var testSuffixes = [][2]string{
+	{"_test.go", ".go"},
+	{"_test.py", ".py"},
+	{"_test.rb", ".rb"},
+	{"_spec.rb", ".rb"},
+	{"Test.java", ".java"},
+	{"Tests.java", ".java"},
+	{"Test.kt", ".kt"},
+	{"Tests.kt", ".kt"},
+	{"Tests.cs", ".cs"},
+	{"Test.cs", ".cs"},
+	{"Test.php", ".php"},
+	{"_test.exs", ".ex"},
+}
+
+// scriptTestExtensions are the JavaScript and TypeScript extensions whose tests
+// are named foo.test.ts or foo.spec.ts.
+var scriptTestExtensions = []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"}
+
+// testedFiles returns the paths of the files the file at relPath may test,
+// most likely first, or nil if it isn't named like a test.
+func testedFiles(relPath string) []string {
+	dir, name := path.Split(relPath)
+	var names []string
+	for _, s := range testSuffixes {
+		if base, ok := strings.CutSuffix(name, s[0]); ok && base != "" {
+			names = append(names, base+s[1])
+		}
+	}
+	if base, ok := strings.CutPrefix(name, "test_"); ok && strings.HasSuffix(base, ".py") {
+		names = append(names, base)
+	}
+	for _, ext := range scriptTestExtensions {
+		for _, infix := range []string{".test", ".spec"} {
+			if base, ok := strings.CutSuffix(name, infix+ext); ok && base != "" {
+				names = append(names, base+ext)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Tests may live next to the file, in a __tests__ directory below it,
+	// or in the mirrored src/test tree of Maven and Gradle projects
+	dirs := []string{dir}
+	if strings.HasSuffix(dir, "__tests__/") {
+		dirs = append(dirs, strings.TrimSuffix(dir, "__tests__/"))
+	}
+	if before, after, ok := strings.Cut("/"+dir, "/src/test/"); ok {
+		dirs = append(dirs, strings.TrimPrefix(before+"/src/main/"+after, "/"))
+	}
+	var paths []string
+	for _, d := range dirs {
+		for _, n := range names {
+			paths = append(paths, d+n)
+		}
+	}
+	return paths
+}
+
+// testFiles returns the paths of the files that may test the file at relPath,
+// the reverse of testedFiles, or nil if it is a test itself.
+func testFiles(relPath string) []string {
+	if testedFiles(relPath) != nil {
+		return nil
+	}
+	dir, name := path.Split(relPath)
+	var names []string
+	for _, s := range testSuffixes {
+		if base, ok := strings.CutSuffix(name, s[1]); ok && base != "" {
+			names = append(names, base+s[0])
+		}
+	}
+	if strings.HasSuffix(name, ".py") {
+		names = append(names, "test_"+name)
+	}
+	for _, ext := range scriptTestExtensions {
+		if base, ok := strings.CutSuffix(name, ext); ok && base != "" {
+			names = append(names, base+".test"+ext, base+".spec"+ext)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	dirs := []string{dir, dir + "__tests__/"}
+	if before, after, ok := strings.Cut("/"+dir, "/src/main/"); ok {
+		dirs = append(dirs, strings.TrimPrefix(before+"/src/test/"+after, "/"))
+	}
+	var paths []string
+	for _, d := range dirs {
+		for _, n := range names {
+			paths = append(paths, d+n)
+		}
+	}
+	return paths
+}
+
+// groupTests moves every test file right after the file it tests, if that file
+// is part of files. Other files keep their order.
+func groupTests(files []manifestEntry) []manifestEntry {
+	index := make(map[string]int, len(files))
+	for i, f := range files {
+		index[f.relPath] = i
+	}
+	tests := make(map[int][]int)
+	grouped := make(map[int]bool)
+	for i, f := range files {
+		for _, tested := range testedFiles(f.relPath) {
+			if j, ok := index[tested]; ok && j != i {
+				tests[j] = append(tests[j], i)
+				grouped[i] = true
+				break
+			}
+		}
+	}
+	if len(grouped) == 0 {
+		return files
+	}
+	ordered := make([]manifestEntry, 0, len(files))
+	var add func(i int)
+	add = func(i int) {
+		ordered = append(ordered, files[i])
+		for _, j := range tests[i] {
+			add(j) // Tested file names are shorter, so there are no cycles
+		}
+	}
+	for i := range files {
+		if !grouped[i] {
+			add(i)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTestedFiles(t *testing.T) {
+	testCases := []struct {
+		path   string
+		expect []string
+	}{
+		{"pkg/server_test.go", []string{"pkg/server.go"}},
+		{"app/test_models.py", []string{"app/models.py"}},
+		{"src/__tests__/button.test.tsx", []string{"src/__tests__/button.tsx", "src/button.tsx"}},
+		{"src/test/java/com/acme/ParserTest.java", []string{"src/test/java/com/acme/Parser.java", "src/main/java/com/acme/Parser.java"}},
+		{"lib/user_spec.rb", []string{"lib/user.rb"}},
+		{"main.go", nil},
+		{"_test.go", nil},
+	}
+	for _, tc := range testCases {
+		if got := testedFiles(tc.path); !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("For %s, expected %q, got %q", tc.path, tc.expect, got)
+		}
+	}
+}
+
+func TestTestFiles(t *testing.T) {
+	if got, expect := testFiles("pkg/server.go"), []string{"pkg/server_test.go", "pkg/__tests__/server_test.go"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+	if got := testFiles("app/models.py"); !slices.Contains(got, "app/test_models.py") {
+		t.Errorf("Expected app/test_models.py among %q", got)
+	}
+	if got := testFiles("src/button.tsx"); !slices.Contains(got, "src/button.test.tsx") || !slices.Contains(got, "src/__tests__/button.test.tsx") {
+		t.Errorf("Expected the tests of button.tsx among %q", got)
+	}
+	if got := testFiles("src/main/java/com/acme/Parser.java"); !slices.Contains(got, "src/test/java/com/acme/ParserTest.java") {
+		t.Errorf("Expected the mirrored test among %q", got)
+	}
+	for _, p := range []string{"pkg/server_test.go", "README.md"} {
+		if got := testFiles(p); got != nil {
+			t.Errorf("For %s, expected no tests, got %q", p, got)
+		}
+	}
+}
+
+func TestGroupTests(t *testing.T) {
+	var files []manifestEntry
+	for _, p := range []string{
+		"a.go",
+		"a_extra_test.go",
+		"a_test.go",
+		"b.go",
+		"orphan_test.go",
+		"src/main/java/Parser.java",
+		"src/test/java/ParserTest.java",
+		"web/app.js",
+		"web/app.test.js",
+		"z_test.go",
+		"z.go",
+	} {
+		files = append(files, manifestEntry{relPath: p})
+	}
+	var got []string
+	for _, f := range groupTests(files) {
+		got = append(got, f.relPath)
+	}
+	expect := []string{
+		"a.go",
+		"a_test.go",
+		"a_extra_test.go",
+		"b.go",
+		"orphan_test.go",
+		"src/main/java/Parser.java",
+		"src/test/java/ParserTest.java",
+		"web/app.js",
+		"web/app.test.js",
+		"z.go",
+		"z_test.go",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
+
+func TestDocsFirst(t *testing.T) {
+	var files []manifestEntry
+	for _, p := range []string{
+		"ARCHITECTURE.md",
+		"cmd/main.go",
+		"docs/guide/setup.md",
+		"docs/intro.md",
+		"go.mod",
+		"pkg/api/README.md",
+		"pkg/api/api.go",
+		"pkg/docs/notes.md",
+		"readme.txt",
+		"CONTRIBUTING",
+	} {
+		files = append(files, manifestEntry{relPath: p})
+	}
+	var got []string
+	for _, f := range docsFirst(files) {
+		got = append(got, f.relPath)
+	}
+	expect := []string{
+		"readme.txt",
+		"ARCHITECTURE.md",
+		"docs/guide/setup.md",
+		"docs/intro.md",
+		"pkg/api/README.md",
+		"CONTRIBUTING",
+		"cmd/main.go",
+		"go.mod",
+		"pkg/api/api.go",
+		"pkg/docs/notes.md",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionExtensions maps the supported --compress formats to their file extension.
+var compressionExtensions = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// outputFile is the file the pack is written to with -o, optionally compressed.
+// Everything is streamed, so memory use doesn't depend on the size of the pack.
+type outputFile struct {
+	file     *os.File
+	buf      *bufio.Writer
+	compress string
+	enc      io.WriteCloser // The compressor, if any
+	w        io.Writer
+}
+
+// outputPath returns the path of the output file, adding the extension of the
+// compression format unless path already has it.
+func outputPath(path, compress string) (string, error) {
+	if compress == "" {
+		return path, nil
+	}
+	ext, ok := compressionExtensions[compress]
+	if !ok {
+		return "", fmt.Errorf("unknown compression %q (expected gzip or zstd)", compress)
+	}
+	if !strings.HasSuffix(path, ext) {
+		path += ext
+	}
+	return path, nil
+}
+
+// createOutput creates the output file at path, compressed with compress if it is not empty.
+func createOutput(path, compress string) (*outputFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Create: %w", err)
+	}
+	o := &outputFile{file: file, buf: bufio.NewWriterSize(file, 64*1024), compress: compress}
+	o.w = o.buf
+	switch compress {
+	case "gzip":
+		o.enc = gzip.NewWriter(o.buf)
+	case "zstd":
+		enc, err := zstd.NewWriter(o.buf)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("zstd.NewWriter: %w", err)
+		}
+		o.enc = enc
+	}
+	if o.enc != nil {
+		o.w = o.enc
+	}
+	return o, nil
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	return o.w.Write(p)
+}
+
+// Close flushes the compressor and the buffer and closes the file.
+func (o *outputFile) Close() error {
+	if o.enc != nil {
+		if err := o.enc.Close(); err != nil {
+			o.file.Close()
+			return fmt.Errorf("%s.Close: %w", o.compress, err)
+		}
+	}
+	if err := o.buf.Flush(); err != nil {
+		o.file.Close()
+		return fmt.Errorf("bufio.Flush: %w", err)
+	}
+	if err := o.file.Close(); err != nil {
+		return fmt.Errorf("os.Close: %w", err)
+	}
+	return nil
+}
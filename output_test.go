@@ -0,0 +1,90 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct {
+		path, compress, expected string
+	}{
+		{"pack.txt", "", "pack.txt"},
+		{"pack.txt", "gzip", "pack.txt.gz"},
+		{"pack.txt.gz", "gzip", "pack.txt.gz"},
+		{"pack.txt", "zstd", "pack.txt.zst"},
+	}
+	for _, tt := range tests {
+		got, err := outputPath(tt.path, tt.compress)
+		if err != nil {
+			t.Fatalf("outputPath(%q, %q) failed: %v", tt.path, tt.compress, err)
+		}
+		if got != tt.expected {
+			t.Errorf("outputPath(%q, %q) = %q, expected %q", tt.path, tt.compress, got, tt.expected)
+		}
+	}
+	if _, err := outputPath("pack.txt", "bzip2"); err == nil {
+		t.Errorf("Expected an error for --compress bzip2")
+	}
+}
+
+func TestGit2LLMCompressedOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create source directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	decompressors := map[string]func(r io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for compress, decompress := range decompressors {
+		t.Run(compress, func(t *testing.T) {
+			path, err := outputPath(filepath.Join(tempDir, "pack.txt"), compress)
+			if err != nil {
+				t.Fatalf("outputPath failed: %v", err)
+			}
+			out, err := createOutput(path, compress)
+			if err != nil {
+				t.Fatalf("createOutput failed: %v", err)
+			}
+			git2llm, err := NewGit2LLM(srcDir, nil, nil, out, false, false, false, nil, "", false)
+			if err != nil {
+				t.Fatalf("NewGit2LLM failed: %v", err)
+			}
+			if err := git2llm.ScanRepository(); err != nil {
+				t.Fatalf("ScanRepository failed: %v", err)
+			}
+			if err := out.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Failed to open output: %v", err)
+			}
+			defer f.Close()
+			r, err := decompress(f)
+			if err != nil {
+				t.Fatalf("Output is not %s compressed: %v", compress, err)
+			}
+			content, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Failed to decompress output: %v", err)
+			}
+			if !strings.Contains(string(content), "Content of main.go:\npackage main\n") {
+				t.Errorf("Decompressed output is missing the file content:\n%s", content)
+			}
+		})
+	}
+}
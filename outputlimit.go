@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WithMaxBytes stops writing file contents before the output grows over n
+// bytes, whether or not tokens are counted. The file that would not fit and
+// all files after it are left out and recorded as skipped; the tree still
+// lists them. 0 means no limit.
+func WithMaxBytes(n int64) Option {
+	return func(g *Git2LLM) {
+		g.maxBytes = n
+	}
+}
+
+// outputBudget is an io.Writer counting the bytes written to the output of a
+// scan, shared by all roots so the limit applies to the whole output.
+type outputBudget struct {
+	max     int64
+	written int64
+	omitted int // Files left out once the limit was reached
+}
+
+func (b *outputBudget) Write(p []byte) (int, error) {
+	b.written += int64(len(p))
+	return len(p), nil
+}
+
+// fits reports whether n more bytes can be written. Once a file was left out,
+// nothing fits anymore, so the output is cut off at one place.
+func (b *outputBudget) fits(n int64) bool {
+	return b.omitted == 0 && b.written+n <= b.max
+}
+
+// omit records that the file at relPath is left out of the output for the limit.
+func (b *outputBudget) omit(g *Git2LLM, relPath string) {
+	if b.omitted == 0 {
+		g.logger.Warn("Output limit reached, leaving out the remaining files", "limit", b.max, "path", relPath)
+	}
+	b.omitted++
+	g.skip(relPath, SkipOverLimit, fmt.Sprintf("output limit of %d bytes reached", b.max))
+}
+
+// errOverLimit stops writing the stanza of a file that doesn't fit in the budget.
+var errOverLimit = errors.New("output limit reached")
+
+// stanzaBuffer holds the stanza of a file until all of it is written. Writing
+// more than room bytes fails, so a file far over the limit isn't read in full.
+type stanzaBuffer struct {
+	bytes.Buffer
+	room int64
+}
+
+func (s *stanzaBuffer) Write(p []byte) (int, error) {
+	if int64(s.Len()+len(p)) > s.room {
+		return 0, errOverLimit
+	}
+	return s.Buffer.Write(p)
+}
+
+// pendingEmitter holds back the file passed to an emitter until the stanza of
+// the file is known to fit, so a file left out isn't emitted either.
+type pendingEmitter struct {
+	emitter
+	relPath, lang string
+	begun, ended  bool
+	content       bytes.Buffer
+}
+
+func (p *pendingEmitter) beginFile(relPath, lang string) (io.Writer, error) {
+	p.relPath, p.lang, p.begun = relPath, lang, true
+	return &p.content, nil
+}
+
+func (p *pendingEmitter) endFile() error {
+	p.ended = true
+	return nil
+}
+
+// flush passes the file held back on to the emitter.
+func (p *pendingEmitter) flush() error {
+	if !p.begun {
+		return nil
+	}
+	w, err := p.emitter.beginFile(p.relPath, p.lang)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(p.content.Bytes()); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if !p.ended {
+		return nil
+	}
+	return p.emitter.endFile()
+}
+
+// processWithinBudget processes the file at filePath like processFile, but
+// only writes its stanza if all of it fits in the budget. The size is that of
+// the bytes actually written, which can be more than the size on disk, e.g. with
+// replacement characters for invalid UTF-8 or placeholders for personal data.
+func (g *Git2LLM) processWithinBudget(filePath, relPath string) error {
+	out, emitters := g.outputWriter, g.emitters
+	stanza := &stanzaBuffer{room: g.budget.max - g.budget.written}
+	pending := make([]*pendingEmitter, len(emitters))
+	g.emitters = make([]emitter, len(emitters))
+	for i, e := range emitters {
+		pending[i] = &pendingEmitter{emitter: e}
+		g.emitters[i] = pending[i]
+	}
+	g.outputWriter = stanza
+	skipped := len(g.skipped)
+	err := g.processFile(filePath, relPath)
+	g.outputWriter, g.emitters = out, emitters
+	if errors.Is(err, errOverLimit) {
+		// The file is only recorded as left out for the limit
+		g.skipped = g.skipped[:skipped]
+		g.budget.omit(g, g.displayPath(relPath))
+		return nil
+	}
+	if _, err := out.Write(stanza.Bytes()); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	for _, p := range pending {
+		if err := p.flush(); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// withBudget returns w counting its bytes in the budget b, if there is one.
+func withBudget(w io.Writer, b *outputBudget) io.Writer {
+	if b == nil {
+		return w
+	}
+	return io.MultiWriter(w, b)
+}
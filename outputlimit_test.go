@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanMaxBytes(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"a.txt": strings.Repeat("a", 200) + "\n",
+		"b.txt": strings.Repeat("b", 2000) + "\n",
+		"c.txt": "c\n",
+	}
+	for name, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	const limit = 1000
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithMaxBytes(limit))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	text := output.String()
+	if len(text) > limit {
+		t.Errorf("Expected at most %d bytes, got %d:\n%s", limit, len(text), text)
+	}
+	if !strings.Contains(text, "File: a.txt\n") || strings.Contains(text, "File: b.txt") {
+		t.Errorf("Expected a.txt and not b.txt in the output. Result:\n%s", text)
+	}
+	// Emission stops at the first file that doesn't fit, even if later ones would
+	if strings.Contains(text, "File: c.txt") || !strings.Contains(text, "└── c.txt\n") {
+		t.Errorf("Expected c.txt only in the tree. Result:\n%s", text)
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0].Path != "b.txt" || result.Skipped[1].Path != "c.txt" ||
+		result.Skipped[0].Reason != SkipOverLimit || result.Skipped[0].Detail != "output limit of 1000 bytes reached" {
+		t.Errorf("Expected b.txt and c.txt to be skipped for the limit, got %+v", result.Skipped)
+	}
+}
+
+func TestScanMaxBytesGrowingContent(t *testing.T) {
+	// Invalid UTF-8 is written as U+FFFD, three bytes for every byte on disk
+	latin1 := "caf" + strings.Repeat("\xe9", 400) + "\n"
+	fsys := IOFS{FS: fstest.MapFS{
+		"a.txt":      {Data: []byte("a\n")},
+		"latin1.txt": {Data: []byte(latin1)},
+	}}
+	const limit = 1000
+	var output, md strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false,
+		WithMaxBytes(limit), withEmitters(&markdownEmitter{w: &md}))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	text := output.String()
+	if len(text) > limit {
+		t.Errorf("Expected at most %d bytes, got %d:\n%s", limit, len(text), text)
+	}
+	if !strings.Contains(text, "File: a.txt\n") || strings.Contains(text, "File: latin1.txt") {
+		t.Errorf("Expected a.txt and not latin1.txt in the output. Result:\n%s", text)
+	}
+	if strings.Contains(md.String(), "caf") {
+		t.Errorf("Expected latin1.txt to be left out of the emitted markdown too, got:\n%s", md.String())
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != "latin1.txt" || result.Skipped[0].Reason != SkipOverLimit {
+		t.Errorf("Expected latin1.txt to be skipped for the limit, got %+v", result.Skipped)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "a.txt" {
+		t.Errorf("Expected only a.txt in the result, got %+v", result.Files)
+	}
+}
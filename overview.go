@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WithOverview emits a project overview before the directory tree, listing the
+// projects found by their marker files (go.mod, package.json, Cargo.toml, ...)
+// with their languages, build systems, frameworks and entrypoints.
+func WithOverview(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.overview = enabled
+	}
+}
+
+// project is a project detected in the start directory.
+type project struct {
+	kind        string // e.g. "Go module"
+	name        string
+	languages   []string
+	build       string // Build system and the file it was found by
+	frameworks  []string
+	entrypoints []string
+}
+
+// projectDetectors find the projects in the start directory, in output order.
+var projectDetectors = []func(g *Git2LLM) (project, bool){
+	detectGo,
+	detectNode,
+	detectPython,
+	detectRust,
+	detectJVM,
+	detectRuby,
+	detectPHP,
+	detectDotNet,
+	detectNative,
+	detectMarker("mix.exs", "Elixir project", "Elixir", "mix"),
+	detectMarker("pubspec.yaml", "Dart package", "Dart", "pub"),
+	detectMarker("Package.swift", "Swift package", "Swift", "SwiftPM"),
+	detectMarker("deno.json", "Deno project", "TypeScript", "deno"),
+}
+
+// buildFiles are build and deployment files listed apart from the projects.
+var buildFiles = []string{"Makefile", "justfile", "Taskfile.yml", "Dockerfile", "docker-compose.yml", "compose.yaml", "Procfile"}
+
+// writeOverview writes the project overview of all roots to w. Roots without
+// any detected project are left out; nothing is written if none has one.
+func writeOverview(w io.Writer, roots []*Git2LLM) error {
+	var out strings.Builder
+	for _, g := range roots {
+		var projects []project
+		for _, detect := range projectDetectors {
+			if p, ok := detect(g); ok {
+				projects = append(projects, p)
+			}
+		}
+		var other []string
+		for _, name := range buildFiles {
+			if g.exists(name) {
+				other = append(other, name)
+			}
+		}
+		if len(projects) == 0 && len(other) == 0 {
+			continue
+		}
+		// Entrypoints are relative to the root, which is named when there are several
+		if g.pathPrefix != "" {
+			fmt.Fprintf(&out, "%s:\n", g.pathPrefix)
+		}
+		for _, p := range projects {
+			out.WriteString(p.kind)
+			if p.name != "" {
+				out.WriteString(" " + p.name)
+			}
+			out.WriteString("\n")
+			writeOverviewLine(&out, "Languages", p.languages)
+			if p.build != "" {
+				writeOverviewLine(&out, "Build", []string{p.build})
+			}
+			writeOverviewLine(&out, "Frameworks", p.frameworks)
+			writeOverviewLine(&out, "Entrypoints", p.entrypoints)
+		}
+		if len(other) > 0 {
+			writeOverviewLine(&out, "Other build files", other)
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Project Overview:\n-----------------\n%s\n\n", out.String()); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+func writeOverviewLine(out *strings.Builder, label string, values []string) {
+	if len(values) > 0 {
+		fmt.Fprintf(out, "  %s: %s\n", label, strings.Join(values, ", "))
+	}
+}
+
+// readRootFile reads the file name, slash separated and relative to the start path.
+func (g *Git2LLM) readRootFile(name string) ([]byte, bool) {
+	content, err := g.fs.ReadFile(filepath.Join(g.startPath, filepath.FromSlash(name)))
+	return content, err == nil
+}
+
+// exists reports whether name, relative to the start path, exists.
+func (g *Git2LLM) exists(name string) bool {
+	_, err := g.fs.Stat(filepath.Join(g.startPath, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// existing returns the names that exist below the start path.
+func (g *Git2LLM) existing(names ...string) []string {
+	var found []string
+	for _, name := range names {
+		if g.exists(name) {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// subdirs returns the directories in dir, relative to the start path.
+func (g *Git2LLM) subdirs(dir string) []string {
+	entries, err := g.fs.ReadDir(filepath.Join(g.startPath, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, path.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// frameworksIn returns the frameworks whose dependency name is in deps.
+func frameworksIn(deps map[string]bool, known [][2]string) []string {
+	var found []string
+	for _, f := range known {
+		if deps[f[0]] {
+			found = append(found, f[1])
+		}
+	}
+	return found
+}
+
+var goFrameworks = [][2]string{
+	{"github.com/gin-gonic/gin", "Gin"}, {"github.com/labstack/echo/v4", "Echo"}, {"github.com/gofiber/fiber/v2", "Fiber"},
+	{"github.com/go-chi/chi/v5", "chi"}, {"github.com/gorilla/mux", "gorilla/mux"}, {"github.com/spf13/cobra", "Cobra"},
+	{"google.golang.org/grpc", "gRPC"}, {"gorm.io/gorm", "GORM"},
+}
+
+func detectGo(g *Git2LLM) (project, bool) {
+	content, ok := g.readRootFile("go.mod")
+	if !ok {
+		return project{}, false
+	}
+	mod := parseGoMod(content)
+	p := project{kind: "Go module", name: mod.module, languages: []string{"Go"}, build: "go (go.mod)"}
+	if mod.goVersion != "" {
+		p.languages = []string{"Go " + mod.goVersion}
+	}
+	deps := make(map[string]bool)
+	for _, r := range mod.requires {
+		if r[2] == "" {
+			deps[r[0]] = true
+		}
+	}
+	p.frameworks = frameworksIn(deps, goFrameworks)
+	// The file with the main function of a command in the start directory
+	if entries, err := g.fs.ReadDir(g.startPath); err == nil {
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+				continue
+			}
+			if src, ok := g.readRootFile(e.Name()); ok && bytes.Contains(src, []byte("package main")) && bytes.Contains(src, []byte("\nfunc main()")) {
+				p.entrypoints = append(p.entrypoints, e.Name())
+				break
+			}
+		}
+	}
+	for _, dir := range g.subdirs("cmd") {
+		p.entrypoints = append(p.entrypoints, dir+"/")
+	}
+	return p, true
+}
+
+// goMod is what the overview and the dependency summary need from a go.mod file.
+type goMod struct {
+	module    string
+	goVersion string
+	requires  [][3]string // path, version and "indirect" for indirect dependencies
+}
+
+func parseGoMod(content []byte) goMod {
+	var mod goMod
+	inRequire := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		indirect := strings.TrimSpace(comment) == "indirect"
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) >= 2:
+			mod.requires = append(mod.requires, goRequire(fields, indirect))
+		case fields[0] == "module" && len(fields) >= 2:
+			mod.module = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) >= 2:
+			mod.goVersion = fields[1]
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) >= 3:
+			mod.requires = append(mod.requires, goRequire(fields[1:], indirect))
+		}
+	}
+	return mod
+}
+
+func goRequire(fields []string, indirect bool) [3]string {
+	r := [3]string{strings.Trim(fields[0], `"`), fields[1], ""}
+	if indirect {
+		r[2] = "indirect"
+	}
+	return r
+}
+
+var nodeFrameworks = [][2]string{
+	{"react", "React"}, {"next", "Next.js"}, {"vue", "Vue"}, {"nuxt", "Nuxt"}, {"@angular/core", "Angular"},
+	{"svelte", "Svelte"}, {"express", "Express"}, {"fastify", "Fastify"}, {"@nestjs/core", "NestJS"},
+	{"electron", "Electron"}, {"vite", "Vite"},
+}
+
+// packageJSON is what the overview and the dependency summary need from a package.json file.
+type packageJSON struct {
+	Name            string            `json:"name"`
+	Main            string            `json:"main"`
+	Bin             json.RawMessage   `json:"bin"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func detectNode(g *Git2LLM) (project, bool) {
+	content, ok := g.readRootFile("package.json")
+	if !ok {
+		return project{}, false
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return project{kind: "Node package", languages: []string{"JavaScript"}, build: "npm (package.json)"}, true
+	}
+	p := project{kind: "Node package", name: pkg.Name, languages: []string{"JavaScript"}}
+	deps := make(map[string]bool)
+	for name := range pkg.Dependencies {
+		deps[name] = true
+	}
+	for name := range pkg.DevDependencies {
+		deps[name] = true
+	}
+	if deps["typescript"] || g.exists("tsconfig.json") {
+		p.languages = []string{"TypeScript", "JavaScript"}
+	}
+	p.build = "npm (package.json)"
+	for _, lock := range [][2]string{{"yarn.lock", "yarn"}, {"pnpm-lock.yaml", "pnpm"}, {"bun.lockb", "bun"}, {"bun.lock", "bun"}} {
+		if g.exists(lock[0]) {
+			p.build = lock[1] + " (" + lock[0] + ")"
+			break
+		}
+	}
+	p.frameworks = frameworksIn(deps, nodeFrameworks)
+	if pkg.Main != "" {
+		p.entrypoints = append(p.entrypoints, path.Clean(pkg.Main))
+	}
+	var bin string
+	var bins map[string]string
+	if json.Unmarshal(pkg.Bin, &bin) == nil && bin != "" {
+		p.entrypoints = append(p.entrypoints, path.Clean(bin))
+	} else if json.Unmarshal(pkg.Bin, &bins) == nil {
+		names := make([]string, 0, len(bins))
+		for name := range bins {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p.entrypoints = append(p.entrypoints, path.Clean(bins[name]))
+		}
+	}
+	for _, script := range []string{"start", "dev", "build", "test"} {
+		if _, ok := pkg.Scripts[script]; ok {
+			p.entrypoints = append(p.entrypoints, "npm run "+script)
+		}
+	}
+	return p, true
+}
+
+// tomlTables reads the keys of a TOML file by table, e.g. "project" -> "name" ->
+// `"app"`. Values are kept as written, with multi-line arrays joined into one
+// line. Arrays of tables are not distinguished from tables; this is enough for
+// the manifest files the overview reads.
+func tomlTables(content []byte) map[string]map[string]string {
+	tables := map[string]map[string]string{"": {}}
+	table := ""
+	var key string
+	var value strings.Builder
+	depth := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if depth > 0 {
+			value.WriteString(" " + line)
+			depth += strings.Count(line, "[") - strings.Count(line, "]")
+			if depth <= 0 {
+				tables[table][key] = value.String()
+				depth = 0
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			if tables[table] == nil {
+				tables[table] = make(map[string]string)
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(k), `"'`)
+		v = strings.TrimSpace(v)
+		if depth = strings.Count(v, "[") - strings.Count(v, "]"); depth > 0 {
+			value.Reset()
+			value.WriteString(v)
+			continue
+		}
+		depth = 0
+		tables[table][key] = v
+	}
+	return tables
+}
+
+// tomlString returns the content of a quoted TOML string.
+func tomlString(value string) string {
+	return strings.Trim(value, `"'`)
+}
+
+var pythonFrameworks = [][2]string{
+	{"django", "Django"}, {"flask", "Flask"}, {"fastapi", "FastAPI"}, {"pytorch", "PyTorch"}, {"torch", "PyTorch"},
+	{"tensorflow", "TensorFlow"}, {"pandas", "pandas"}, {"pytest", "pytest"},
+}
+
+func detectPython(g *Git2LLM) (project, bool) {
+	pyproject, hasPyproject := g.readRootFile("pyproject.toml")
+	markers := g.existing("setup.py", "setup.cfg", "requirements.txt", "Pipfile")
+	if !hasPyproject && len(markers) == 0 {
+		return project{}, false
+	}
+	p := project{kind: "Python project", languages: []string{"Python"}}
+	deps := make(map[string]bool)
+	if hasPyproject {
+		tables := tomlTables(pyproject)
+		p.name = tomlString(tables["project"]["name"])
+		if p.name == "" {
+			p.name = tomlString(tables["tool.poetry"]["name"])
+		}
+		backend := tomlString(tables["build-system"]["build-backend"])
+		p.build = "pip (pyproject.toml)"
+		for _, b := range []string{"poetry", "hatch", "flit", "pdm", "setuptools", "maturin"} {
+			if strings.Contains(backend, b) {
+				p.build = b + " (pyproject.toml)"
+				break
+			}
+		}
+		for _, scripts := range []string{"project.scripts", "tool.poetry.scripts"} {
+			for _, name := range sortedKeys(tables[scripts]) {
+				p.entrypoints = append(p.entrypoints, name)
+			}
+		}
+		for _, dep := range pyprojectRequirements(tables) {
+			deps[dep[0]] = true
+		}
+	}
+	if g.exists("uv.lock") {
+		p.build = "uv (uv.lock)"
+	} else if p.build == "" {
+		switch markers[0] {
+		case "Pipfile":
+			p.build = "pipenv (Pipfile)"
+		case "requirements.txt":
+			p.build = "pip (requirements.txt)"
+		default:
+			p.build = "setuptools (" + markers[0] + ")"
+		}
+	}
+	if content, ok := g.readRootFile("requirements.txt"); ok {
+		for _, dep := range requirementsTxt(content) {
+			deps[dep[0]] = true
+		}
+	}
+	p.frameworks = frameworksIn(deps, pythonFrameworks)
+	p.entrypoints = append(p.entrypoints, g.existing("manage.py", "__main__.py", "main.py", "app.py")...)
+	return p, true
+}
+
+// pyprojectRequirements returns the names and version constraints of the
+// dependencies of a pyproject.toml file, from the [project] table or Poetry.
+func pyprojectRequirements(tables map[string]map[string]string) [][2]string {
+	specs := tomlArray(tables["project"]["dependencies"])
+	for _, t := range []string{"tool.poetry.dependencies", "tool.poetry.group.dev.dependencies"} {
+		for _, name := range sortedKeys(tables[t]) {
+			if name != "python" {
+				specs = append(specs, name+" "+tomlString(tables[t][name]))
+			}
+		}
+	}
+	return splitRequirements(specs)
+}
+
+// requirementsTxt returns the names and version constraints in a requirements.txt file.
+func requirementsTxt(content []byte) [][2]string {
+	var specs []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "-") {
+			specs = append(specs, line)
+		}
+	}
+	return splitRequirements(specs)
+}
+
+// splitRequirements splits requirement specifiers such as "django>=4.2" into
+// the lower case name and the constraint, leaving out extras.
+func splitRequirements(specs []string) [][2]string {
+	var deps [][2]string
+	for _, spec := range specs {
+		spec, _, _ = strings.Cut(spec, ";") // Environment markers
+		i := strings.IndexAny(spec, " <>=!~[(@")
+		if i < 0 {
+			i = len(spec)
+		}
+		name := strings.ToLower(strings.TrimSpace(spec[:i]))
+		constraint := strings.TrimSpace(spec[i:])
+		if strings.HasPrefix(constraint, "[") {
+			// Extras such as requests[security]
+			if _, after, ok := strings.Cut(constraint, "]"); ok {
+				constraint = strings.TrimSpace(after)
+			}
+		}
+		if name != "" {
+			deps = append(deps, [2]string{name, constraint})
+		}
+	}
+	return deps
+}
+
+// tomlArray returns the strings in a TOML array of strings.
+func tomlArray(value string) []string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+		if item = tomlString(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var rustFrameworks = [][2]string{
+	{"tokio", "Tokio"}, {"actix-web", "Actix Web"}, {"axum", "axum"}, {"rocket", "Rocket"}, {"bevy", "Bevy"},
+	{"clap", "clap"}, {"serde", "Serde"},
+}
+
+func detectRust(g *Git2LLM) (project, bool) {
+	content, ok := g.readRootFile("Cargo.toml")
+	if !ok {
+		return project{}, false
+	}
+	tables := tomlTables(content)
+	p := project{kind: "Rust crate", name: tomlString(tables["package"]["name"]), languages: []string{"Rust"}, build: "cargo (Cargo.toml)"}
+	if _, ok := tables["workspace"]; ok && p.name == "" {
+		p.kind = "Rust workspace"
+		p.entrypoints = tomlArray(tables["workspace"]["members"])
+	}
+	deps := make(map[string]bool)
+	for name := range tables["dependencies"] {
+		deps[name] = true
+	}
+	p.frameworks = frameworksIn(deps, rustFrameworks)
+	p.entrypoints = append(p.entrypoints, g.existing("src/main.rs", "src/lib.rs")...)
+	if entries, err := g.fs.ReadDir(filepath.Join(g.startPath, "src", "bin")); err == nil {
+		for _, e := range entries {
+			p.entrypoints = append(p.entrypoints, "src/bin/"+e.Name())
+		}
+	}
+	return p, true
+}
+
+func detectJVM(g *Git2LLM) (project, bool) {
+	var p project
+	var content []byte
+	switch {
+	case g.exists("pom.xml"):
+		p = project{kind: "Maven project", build: "Maven (pom.xml)"}
+		content, _ = g.readRootFile("pom.xml")
+	case g.exists("build.gradle.kts"), g.exists("build.gradle"):
+		name := g.existing("build.gradle.kts", "build.gradle")[0]
+		p = project{kind: "Gradle project", build: "Gradle (" + name + ")"}
+		content, _ = g.readRootFile(name)
+	default:
+		return project{}, false
+	}
+	kotlin := g.exists("src/main/kotlin") || bytes.Contains(content, []byte("kotlin"))
+	if g.exists("src/main/java") || !kotlin {
+		p.languages = append(p.languages, "Java")
+	}
+	if kotlin {
+		p.languages = append(p.languages, "Kotlin")
+	}
+	if bytes.Contains(content, []byte("spring-boot")) {
+		p.frameworks = append(p.frameworks, "Spring Boot")
+	}
+	if bytes.Contains(content, []byte("com.android")) {
+		p.frameworks = append(p.frameworks, "Android")
+	}
+	return p, true
+}
+
+func detectRuby(g *Git2LLM) (project, bool) {
+	content, ok := g.readRootFile("Gemfile")
+	if !ok {
+		return project{}, false
+	}
+	p := project{kind: "Ruby project", languages: []string{"Ruby"}, build: "Bundler (Gemfile)"}
+	if g.exists("config/application.rb") || bytes.Contains(content, []byte(`"rails"`)) || bytes.Contains(content, []byte(`'rails'`)) {
+		p.frameworks = append(p.frameworks, "Rails")
+	}
+	p.entrypoints = g.existing("config.ru", "bin/rails", "Rakefile")
+	return p, true
+}
+
+func detectPHP(g *Git2LLM) (project, bool) {
+	content, ok := g.readRootFile("composer.json")
+	if !ok {
+		return project{}, false
+	}
+	var composer struct {
+		Name    string            `json:"name"`
+		Require map[string]string `json:"require"`
+	}
+	_ = json.Unmarshal(content, &composer) // The name is optional
+	p := project{kind: "PHP package", name: composer.Name, languages: []string{"PHP"}, build: "Composer (composer.json)"}
+	if _, ok := composer.Require["laravel/framework"]; ok || g.exists("artisan") {
+		p.frameworks = append(p.frameworks, "Laravel")
+	}
+	if _, ok := composer.Require["symfony/framework-bundle"]; ok {
+		p.frameworks = append(p.frameworks, "Symfony")
+	}
+	p.entrypoints = g.existing("public/index.php", "index.php", "artisan")
+	return p, true
+}
+
+func detectDotNet(g *Git2LLM) (project, bool) {
+	entries, err := g.fs.ReadDir(g.startPath)
+	if err != nil {
+		return project{}, false
+	}
+	p := project{kind: ".NET project", build: "dotnet"}
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".sln":
+			p.kind, p.name = ".NET solution", strings.TrimSuffix(e.Name(), ".sln")
+			p.build = "dotnet (" + e.Name() + ")"
+		case ".csproj":
+			p.languages = append(p.languages, "C#")
+			p.entrypoints = append(p.entrypoints, e.Name())
+		case ".fsproj":
+			p.languages = append(p.languages, "F#")
+			p.entrypoints = append(p.entrypoints, e.Name())
+		}
+	}
+	if p.build == "dotnet" && len(p.entrypoints) == 0 {
+		return project{}, false
+	}
+	if len(p.languages) == 0 {
+		p.languages = []string{"C#"}
+	}
+	return p, true
+}
+
+func detectNative(g *Git2LLM) (project, bool) {
+	for _, marker := range [][2]string{{"CMakeLists.txt", "CMake"}, {"meson.build", "Meson"}, {"configure.ac", "Autotools"}} {
+		if g.exists(marker[0]) {
+			return project{kind: marker[1] + " project", languages: []string{"C/C++"}, build: marker[1] + " (" + marker[0] + ")"}, true
+		}
+	}
+	return project{}, false
+}
+
+// detectMarker returns a detector for a project found by a single marker file.
+func detectMarker(marker, kind, language, build string) func(g *Git2LLM) (project, bool) {
+	return func(g *Git2LLM) (project, bool) {
+		if !g.exists(marker) {
+			return project{}, false
+		}
+		return project{kind: kind, languages: []string{language}, build: build + " (" + marker + ")"}, true
+	}
+}
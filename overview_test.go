@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMOverview(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n\tgoogle.golang.org/grpc v1.60.0 // indirect\n)\n",
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"cmd/tool/x.go":  "package main\n",
+		"web/.keep":      "",
+		"package.json":   `{"name": "web", "main": "./index.js", "scripts": {"build": "vite build", "lint": "eslint"}, "dependencies": {"react": "^18.0.0"}, "devDependencies": {"typescript": "^5.0.0"}}`,
+		"pnpm-lock.yaml": "lockfileVersion: '6.0'\n",
+		"pyproject.toml": "[project]\nname = \"tools\"\ndependencies = [\n  \"fastapi>=0.110\",\n  \"uvicorn\",\n]\n\n[project.scripts]\ntools = \"tools.cli:main\"\n\n[build-system]\nbuild-backend = \"hatchling.build\"\n",
+		"Cargo.toml":     "[package]\nname = \"core\"\n\n[dependencies]\ntokio = { version = \"1\", features = [\"full\"] }\n",
+		"src/main.rs":    "fn main() {}\n",
+		"Dockerfile":     "FROM scratch\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, []string{".none"}, nil, &output, false, false, false, nil, "", false, WithOverview(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	expect := `Project Overview:
+-----------------
+Go module example.com/app
+  Languages: Go 1.22
+  Build: go (go.mod)
+  Frameworks: Cobra
+  Entrypoints: main.go, cmd/tool/
+Node package web
+  Languages: TypeScript, JavaScript
+  Build: pnpm (pnpm-lock.yaml)
+  Frameworks: React
+  Entrypoints: index.js, npm run build
+Python project tools
+  Languages: Python
+  Build: hatch (pyproject.toml)
+  Frameworks: FastAPI
+  Entrypoints: tools
+Rust crate core
+  Languages: Rust
+  Build: cargo (Cargo.toml)
+  Frameworks: Tokio
+  Entrypoints: src/main.rs
+  Other build files: Dockerfile
+
+
+Directory Structure:
+`
+	if !strings.HasPrefix(output.String(), expect) {
+		t.Errorf("Expected output to start with:\n%s\ngot:\n%s", expect, output.String())
+	}
+
+	// Nothing is written without a project
+	output.Reset()
+	empty := t.TempDir()
+	git2llm, err = NewGit2LLM(empty, nil, nil, &output, false, false, false, nil, "", false, WithOverview(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if !strings.HasPrefix(output.String(), "Directory Structure:") {
+		t.Errorf("Expected no overview, got:\n%s", output.String())
+	}
+}
+
+func TestTomlTables(t *testing.T) {
+	tables := tomlTables([]byte("name = \"top\"\n[project]\nname = \"app\" \ndeps = [\n  \"a[x]>=1\",\n  \"b\",\n]\n[[bin]]\nname = \"tool\"\n"))
+	if tables[""]["name"] != `"top"` || tables["project"]["name"] != `"app"` || tables["bin"]["name"] != `"tool"` {
+		t.Errorf("Unexpected tables %v", tables)
+	}
+	if deps := tomlArray(tables["project"]["deps"]); len(deps) != 2 || deps[0] != "a[x]>=1" || deps[1] != "b" {
+		t.Errorf("Unexpected array %q", deps)
+	}
+}
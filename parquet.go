@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const parquetMagic = "PAR1"
+
+// maxParquetFooter limits the footer read into memory; real footers are far smaller.
+const maxParquetFooter = 64 << 20
+
+var (
+	parquetTypes      = []string{"boolean", "int32", "int64", "int96", "float", "double", "binary", "fixed_len_byte_array"}
+	parquetRepetition = []string{"required", "optional", "repeated"}
+	parquetConverted  = []string{"UTF8", "MAP", "MAP_KEY_VALUE", "LIST", "ENUM", "DECIMAL", "DATE", "TIME_MILLIS",
+		"TIME_MICROS", "TIMESTAMP_MILLIS", "TIMESTAMP_MICROS", "UINT_8", "UINT_16", "UINT_32", "UINT_64", "INT_8",
+		"INT_16", "INT_32", "INT_64", "JSON", "BSON", "INTERVAL"}
+)
+
+// parquetSchema describes the columns and the number of rows of a Parquet file,
+// read from the Thrift encoded metadata in its footer.
+func parquetSchema(r io.ReaderAt, size int64) (string, error) {
+	tail := make([]byte, 8)
+	if size < 12 {
+		return "", errors.New("not a Parquet file")
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return "", fmt.Errorf("read footer: %w", err)
+	}
+	if string(tail[4:]) != parquetMagic {
+		return "", errors.New("not a Parquet file")
+	}
+	length := int64(binary.LittleEndian.Uint32(tail))
+	if length > size-12 || length > maxParquetFooter {
+		return "", errors.New("invalid footer length")
+	}
+	footer := make([]byte, length)
+	if _, err := r.ReadAt(footer, size-8-length); err != nil {
+		return "", fmt.Errorf("read footer: %w", err)
+	}
+	d := &thriftDecoder{buf: footer}
+	metadata, err := d.readStruct()
+	if err != nil {
+		return "", fmt.Errorf("decode metadata: %w", err)
+	}
+
+	// FileMetaData: 2 schema, 3 num_rows, 4 row_groups, 6 created_by
+	var out strings.Builder
+	rowGroups, _ := metadata[4].([]any)
+	fmt.Fprintf(&out, "Parquet file, %d rows in %d row groups", toInt64(metadata[3]), len(rowGroups))
+	if createdBy, ok := metadata[6].([]byte); ok {
+		fmt.Fprintf(&out, ", created by %s", createdBy)
+	}
+	out.WriteString("\n")
+	elements, _ := metadata[2].([]any)
+	if _, err := writeParquetElements(&out, elements, 0); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// writeParquetElements writes the schema element at the start of elements and
+// its children, indented by depth, and returns the number of elements used.
+func writeParquetElements(out *strings.Builder, elements []any, depth int) (int, error) {
+	if len(elements) == 0 {
+		return 0, errors.New("truncated schema")
+	}
+	// SchemaElement: 1 type, 2 type_length, 3 repetition_type, 4 name, 5 num_children, 6 converted_type
+	element, _ := elements[0].(map[int16]any)
+	name, _ := element[4].([]byte)
+	line := string(name)
+	if depth == 0 {
+		line = "message " + line
+	} else {
+		kind := "group"
+		if t, ok := element[1]; ok {
+			kind = enumName(parquetTypes, t)
+			if length, ok := element[2]; ok {
+				kind += fmt.Sprintf("(%d)", toInt64(length))
+			}
+		}
+		line = kind + " " + line
+		if repetition, ok := element[3]; ok {
+			line = enumName(parquetRepetition, repetition) + " " + line
+		}
+		if converted, ok := element[6]; ok {
+			line += " (" + enumName(parquetConverted, converted) + ")"
+		}
+	}
+	out.WriteString(strings.Repeat("  ", depth) + line + "\n")
+
+	used := 1
+	for i := int64(0); i < toInt64(element[5]); i++ {
+		n, err := writeParquetElements(out, elements[used:], depth+1)
+		if err != nil {
+			return 0, err
+		}
+		used += n
+	}
+	return used, nil
+}
+
+func enumName(names []string, value any) string {
+	if v := toInt64(value); v >= 0 && v < int64(len(names)) {
+		return names[v]
+	}
+	return fmt.Sprintf("%d", toInt64(value))
+}
+
+func toInt64(value any) int64 {
+	v, _ := value.(int64)
+	return v
+}
+
+// thriftDecoder decodes the Thrift compact protocol into generic values:
+// structs become map[int16]any, lists and sets []any, integers int64,
+// strings and binaries []byte.
+type thriftDecoder struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+var errThriftTruncated = errors.New("truncated thrift data")
+
+func (d *thriftDecoder) readStruct() (map[int16]any, error) {
+	fields := make(map[int16]any)
+	var id int16
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return fields, nil // Stop
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := d.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(zigzag(v))
+		}
+		value, err := d.readValue(b & 0x0f)
+		if err != nil {
+			return nil, err
+		}
+		fields[id] = value
+	}
+}
+
+func (d *thriftDecoder) readValue(kind byte) (any, error) {
+	if d.depth++; d.depth > 64 {
+		return nil, errors.New("thrift data nested too deeply")
+	}
+	defer func() { d.depth-- }()
+	switch kind {
+	case 1:
+		return true, nil
+	case 2:
+		return false, nil
+	case 3:
+		b, err := d.readByte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		v, err := d.readVarint()
+		return zigzag(v), err
+	case 7:
+		if d.pos+8 > len(d.buf) {
+			return nil, errThriftTruncated
+		}
+		d.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos-8:])), nil
+	case 8:
+		n, err := d.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.buf)-d.pos) {
+			return nil, errThriftTruncated
+		}
+		d.pos += int(n)
+		return d.buf[d.pos-int(n) : d.pos], nil
+	case 9, 10:
+		return d.readList()
+	case 11:
+		return d.readMap()
+	case 12:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %d", kind)
+}
+
+func (d *thriftDecoder) readList() ([]any, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(b >> 4)
+	if size == 15 {
+		if size, err = d.readVarint(); err != nil {
+			return nil, err
+		}
+	}
+	if size > uint64(len(d.buf)-d.pos) {
+		return nil, errThriftTruncated // Every element takes at least one byte
+	}
+	kind := b & 0x0f
+	values := make([]any, 0, size)
+	for i := uint64(0); i < size; i++ {
+		value, err := d.readElement(kind)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// readMap skips a map; none are needed for the schema.
+func (d *thriftDecoder) readMap() (any, error) {
+	size, err := d.readVarint()
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	kinds, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < size; i++ {
+		if _, err := d.readElement(kinds >> 4); err != nil {
+			return nil, err
+		}
+		if _, err := d.readElement(kinds & 0x0f); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// readElement reads an element of a list or map. Booleans in these are a byte
+// each instead of being part of the type.
+func (d *thriftDecoder) readElement(kind byte) (any, error) {
+	if kind == 1 || kind == 2 {
+		b, err := d.readByte()
+		return b == 1, err
+	}
+	return d.readValue(kind)
+}
+
+func (d *thriftDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errThriftTruncated
+	}
+	d.pos++
+	return d.buf[d.pos-1], nil
+}
+
+func (d *thriftDecoder) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	d.pos += n
+	return v, nil
+}
+
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
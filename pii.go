@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// piiDetector finds one kind of personal data.
+type piiDetector struct {
+	kind  string // The name in placeholders, e.g. EMAIL for <EMAIL_1>
+	re    *regexp.Regexp
+	punct string // Characters continuing a match if followed by a letter or digit, e.g. "." for 1.2.3.4.5
+	valid func(match string) bool
+}
+
+// piiDetectors are tried in order; an earlier detector wins where matches overlap.
+var piiDetectors = []piiDetector{
+	{
+		kind: "EMAIL",
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	{
+		kind:  "IP",
+		re:    regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}`),
+		punct: ":.",
+		valid: func(match string) bool {
+			addr, err := netip.ParseAddr(match)
+			// Python slices such as [::2] are valid addresses too
+			digits := len(match) - strings.Count(match, ":")
+			return err == nil && addr.Is6() && digits >= 4 && !addr.IsLoopback() && !addr.IsUnspecified()
+		},
+	},
+	{
+		kind:  "IP",
+		re:    regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}`),
+		punct: ".",
+		valid: func(match string) bool {
+			addr, err := netip.ParseAddr(match)
+			return err == nil && !addr.IsLoopback() && !addr.IsUnspecified() && match != "255.255.255.255"
+		},
+	},
+	{
+		// US social security numbers and UK national insurance numbers
+		kind:  "NATIONAL_ID",
+		re:    regexp.MustCompile(`\d{3}-\d{2}-\d{4}|[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]`),
+		punct: "-",
+		valid: func(match string) bool {
+			if !strings.Contains(match, "-") {
+				return true
+			}
+			area, group, serial := match[:3], match[4:6], match[7:]
+			return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+		},
+	},
+	{
+		// International numbers with a +, and North American numbers with separators
+		kind:  "PHONE",
+		re:    regexp.MustCompile(`\+\d{1,3}[ .-]?(?:\(\d{1,4}\)[ .-]?)?\d{1,4}(?:[ .-]?\d{2,4}){1,4}|\(\d{3}\) ?\d{3}[ .-]\d{4}|\d{3}[ .-]\d{3}[ .-]\d{4}`),
+		punct: ".-",
+		valid: func(match string) bool {
+			digits := 0
+			for _, c := range match {
+				if c >= '0' && c <= '9' {
+					digits++
+				}
+			}
+			return digits >= 10 && digits <= 15
+		},
+	},
+}
+
+// piiMatch is personal data found at content[start:end].
+type piiMatch struct {
+	start, end int
+	kind       string
+}
+
+// findPII returns the personal data in content, in order and without overlaps.
+func findPII(content []byte) []piiMatch {
+	var matches []piiMatch
+	for _, d := range piiDetectors {
+		for _, loc := range d.re.FindAllIndex(content, -1) {
+			if !isDelimited(content, loc[0], loc[1], d.punct) {
+				continue
+			}
+			if d.valid != nil && !d.valid(string(content[loc[0]:loc[1]])) {
+				continue
+			}
+			matches = append(matches, piiMatch{start: loc[0], end: loc[1], kind: d.kind})
+		}
+	}
+	// Stable, so the earlier detector comes first where matches start together
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	kept := matches[:0]
+	end := 0
+	for _, m := range matches {
+		if m.start >= end {
+			kept = append(kept, m)
+			end = m.end
+		}
+	}
+	return kept
+}
+
+// isDelimited reports whether content[start:end] is not part of a longer word
+// or number: it is not next to a letter, digit or underscore, nor to one of
+// punct followed by one.
+func isDelimited(content []byte, start, end int, punct string) bool {
+	if start > 0 {
+		c := content[start-1]
+		if isWordByte(c) || strings.IndexByte(punct, c) >= 0 && start > 1 && isWordByte(content[start-2]) {
+			return false
+		}
+	}
+	if end < len(content) {
+		c := content[end]
+		if isWordByte(c) || strings.IndexByte(punct, c) >= 0 && end+1 < len(content) && isWordByte(content[end+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// describePII summarizes matches for the skip summary, e.g. "2 EMAIL, 1 IP".
+func describePII(matches []piiMatch) string {
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// piiMasker replaces personal data by typed placeholders such as <EMAIL_1>. A
+// value gets the same placeholder wherever it appears, so the model can still
+// tell that two files refer to the same person. It is shared by all roots.
+type piiMasker struct {
+	placeholders map[string]string // By kind and value
+	counts       map[string]int    // By kind
+}
+
+func newPIIMasker() *piiMasker {
+	return &piiMasker{placeholders: make(map[string]string), counts: make(map[string]int)}
+}
+
+// mask returns content with matches, as returned by findPII, replaced.
+func (p *piiMasker) mask(content []byte, matches []piiMatch) []byte {
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		key := m.kind + "\x00" + string(content[m.start:m.end])
+		placeholder, ok := p.placeholders[key]
+		if !ok {
+			p.counts[m.kind]++
+			placeholder = fmt.Sprintf("<%s_%d>", m.kind, p.counts[m.kind])
+			p.placeholders[key] = placeholder
+		}
+		out.Write(content[last:m.start])
+		out.WriteString(placeholder)
+		last = m.end
+	}
+	out.Write(content[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFindPII(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect []string // kind:value
+	}{
+		{"contact: jane.doe+test@example.co.uk.", []string{"EMAIL:jane.doe+test@example.co.uk"}},
+		{"<a href=\"mailto:ops@acme.io\">", []string{"EMAIL:ops@acme.io"}},
+		{"server 10.0.12.7:8080 and 127.0.0.1", []string{"IP:10.0.12.7"}},
+		{"version 1.2.3.4.5 or v1.2.3.4", nil},
+		{"addr fe80::1ff:fe23:4567:890a, slice [::2] and std::map", []string{"IP:fe80::1ff:fe23:4567:890a"}},
+		{"ssn: 123-45-6789, invalid 000-12-3456", []string{"NATIONAL_ID:123-45-6789"}},
+		{"NI number AB 12 34 56 C", []string{"NATIONAL_ID:AB 12 34 56 C"}},
+		{"call +1 555 123 4567 or (555) 123-4567 or 555.123.4567", []string{"PHONE:+1 555 123 4567", "PHONE:(555) 123-4567", "PHONE:555.123.4567"}},
+		{"date 2024-01-15, id 12345678901234, time 12:30:45", nil},
+	}
+	for _, tc := range testCases {
+		var got []string
+		for _, m := range findPII([]byte(tc.input)) {
+			got = append(got, m.kind+":"+tc.input[m.start:m.end])
+		}
+		if strings.Join(got, "|") != strings.Join(tc.expect, "|") {
+			t.Errorf("For %q, expected %q, got %q", tc.input, tc.expect, got)
+		}
+	}
+}
+
+func TestScanMaskPII(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{
+		"fixtures/users.csv": "name,email,ip\nJane,jane@example.com,203.0.113.9\nJohn,john@example.com,203.0.113.9\n",
+		"notes.md":           "Ask jane@example.com.\n",
+		"main.go":            "package main\n",
+	} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	scan := func(action PolicyAction) (string, *ScanResult) {
+		t.Helper()
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false,
+			WithPolicy(map[string]PolicyAction{CategoryPII: action}))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		result, err := Scan(git2llm)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		return output.String(), result
+	}
+
+	output, _ := scan(PolicyRedact)
+	for _, expected := range []string{
+		"File: fixtures/users.csv (Personal data masked)\n",
+		"Jane,<EMAIL_1>,<IP_1>\nJohn,<EMAIL_2>,<IP_1>\n",
+		"File: notes.md (Personal data masked)\n",
+		"Ask <EMAIL_1>.\n",
+		"File: main.go\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, output)
+		}
+	}
+
+	output, result := scan(PolicySkip)
+	if len(result.Skipped) != 2 || result.Skipped[0].Reason != SkipPII || result.Skipped[0].Detail != "2 EMAIL, 2 IP" {
+		t.Errorf("Expected the files with personal data to be skipped, got %+v", result.Skipped)
+	}
+	if strings.Contains(output, "example.com") || !strings.Contains(output, "File: notes.md (Personal data - skipped content)\n") {
+		t.Errorf("Expected the personal data to be left out. Result:\n%s", output)
+	}
+}
+
+func TestScanMaskPIIBeforeTransformers(t *testing.T) {
+	fsys := IOFS{FS: fstest.MapFS{
+		"users.csv": {Data: []byte("name,email\nJane,jane@example.com\n")},
+	}}
+	var seen []string
+	record := TransformerFunc(func(path string, content []byte) ([]byte, bool, error) {
+		seen = append(seen, string(content))
+		return content, true, nil
+	})
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false,
+		WithTransformers(record), WithPolicy(map[string]PolicyAction{CategoryPII: PolicyRedact}))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if len(seen) != 1 || strings.Contains(seen[0], "jane@example.com") || !strings.Contains(seen[0], "<EMAIL_1>") {
+		t.Errorf("Expected the transformer to get masked content, got %q", seen)
+	}
+	if !strings.Contains(output.String(), "File: users.csv (Personal data masked)\n") {
+		t.Errorf("Expected the file to be marked as masked. Result:\n%s", output.String())
+	}
+}
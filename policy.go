@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// exitPolicy is the exit status when files violate a fail policy.
+const exitPolicy = 5
+
+// PolicyAction is what happens to a file found by a detection category.
+type PolicyAction string
+
+const (
+	PolicySkip   PolicyAction = "skip"   // Leave the content out, the default
+	PolicyRedact PolicyAction = "redact" // Include the file without the sensitive part
+	PolicyWarn   PolicyAction = "warn"   // Include the file and log a warning; binaries are left out
+	PolicyFail   PolicyAction = "fail"   // Leave the content out and fail the scan with a *PolicyError
+)
+
+// Detection categories.
+const (
+	CategorySecrets   = "secrets"   // Files containing a private key
+	CategoryBinaries  = "binaries"  // Files containing NUL bytes
+	CategoryOversized = "oversized" // Files larger than WithMaxFileSize
+	CategoryPII       = "pii"       // Files containing personal data, only detected if in the policy
+)
+
+// policyActions are the actions every category supports.
+var policyActions = map[string][]PolicyAction{
+	CategorySecrets:   {PolicySkip, PolicyRedact, PolicyWarn, PolicyFail},
+	CategoryBinaries:  {PolicySkip, PolicyRedact, PolicyWarn, PolicyFail},
+	CategoryOversized: {PolicySkip, PolicyWarn, PolicyFail},
+	CategoryPII:       {PolicySkip, PolicyRedact, PolicyWarn, PolicyFail},
+}
+
+// optInCategories are only detected if their action is set explicitly, not by an ACTION alone.
+var optInCategories = map[string]bool{CategoryPII: true}
+
+// WithPolicy sets the action for files found by a detection category, e.g.
+// CategorySecrets: PolicyFail to fail CI runs on private keys. Categories not
+// in policy are skipped, except CategoryPII, which is not detected then.
+// PolicyRedact masks personal data with placeholders such as <EMAIL_1>.
+func WithPolicy(policy map[string]PolicyAction) Option {
+	return func(g *Git2LLM) {
+		g.policy = policy
+		g.pii = nil
+		if _, ok := policy[CategoryPII]; ok {
+			g.pii = newPIIMasker()
+		}
+	}
+}
+
+// WithMaxFileSize makes files larger than size bytes oversized. 0 means no limit.
+func WithMaxFileSize(size int64) Option {
+	return func(g *Git2LLM) {
+		g.maxFileSize = size
+	}
+}
+
+// PolicyViolation is a file found by a category whose action is PolicyFail.
+type PolicyViolation struct {
+	Path     string `json:"path"`
+	Category string `json:"category"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// PolicyError is returned by Scan if files violate a PolicyFail action. The
+// scan is completed without their contents.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	files := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		files[i] = fmt.Sprintf("%s (%s)", v.Path, v.Category)
+	}
+	return fmt.Sprintf("%d files violate the policy: %s", len(e.Violations), strings.Join(files, ", "))
+}
+
+// enforce returns the action for the file at relPath found by category,
+// logging a warning or recording a violation as the action requires.
+func (g *Git2LLM) enforce(relPath, category, detail string) PolicyAction {
+	action, ok := g.policy[category]
+	if !ok {
+		action = PolicySkip
+	}
+	switch action {
+	case PolicyWarn:
+		if category == CategoryBinaries {
+			g.logger.Warn("Skipping binary file", "path", relPath)
+		} else {
+			g.logger.Warn("Including file despite the policy", "path", relPath, "category", category, "detail", detail)
+		}
+	case PolicyFail:
+		g.violations = append(g.violations, PolicyViolation{Path: relPath, Category: category, Detail: detail})
+	}
+	return action
+}
+
+// policyError returns the violations of all roots as a *PolicyError, or nil if there are none.
+func policyError(roots []*Git2LLM) error {
+	var violations []PolicyViolation
+	for _, g := range roots {
+		violations = append(violations, g.violations...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &PolicyError{Violations: violations}
+}
+
+// parsePolicy parses the values of --policy: comma separated CATEGORY=ACTION
+// pairs, or an ACTION alone for every category supporting it except the
+// opt-in ones.
+func parsePolicy(values []string) (map[string]PolicyAction, error) {
+	policy := make(map[string]PolicyAction)
+	for _, value := range values {
+		for _, spec := range strings.Split(value, ",") {
+			spec = strings.TrimSpace(spec)
+			category, action, ok := strings.Cut(spec, "=")
+			if !ok {
+				found := false
+				for category, actions := range policyActions {
+					if !optInCategories[category] && slices.Contains(actions, PolicyAction(spec)) {
+						policy[category], found = PolicyAction(spec), true
+					}
+				}
+				if !found {
+					return nil, fmt.Errorf("invalid --policy %q: expected CATEGORY=ACTION or one of skip, redact, warn, fail", spec)
+				}
+				continue
+			}
+			actions, known := policyActions[category]
+			if !known {
+				categories := make([]string, 0, len(policyActions))
+				for name := range policyActions {
+					categories = append(categories, name)
+				}
+				sort.Strings(categories)
+				return nil, fmt.Errorf("invalid --policy %q: unknown category %s, expected one of %s", spec, category, strings.Join(categories, ", "))
+			}
+			if !slices.Contains(actions, PolicyAction(action)) {
+				names := make([]string, len(actions))
+				for i, a := range actions {
+					names[i] = string(a)
+				}
+				return nil, fmt.Errorf("invalid --policy %q: %s supports %s", spec, category, strings.Join(names, ", "))
+			}
+			policy[category] = PolicyAction(action)
+		}
+	}
+	return policy, nil
+}
+
+// privateKeyBlock matches a PEM private key, or its start if it is cut off.
+var privateKeyBlock = regexp.MustCompile(`(?s)(-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----)(.*?)(-----END [A-Z0-9 ]*PRIVATE KEY-----|\z)`)
+
+// redactPrivateKeys replaces the body of every private key in content.
+func redactPrivateKeys(content []byte) []byte {
+	return privateKeyBlock.ReplaceAllFunc(content, func(block []byte) []byte {
+		m := privateKeyBlock.FindSubmatch(block)
+		redacted := append([]byte{}, m[1]...)
+		redacted = append(redacted, "\n"+redactedValue+"\n"...)
+		return append(redacted, m[3]...)
+	})
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	_ "net/http/pprof" // Registers the /debug/pprof handlers
+)
+
+// servePprof serves the runtime profiles of net/http/pprof on addr in the
+// background for the rest of the run, e.g. for
+// go tool pprof http://localhost:6060/debug/pprof/heap during a large scan.
+func servePprof(addr string, logger *slog.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", addr, err)
+	}
+	logger.Info("Serving profiles", "url", "http://"+listener.Addr().String()+"/debug/pprof/")
+	go http.Serve(listener, nil)
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressInterval limits how often the progress line is redrawn.
+const progressInterval = 100 * time.Millisecond
+
+// progress renders a single status line for the content pass. A nil *progress is a no-op.
+type progress struct {
+	w          io.Writer
+	totalFiles int
+	totalBytes int64
+	files      int
+	bytes      int64
+	start      time.Time
+	lastDraw   time.Time
+	width      int
+}
+
+// WithProgress renders a progress line with files, bytes, tokens and ETA to w during the content pass.
+func WithProgress(w io.Writer) Option {
+	return func(g *Git2LLM) {
+		g.progressWriter = w
+	}
+}
+
+// isTerminal reports whether f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// newProgress returns a progress line for the given manifest, or nil if progress is disabled.
+func (g *Git2LLM) newProgress(files []manifestEntry) *progress {
+	if g.progressWriter == nil {
+		return nil
+	}
+	p := &progress{w: g.progressWriter, totalFiles: len(files), start: time.Now()}
+	for _, f := range files {
+		p.totalBytes += f.size
+	}
+	return p
+}
+
+// update records a processed file and redraws the line if enough time has passed.
+func (p *progress) update(size int64, tokens int) {
+	if p == nil {
+		return
+	}
+	p.files++
+	p.bytes += size
+	now := time.Now()
+	if p.files < p.totalFiles && now.Sub(p.lastDraw) < progressInterval {
+		return
+	}
+	p.lastDraw = now
+
+	line := fmt.Sprintf("[%d/%d files] %s/%s", p.files, p.totalFiles, formatBytes(p.bytes), formatBytes(p.totalBytes))
+	if tokens > 0 {
+		line += fmt.Sprintf(", %d tokens", tokens)
+	}
+	if eta, ok := p.eta(now); ok {
+		line += fmt.Sprintf(", ETA %s", eta)
+	}
+	p.draw(line)
+}
+
+// eta estimates the remaining time from the bytes processed so far.
+func (p *progress) eta(now time.Time) (time.Duration, bool) {
+	if p.bytes == 0 || p.totalBytes == 0 || p.bytes >= p.totalBytes {
+		return 0, false
+	}
+	elapsed := now.Sub(p.start)
+	remaining := time.Duration(float64(elapsed) * float64(p.totalBytes-p.bytes) / float64(p.bytes))
+	return remaining.Round(time.Second), true
+}
+
+func (p *progress) draw(line string) {
+	// Pad with spaces to overwrite a longer previous line
+	padding := ""
+	if len(line) < p.width {
+		padding = strings.Repeat(" ", p.width-len(line))
+	}
+	p.width = len(line)
+	fmt.Fprintf(p.w, "\r%s%s", line, padding)
+}
+
+// done clears the progress line.
+func (p *progress) done() {
+	if p == nil || p.width == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", p.width))
+}
+
+// formatBytes formats a byte count with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
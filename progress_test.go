@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		n      int64
+		expect string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tc := range testCases {
+		if got := formatBytes(tc.n); got != tc.expect {
+			t.Errorf("formatBytes(%d): expected %q, got %q", tc.n, tc.expect, got)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var output strings.Builder
+	g := &Git2LLM{progressWriter: &output}
+	p := g.newProgress([]manifestEntry{{size: 100}, {size: 300}})
+
+	p.update(100, 10)
+	if !strings.Contains(output.String(), "[1/2 files] 100 B/400 B, 10 tokens") {
+		t.Errorf("Unexpected progress line: %q", output.String())
+	}
+
+	p.start = time.Now().Add(-time.Second)
+	if eta, ok := p.eta(time.Now()); !ok || eta != 3*time.Second {
+		t.Errorf("Expected ETA of 3s, got %v (ok: %v)", eta, ok)
+	}
+
+	// The last file is always drawn, regardless of the redraw interval
+	p.update(300, 40)
+	if !strings.Contains(output.String(), "[2/2 files] 400 B/400 B, 40 tokens") {
+		t.Errorf("Unexpected progress line: %q", output.String())
+	}
+
+	p.done()
+	if !strings.HasSuffix(output.String(), "\r") {
+		t.Error("Expected the progress line to be cleared")
+	}
+
+	// Progress is disabled without a writer
+	disabled := (&Git2LLM{}).newProgress(nil)
+	disabled.update(1, 1)
+	disabled.done()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "<redacted>"
+
+// defaultRedactPatterns are the files whose values are redacted unless disabled.
+var defaultRedactPatterns = []string{".env*", "*.properties", "secrets.yaml", "secrets.yml"}
+
+// keyValueLine matches "key=value", "export key=value", "key: value" and "key value"
+// style assignments. The first group is everything up to the value.
+var keyValueLine = regexp.MustCompile(`^(\s*(?:export\s+)?[^\s=:#!]+(?:\s*[=:]\s*|\s+))(\S.*)$`)
+
+// blockScalar matches the YAML indicators for a multi-line value.
+var blockScalar = regexp.MustCompile(`^[|>][-+0-9]*$`)
+
+// shouldRedact reports whether the values of the file at relPath must be redacted.
+func (g *Git2LLM) shouldRedact(relPath string) bool {
+	name := path.Base(relPath)
+	for _, pattern := range g.redactPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// redact copies r to w line by line, keeping keys, comments and structure of
+// configuration files but replacing all values with a placeholder.
+func redact(w io.Writer, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	continuation := false // previous line ended with a backslash
+	blockIndent := -1     // indentation of the key owning a YAML block scalar
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, werr := io.WriteString(w, redactLine(line, &continuation, &blockIndent)); werr != nil {
+				return fmt.Errorf("error writing to output file: %w", werr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+	}
+}
+
+func redactLine(line string, continuation *bool, blockIndent *int) string {
+	body := strings.TrimRight(line, "\r\n")
+	ending := line[len(body):]
+	trimmed := strings.TrimSpace(body)
+	indent := len(body) - len(strings.TrimLeft(body, " \t"))
+
+	if *continuation {
+		*continuation = strings.HasSuffix(body, "\\")
+		return strings.Repeat(" ", indent) + redactedValue + ending
+	}
+	if *blockIndent >= 0 {
+		if trimmed == "" {
+			return line
+		}
+		if indent > *blockIndent {
+			return strings.Repeat(" ", indent) + redactedValue + ending
+		}
+		*blockIndent = -1
+	}
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") || trimmed == "---" {
+		return line
+	}
+
+	// YAML list items keep their dash, the item itself is treated like a line of its own
+	prefix := ""
+	rest := body
+	if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+		dash := strings.Index(body, "-")
+		itemStart := dash + 1 + len(body[dash+1:]) - len(strings.TrimLeft(body[dash+1:], " \t"))
+		prefix, rest = body[:itemStart], body[itemStart:]
+		if rest == "" {
+			return line
+		}
+	}
+
+	m := keyValueLine.FindStringSubmatch(rest)
+	if m == nil {
+		if prefix != "" {
+			return prefix + redactedValue + ending
+		}
+		return line // A bare key such as a YAML mapping header
+	}
+	value := m[2]
+	if blockScalar.MatchString(value) {
+		*blockIndent = indent
+		return line
+	}
+	*continuation = strings.HasSuffix(value, "\\")
+	return prefix + m[1] + redactedValue + ending
+}
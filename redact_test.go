@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "env file",
+			input:    "# Database\nDATABASE_URL=postgres://user:pass@db/app\nexport API_KEY=\"abc123\"\nEMPTY=\n",
+			expected: "# Database\nDATABASE_URL=<redacted>\nexport API_KEY=<redacted>\nEMPTY=\n",
+		},
+		{
+			name:     "properties with continuation",
+			input:    "db.user = admin\ndb.password: hunter2\nlong.value = first \\\n    second\nnext=1",
+			expected: "db.user = <redacted>\ndb.password: <redacted>\nlong.value = <redacted>\n    <redacted>\nnext=<redacted>",
+		},
+		{
+			name:     "yaml",
+			input:    "database:\n  user: admin\n  hosts:\n    - db1.internal\n    - name: db2\n  cert: |\n    -----BEGIN-----\n    abc\n  port: 5432\n",
+			expected: "database:\n  user: <redacted>\n  hosts:\n    - <redacted>\n    - name: <redacted>\n  cert: |\n    <redacted>\n    <redacted>\n  port: <redacted>\n",
+		},
+		{
+			name:     "crlf line endings",
+			input:    "A=1\r\nB=2\r\n",
+			expected: "A=<redacted>\r\nB=<redacted>\r\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var output strings.Builder
+			if err := redact(&output, strings.NewReader(tc.input)); err != nil {
+				t.Fatalf("redact failed: %v", err)
+			}
+			if output.String() != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestShouldRedact(t *testing.T) {
+	git2llm := &Git2LLM{redactPatterns: defaultRedactPatterns}
+	testCases := []struct {
+		path   string
+		expect bool
+	}{
+		{".env", true},
+		{"deploy/.env.production", true},
+		{"src/main/resources/application.properties", true},
+		{"config/secrets.yaml", true},
+		{"config/values.yaml", false},
+		{"main.go", false},
+	}
+	for _, tc := range testCases {
+		if got := git2llm.shouldRedact(tc.path); got != tc.expect {
+			t.Errorf("For '%s', expected %v, got %v", tc.path, tc.expect, got)
+		}
+	}
+
+	disabled := &Git2LLM{}
+	if disabled.shouldRedact(".env") {
+		t.Error("Expected no redaction without patterns")
+	}
+}
+
+func TestGit2LLMRedactedFile(t *testing.T) {
+	mockFS := &MockFS{FileContentMap: map[string]string{"app.properties": "password=hunter2\n"}}
+	var output strings.Builder
+	git2llm := &Git2LLM{fs: mockFS, outputWriter: &output, redactPatterns: defaultRedactPatterns, logger: newLogger(io.Discard, slog.LevelInfo, false)}
+
+	if err := git2llm.processFile("app.properties", "app.properties"); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	result := output.String()
+	if strings.Contains(result, "hunter2") {
+		t.Errorf("Expected value to be redacted. Output:\n%s", result)
+	}
+	if !strings.Contains(result, "File: app.properties (Values redacted)") || !strings.Contains(result, "password=<redacted>") {
+		t.Errorf("Expected redacted key in output. Output:\n%s", result)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+// ScanResult describes the output of a scan for programmatic consumers, e.g. to
+// render the files differently or to budget tokens.
+type ScanResult struct {
+	Files   []FileResult  // Files whose content was written, in output order
+	Skipped []SkippedFile // Files left out of the output, in scan order
+	Tree    string        // The directory trees, one per root separated by an empty line; empty without a tree
+	Tokens  int           // Tokens in the output, 0 unless tokens are counted
+}
+
+// FileResult describes a file whose content was written.
+type FileResult struct {
+	Path   string      `json:"path"`             // As shown in the output
+	Size   int64       `json:"size"`             // Size on disk in bytes
+	Lines  int         `json:"lines"`            // Lines written, after transformation and redaction
+	Tokens int         `json:"tokens"`           // 0 unless tokens are counted
+	SHA256 string      `json:"sha256,omitempty"` // Hex SHA-256 of the file on disk, with WithFileHashes
+	Commit *CommitInfo `json:"commit,omitempty"` // Last commit of the file, with WithFileGitInfo
+}
+
+func newScanResult(roots []*Git2LLM, tree string, tokens int) *ScanResult {
+	r := &ScanResult{Skipped: skippedFiles(roots), Tree: tree, Tokens: tokens}
+	for _, g := range roots {
+		for _, f := range g.results {
+			r.Files = append(r.Files, *f)
+		}
+	}
+	return r
+}
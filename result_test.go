@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanResult(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"pkg/util.go": "package pkg\n",
+		"image.bin":   "\x00\x01\x02",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, io.Discard, false, false, true, nil, "estimate", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %+v", result.Files)
+	}
+	main := result.Files[0]
+	if main.Path != "main.go" || main.Size != int64(len(testFiles["main.go"])) || main.Lines != 3 || main.Tokens == 0 {
+		t.Errorf("Unexpected result for main.go: %+v", main)
+	}
+	if result.Files[1].Path != "pkg/util.go" {
+		t.Errorf("Expected pkg/util.go second, got %s", result.Files[1].Path)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != "image.bin" || result.Skipped[0].Reason != SkipBinary {
+		t.Errorf("Expected image.bin to be skipped as binary, got %+v", result.Skipped)
+	}
+	if !strings.Contains(result.Tree, "pkg/") || !strings.Contains(result.Tree, "main.go") {
+		t.Errorf("Expected the tree in the result, got:\n%s", result.Tree)
+	}
+	sum := 0
+	for _, f := range result.Files {
+		sum += f.Tokens
+	}
+	if result.Tokens < sum {
+		t.Errorf("Expected the total of %d tokens to include the %d tokens of the files", result.Tokens, sum)
+	}
+}
+
+func TestScanWithoutTree(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, true, nil, "estimate", false, WithTree(false))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if strings.Contains(output.String(), "Directory Structure:") || result.Tree != "" {
+		t.Errorf("Expected no tree. Result:\n%s", output.String())
+	}
+	if !strings.Contains(output.String(), "File Contents:") || len(result.Files) != 1 {
+		t.Errorf("Expected the file contents. Result:\n%s", output.String())
+	}
+	if result.Tokens != result.Files[0].Tokens {
+		t.Errorf("Expected only the file to be counted, got %d tokens for %d in the file", result.Tokens, result.Files[0].Tokens)
+	}
+}
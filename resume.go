@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// resumeSuffix is appended to the path of the output file to name the state
+// file of --resume.
+const resumeSuffix = ".resume"
+
+// WithCheckpoint calls fn with the path of every file of the content section,
+// as shown in the output, once its stanza was written or it was left out.
+func WithCheckpoint(fn func(path string)) Option {
+	return func(g *Git2LLM) {
+		g.checkpoint = fn
+	}
+}
+
+// WithResumeAfter continues a scan whose output was written up to the file at
+// path, as shown in the output: the parts before the file contents and the
+// files up to and including path are not written again. The scan fails if
+// path is not part of it anymore.
+func WithResumeAfter(path string) Option {
+	return func(g *Git2LLM) {
+		g.resumeAfter = path
+	}
+}
+
+// resumeSkip tracks whether a resumed scan has passed the file it resumes
+// after, across all roots.
+type resumeSkip struct {
+	after  string
+	passed bool
+}
+
+// resumePoint finds the file to resume roots after: it returns an error if
+// none of the roots includes it, so a changed tree isn't silently skipped.
+func resumePoint(roots []*Git2LLM) error {
+	after := roots[0].resumeAfter
+	for _, g := range roots {
+		files, err := g.contentFiles()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if g.displayPath(f.relPath) == after {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("can't resume after %s: it is not part of the scan anymore", after)
+}
+
+// countingWriter counts the bytes written to w, starting at n.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// resumeState is the state file of --resume next to the output file. Its
+// first line records the arguments of the scan, every further line a
+// checkpoint: a file and the size of the output once its stanza was written.
+// Checkpoints may get ahead of the output file while it is buffered, so a
+// scan resumes at the last checkpoint the file has reached.
+type resumeState struct {
+	path string
+	file *os.File
+}
+
+type resumeArgs struct {
+	Args []string `json:"args"`
+}
+
+type resumeCheckpoint struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+// loadResumeState reads the state file at path written by a scan with args
+// and returns the checkpoint to resume an output of size bytes at. ok is
+// false if there is no state file or the output didn't get to the first file.
+func loadResumeState(path string, args []string, size int64) (resumeCheckpoint, bool, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return resumeCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return resumeCheckpoint{}, false, fmt.Errorf("error reading resume state: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	if !scanner.Scan() {
+		return resumeCheckpoint{}, false, nil
+	}
+	var header resumeArgs
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return resumeCheckpoint{}, false, fmt.Errorf("invalid resume state %s: %w", path, err)
+	}
+	if !slices.Equal(header.Args, args) {
+		return resumeCheckpoint{}, false, fmt.Errorf("the interrupted scan had other arguments, remove %s to start over", path)
+	}
+	var last resumeCheckpoint
+	found := false
+	for scanner.Scan() {
+		var c resumeCheckpoint
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			break // The last line may be cut off
+		}
+		if c.Offset <= size {
+			last, found = c, true
+		}
+	}
+	return last, found, nil
+}
+
+// createResumeState writes a new state file at path for a scan with args,
+// starting with the checkpoint at if it is not nil.
+func createResumeState(path string, args []string, at *resumeCheckpoint) (*resumeState, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.Create: %w", err)
+	}
+	s := &resumeState{path: path, file: file}
+	if err := s.writeLine(resumeArgs{Args: args}); err != nil {
+		return nil, err
+	}
+	if at != nil {
+		if err := s.writeLine(at); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *resumeState) writeLine(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		s.file.Close()
+		return fmt.Errorf("error writing resume state: %w", err)
+	}
+	return nil
+}
+
+// checkpoint records that the output is complete up to offset bytes after the
+// file at path. Errors are ignored: at worst, a scan resumes further back.
+func (s *resumeState) checkpoint(path string, offset int64) {
+	s.writeLine(resumeCheckpoint{Path: path, Offset: offset})
+}
+
+// remove deletes the state file once the scan is complete.
+func (s *resumeState) remove() error {
+	s.file.Close()
+	if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("error removing resume state: %w", err)
+	}
+	return nil
+}
+
+// resumeOutput opens the output file at path to continue it after offset
+// bytes, dropping whatever was written after them.
+func resumeOutput(path string, offset int64) (*outputFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile: %w", err)
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error truncating output file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error seeking output file: %w", err)
+	}
+	o := &outputFile{file: file, buf: bufio.NewWriterSize(file, 64*1024)}
+	o.w = o.buf
+	return o, nil
+}
+
+// startResumable opens the output file at path for a scan with args and
+// --resume. If the state file next to it records an interrupted scan with the
+// same args, the output is continued from its last checkpoint, which is
+// returned; otherwise a new output is started and the checkpoint is empty.
+func startResumable(path string, args []string) (*outputFile, *resumeState, resumeCheckpoint, error) {
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	statePath := path + resumeSuffix
+	at, found, err := loadResumeState(statePath, args, size)
+	if err != nil {
+		return nil, nil, at, err
+	}
+	var out *outputFile
+	if found {
+		out, err = resumeOutput(path, at.Offset)
+	} else {
+		out, err = createOutput(path, "")
+	}
+	if err != nil {
+		return nil, nil, at, err
+	}
+	var from *resumeCheckpoint
+	if found {
+		from = &at
+	}
+	state, err := createResumeState(statePath, args, from)
+	if err != nil {
+		out.Close()
+		return nil, nil, at, err
+	}
+	return out, state, at, nil
+}
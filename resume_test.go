@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type resumeTestCheckpoint struct {
+	path   string
+	offset int64
+}
+
+func TestGit2LLMResume(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("package "+strings.TrimSuffix(name, ".go")), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+	scan := func(out *strings.Builder, offset int64, opts ...Option) []resumeTestCheckpoint {
+		var checkpoints []resumeTestCheckpoint
+		counter := &countingWriter{w: out, n: offset}
+		opts = append(opts, WithCheckpoint(func(path string) {
+			checkpoints = append(checkpoints, resumeTestCheckpoint{path, counter.n})
+		}))
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, counter, false, false, false, nil, "", false, opts...)
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		return checkpoints
+	}
+
+	var full strings.Builder
+	checkpoints := scan(&full, 0)
+	if len(checkpoints) != 4 || checkpoints[1].path != "b.go" {
+		t.Fatalf("Expected a checkpoint per file, got %v", checkpoints)
+	}
+	if !strings.HasSuffix(full.String()[:checkpoints[1].offset], "package b\n\n") {
+		t.Errorf("Expected the checkpoint of b.go at the end of its stanza. Result:\n%s", full.String())
+	}
+
+	// Continue the output after b.go
+	var resumed strings.Builder
+	resumed.WriteString(full.String()[:checkpoints[1].offset])
+	rest := scan(&resumed, checkpoints[1].offset, WithResumeAfter("b.go"))
+	if resumed.String() != full.String() {
+		t.Errorf("Expected the resumed output to equal the full one. Got:\n%s", resumed.String())
+	}
+	if len(rest) != 2 || rest[1] != checkpoints[3] {
+		t.Errorf("Expected the checkpoints of c.go and d.go, got %v", rest)
+	}
+
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &strings.Builder{}, false, false, false, nil, "", false, WithResumeAfter("gone.go"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err == nil || !strings.Contains(err.Error(), "not part of the scan") {
+		t.Errorf("Expected an error resuming after a missing file, got %v", err)
+	}
+}
+
+func TestResumeState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pack.txt"+resumeSuffix)
+	args := []string{"--resume", "-o", "pack.txt", "."}
+	if _, found, err := loadResumeState(path, args, 100); found || err != nil {
+		t.Fatalf("Expected no state, got %v, %v", found, err)
+	}
+
+	state, err := createResumeState(path, args, nil)
+	if err != nil {
+		t.Fatalf("createResumeState failed: %v", err)
+	}
+	state.checkpoint("a.go", 50)
+	state.checkpoint("b.go", 90)
+	state.checkpoint("c.go", 130)
+
+	// The output only reached 100 bytes before the scan was interrupted
+	at, found, err := loadResumeState(path, args, 100)
+	if err != nil || !found || at != (resumeCheckpoint{Path: "b.go", Offset: 90}) {
+		t.Errorf("Expected to resume after b.go, got %v, %v, %v", at, found, err)
+	}
+	if _, found, _ := loadResumeState(path, args, 10); found {
+		t.Error("Expected no checkpoint before the first file")
+	}
+	if _, _, err := loadResumeState(path, []string{"-o", "pack.txt", "."}, 100); err == nil {
+		t.Error("Expected an error for other arguments")
+	}
+
+	if err := state.remove(); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the state file to be removed, got %v", err)
+	}
+}
+
+func TestStartResumable(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("package "+strings.TrimSuffix(name, ".go")+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "pack.txt")
+	args := []string{"--resume", "-o", path, tempDir}
+
+	// run scans into the output file at path like main does with --resume,
+	// interrupting the scan once the file stopAfter was written.
+	run := func(stopAfter string) (resumeCheckpoint, map[string]int64, error) {
+		t.Helper()
+		out, state, at, err := startResumable(path, args)
+		if err != nil {
+			t.Fatalf("startResumable failed: %v", err)
+		}
+		counter := &countingWriter{w: out, n: at.Offset}
+		offsets := make(map[string]int64)
+		stop := make(chan struct{})
+		opts := []Option{WithInterrupt(stop), WithCheckpoint(func(p string) {
+			state.checkpoint(p, counter.n)
+			offsets[p] = counter.n
+			if p == stopAfter {
+				close(stop)
+			}
+		})}
+		if at.Path != "" {
+			opts = append(opts, WithResumeAfter(at.Path))
+		}
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, counter, false, false, false, nil, "", false, opts...)
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		err = ScanRepositories(git2llm)
+		if closeErr := out.Close(); closeErr != nil {
+			t.Fatalf("Close failed: %v", closeErr)
+		}
+		if err == nil {
+			if err := state.remove(); err != nil {
+				t.Fatalf("remove failed: %v", err)
+			}
+		}
+		return at, offsets, err
+	}
+
+	var full strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &full, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := ScanRepositories(git2llm); err != nil {
+		t.Fatalf("ScanRepositories failed: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		damage func(offsets map[string]int64) error
+		after  string
+	}{
+		// The buffered end of the output was lost, cutting off the stanza of c.go
+		{"lost end", func(offsets map[string]int64) error { return os.Truncate(path, offsets["c.go"]-5) }, "b.go"},
+		// More was written after the last checkpoint than the rest of the scan writes
+		{"trailing garbage", func(map[string]int64) error {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.WriteString(strings.Repeat("garbage\n", 1000))
+			return err
+		}, "c.go"},
+	}
+	for _, tc := range testCases {
+		at, offsets, err := run("c.go")
+		if !errors.Is(err, ErrInterrupted) || at.Path != "" {
+			t.Fatalf("%s: expected a new scan to be interrupted, got %v after %q", tc.name, err, at.Path)
+		}
+		if err := tc.damage(offsets); err != nil {
+			t.Fatalf("%s: failed to damage the output: %v", tc.name, err)
+		}
+
+		at, _, err = run("")
+		if err != nil {
+			t.Fatalf("%s: resumed scan failed: %v", tc.name, err)
+		}
+		if at != (resumeCheckpoint{Path: tc.after, Offset: offsets[tc.after]}) {
+			t.Errorf("%s: expected to resume after %s at %d, got %+v", tc.name, tc.after, offsets[tc.after], at)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read the output: %v", err)
+		}
+		if string(content) != full.String() {
+			t.Errorf("%s: expected the resumed output to equal an uninterrupted one. Got:\n%s\nExpected:\n%s", tc.name, content, full.String())
+		}
+		if _, err := os.Stat(path + resumeSuffix); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the state file to be removed, got %v", tc.name, err)
+		}
+	}
+}
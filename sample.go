@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// WithSampleData cuts CSV and TSV files down to the header and the first rows
+// rows, followed by a note with the total number of rows. The file is read as
+// a stream: only the sampled rows are kept in memory, the others are counted.
+// 0 includes data files in full.
+func WithSampleData(rows int) Option {
+	return func(g *Git2LLM) {
+		g.sampleRows = rows
+	}
+}
+
+// dataComma returns the field separator of the data file at relPath if it is
+// sampled, or 0.
+func (g *Git2LLM) dataComma(relPath string) rune {
+	if g.sampleRows <= 0 {
+		return 0
+	}
+	switch strings.ToLower(path.Ext(relPath)) {
+	case ".csv":
+		return ','
+	case ".tsv":
+		return '\t'
+	}
+	return 0
+}
+
+// sampleData reads CSV or TSV data from r and returns the header and the first
+// rows rows as they are in the file, followed by a note with the total number
+// of rows if there are more. Quotes are parsed leniently, so a quote left open
+// runs to the end of the file; any other error is returned, the content is
+// never included unsampled.
+func sampleData(r io.Reader, comma rune, rows int) ([]byte, error) {
+	kept := &sampleBuffer{}
+	cr := csv.NewReader(io.TeeReader(r, kept))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+
+	var end int64
+	total := -1 // The header is not a row
+	for {
+		_, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		total++
+		if total <= rows {
+			end = cr.InputOffset()
+		} else {
+			kept.full = true
+		}
+	}
+	if total <= rows {
+		return kept.Bytes(), nil
+	}
+	out := bytes.NewBuffer(kept.Bytes()[:end:end])
+	if end > 0 && out.Bytes()[end-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	fmt.Fprintf(out, "[Sampled: the header and the first %d of %d rows; the other rows are not included]\n", rows, total)
+	return out.Bytes(), nil
+}
+
+// sampleBuffer keeps what is written to it until it is full. The CSV reader
+// reads ahead, so it holds somewhat more than the sampled rows.
+type sampleBuffer struct {
+	bytes.Buffer
+	full bool
+}
+
+func (b *sampleBuffer) Write(p []byte) (int, error) {
+	if b.full {
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
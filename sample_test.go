@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSampleData(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		comma    rune
+		expected string
+	}{
+		{
+			name:     "csv",
+			content:  "id,name\n1,alice\n2,\"bob\nsmith\"\n3,carol\n4,dave\n",
+			comma:    ',',
+			expected: "id,name\n1,alice\n2,\"bob\nsmith\"\n[Sampled: the header and the first 2 of 4 rows; the other rows are not included]\n",
+		},
+		{
+			name:     "tsv",
+			content:  "id\tname\n1\talice\n2\tbob\n3\tcarol",
+			comma:    '\t',
+			expected: "id\tname\n1\talice\n2\tbob\n[Sampled: the header and the first 2 of 3 rows; the other rows are not included]\n",
+		},
+		{
+			// Files with no more rows than the sample are unchanged
+			name:     "small",
+			content:  "id,name\n1,alice\n2,bob\n",
+			comma:    ',',
+			expected: "id,name\n1,alice\n2,bob\n",
+		},
+		{
+			// A quote left open runs to the end of the file, which is one row
+			name:     "open quote",
+			content:  "id,name\n1,alice\n2,bob\n3,\"carol\n4,dave\n",
+			comma:    ',',
+			expected: "id,name\n1,alice\n2,bob\n[Sampled: the header and the first 2 of 3 rows; the other rows are not included]\n",
+		},
+	}
+	for _, tc := range testCases {
+		// One byte at a time, as the rows are streamed from the file
+		content, err := sampleData(iotest.OneByteReader(strings.NewReader(tc.content)), tc.comma, 2)
+		if err != nil {
+			t.Errorf("%s: sampleData failed: %v", tc.name, err)
+			continue
+		}
+		if string(content) != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, content)
+		}
+	}
+
+	if _, err := sampleData(iotest.ErrReader(io.ErrUnexpectedEOF), ',', 2); err == nil {
+		t.Errorf("Expected a read error to be returned")
+	}
+}
+
+func TestSampleDataLarge(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,name,value\n")
+	for i := 0; i < 100000; i++ {
+		b.WriteString("1,alice,42\n")
+	}
+	content, err := sampleData(strings.NewReader(b.String()), ',', 3)
+	if err != nil {
+		t.Fatalf("sampleData failed: %v", err)
+	}
+	expected := "id,name,value\n1,alice,42\n1,alice,42\n1,alice,42\n[Sampled: the header and the first 3 of 100000 rows; the other rows are not included]\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+}
+
+func TestGit2LLMSampleData(t *testing.T) {
+	mockFS := &MockFS{FileContentMap: map[string]string{
+		"users.csv": "id,name\n1,alice\n2,bob\n3,carol\n",
+		"notes.txt": "a,b\n1,2\n3,4\n5,6\n",
+	}}
+	var output strings.Builder
+	git2llm := &Git2LLM{fs: mockFS, outputWriter: &output, logger: newLogger(io.Discard, slog.LevelInfo, false)}
+	WithSampleData(1)(git2llm)
+	for _, name := range []string{"users.csv", "notes.txt"} {
+		if err := git2llm.processFile(name, name); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+	if !strings.Contains(output.String(), "id,name\n1,alice\n[Sampled: the header and the first 1 of 3 rows") {
+		t.Errorf("Expected users.csv to be sampled, got:\n%s", output.String())
+	}
+	if !strings.Contains(output.String(), "a,b\n1,2\n3,4\n5,6\n") {
+		t.Errorf("Expected notes.txt in full, got:\n%s", output.String())
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithSanitize replaces invalid UTF-8 in file contents and removes ANSI escape
+// sequences and control characters other than newline and tab. It is enabled by default.
+func WithSanitize(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.sanitize = enabled
+	}
+}
+
+type sanitizerState int
+
+const (
+	stateText sanitizerState = iota
+	stateEscape
+	stateCSI    // Control sequence: ESC [ parameters final
+	stateString // OSC, DCS and the like: ESC ] ... terminated by BEL or ESC \
+	stateStringEscape
+)
+
+// sanitizer is an io.Writer passing text on to w with invalid UTF-8 replaced by
+// U+FFFD, and ANSI escape sequences and control characters removed. Newlines and
+// tabs are kept, and so are carriage returns before a newline. Sequences may be
+// split across writes; Flush must be called after the last write.
+type sanitizer struct {
+	w         io.Writer
+	state     sanitizerState
+	partial   []byte // Incomplete UTF-8 sequence at the end of the last write
+	pendingCR bool
+	out       []byte
+	removed   int // Bytes removed or replaced
+}
+
+func newSanitizer(w io.Writer) *sanitizer {
+	return &sanitizer{w: w}
+}
+
+func (s *sanitizer) Write(p []byte) (int, error) {
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+	s.out = s.out[:0]
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch s.state {
+		case stateEscape:
+			switch {
+			case b == '[':
+				s.state = stateCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				s.state = stateString
+			case b >= 0x20 && b <= 0x2f:
+				// Intermediate byte, the sequence continues
+			default:
+				s.state = stateText
+			}
+			s.removed++
+			i++
+			continue
+		case stateCSI:
+			if b < 0x20 || b > 0x7e {
+				s.state = stateText // Malformed, the byte is text again
+				continue
+			}
+			if b >= 0x40 {
+				s.state = stateText
+			}
+			s.removed++
+			i++
+			continue
+		case stateString, stateStringEscape:
+			switch {
+			case b == '\n':
+				s.state = stateText // Unterminated; don't swallow the rest of the file
+				continue
+			case b == 0x07 || (s.state == stateStringEscape && b == '\\'):
+				s.state = stateText
+			case b == 0x1b:
+				s.state = stateStringEscape
+			default:
+				s.state = stateString
+			}
+			s.removed++
+			i++
+			continue
+		}
+
+		if s.pendingCR {
+			s.pendingCR = false
+			if b == '\n' {
+				s.out = append(s.out, '\r')
+			} else {
+				s.removed++
+			}
+		}
+		if b < utf8.RuneSelf {
+			switch {
+			case b == '\n' || b == '\t' || (b >= 0x20 && b != 0x7f):
+				s.out = append(s.out, b)
+			case b == '\r':
+				s.pendingCR = true
+			case b == 0x1b:
+				s.state = stateEscape
+				s.removed++
+			default:
+				s.removed++
+			}
+			i++
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			s.partial = append([]byte(nil), data[i:]...)
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			s.out = utf8.AppendRune(s.out, utf8.RuneError)
+			s.removed++
+		case r == 0x9b: // C1 control sequence introducer
+			s.state = stateCSI
+			s.removed += size
+		case unicode.IsControl(r):
+			s.removed += size
+		default:
+			s.out = append(s.out, data[i:i+size]...)
+		}
+		i += size
+	}
+	if len(s.out) > 0 {
+		if _, err := s.w.Write(s.out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes what is left of an incomplete UTF-8 sequence as U+FFFD and
+// resets the state for the next file.
+func (s *sanitizer) Flush() error {
+	s.state = stateText
+	if s.pendingCR {
+		s.pendingCR = false
+		s.removed++
+	}
+	if len(s.partial) == 0 {
+		return nil
+	}
+	s.removed += len(s.partial)
+	s.partial = nil
+	_, err := s.w.Write([]byte(string(utf8.RuneError)))
+	return err
+}
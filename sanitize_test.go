@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizer(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"plain", "func main() {\n\tfmt.Println(\"héllo, 世界\")\n}\n", "func main() {\n\tfmt.Println(\"héllo, 世界\")\n}\n"},
+		{"colors", "\x1b[1;32mPASS\x1b[0m ok\n", "PASS ok\n"},
+		{"cursor", "50%\x1b[2K\x1b[1G100%\n", "50%100%\n"},
+		{"title", "\x1b]0;make test\x07done\n\x1b]8;;http://x\x1b\\link\n", "done\nlink\n"},
+		{"charset", "\x1b(Bascii\n", "ascii\n"},
+		{"invalid utf8", "caf\xe9 \xff\n", "caf� �\n"},
+		{"controls", "a\x00b\x08c\x7fd\u0085e\n", "abcde\n"},
+		{"crlf", "line\r\nprogress\r10%\r100%\r\n", "line\r\nprogress10%100%\r\n"},
+		{"unterminated osc", "\x1b]0;title\nnext\n", "\nnext\n"},
+		{"truncated rune", "end \xe4\xb8", "end �"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Write one byte at a time, so sequences are split across writes
+			var out bytes.Buffer
+			s := newSanitizer(&out)
+			for i := 0; i < len(tc.input); i++ {
+				if _, err := s.Write([]byte{tc.input[i]}); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if err := s.Flush(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+			if out.String() != tc.expect {
+				t.Errorf("Expected %q, got %q", tc.expect, out.String())
+			}
+
+			out.Reset()
+			s = newSanitizer(&out)
+			if _, err := io.Copy(s, strings.NewReader(tc.input)); err != nil {
+				t.Fatalf("Copy failed: %v", err)
+			}
+			if err := s.Flush(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+			if out.String() != tc.expect {
+				t.Errorf("Expected %q in one write, got %q", tc.expect, out.String())
+			}
+		})
+	}
+}
+
+func TestGit2LLMSanitize(t *testing.T) {
+	tempDir := t.TempDir()
+	capture := "\x1b[31mFAIL\x1b[0m TestX\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "capture.txt"), []byte(capture), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	for _, sanitize := range []bool{true, false} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithSanitize(sanitize))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		expect := "Content of capture.txt:\nFAIL TestX\n"
+		if !sanitize {
+			expect = "Content of capture.txt:\n" + capture
+		}
+		if !strings.Contains(output.String(), expect) {
+			t.Errorf("With sanitize %v, expected %q in output:\n%s", sanitize, expect, output.String())
+		}
+	}
+}
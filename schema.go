@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// schemaReaders describe data files by their extension.
+var schemaReaders = map[string]func(r io.ReaderAt, size int64) (string, error){
+	".sqlite":  sqliteSchema,
+	".sqlite3": sqliteSchema,
+	".db":      sqliteSchema,
+	".parquet": parquetSchema,
+}
+
+// WithDataSchemas emits the schema of SQLite databases and Parquet files
+// (tables, columns, types and row counts) instead of skipping them as binary.
+func WithDataSchemas(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.dataSchemas = enabled
+	}
+}
+
+// dataSchema returns the schema of the data file at filePath. It reports false
+// for other files and for data files that can't be read.
+func (g *Git2LLM) dataSchema(filePath string) (string, bool) {
+	read, ok := schemaReaders[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return "", false
+	}
+	file, err := g.fs.Open(filePath)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	// Local files are read only where needed; other file systems have the content in memory anyway
+	var r io.ReaderAt
+	var size int64
+	if ra, ok := file.(io.ReaderAt); ok {
+		info, err := g.fs.Stat(filePath)
+		if err != nil {
+			return "", false
+		}
+		r, size = ra, info.Size()
+	} else {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", false
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+	schema, err := read(r, size)
+	if err != nil {
+		g.logger.Debug("Could not read schema", "path", filePath, "error", err)
+		return "", false
+	}
+	return schema, true
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readSchema(t *testing.T, path string, read func(f *os.File, size int64) (string, error)) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	schema, err := read(f, info.Size())
+	if err != nil {
+		t.Fatalf("Failed to read the schema of %s: %v", path, err)
+	}
+	return schema
+}
+
+func TestSQLiteSchema(t *testing.T) {
+	schema := readSchema(t, "testdata/app.sqlite", func(f *os.File, size int64) (string, error) {
+		return sqliteSchema(f, size)
+	})
+	for _, expected := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT);\n-- users: 500 rows\n",
+		"CREATE TABLE tags (name TEXT PRIMARY KEY, description TEXT) WITHOUT ROWID;\n-- tags: 300 rows\n",
+		"CREATE INDEX users_email ON users (email);\n",
+		"CREATE VIEW user_names AS SELECT name FROM users;\n",
+		"column_079 TEXT);\n-- wide: 1 rows\n", // The statement is stored on overflow pages
+	} {
+		if !strings.Contains(schema, expected) {
+			t.Errorf("Expected the schema to contain %q, got:\n%s", expected, schema)
+		}
+	}
+	if _, err := sqliteSchema(strings.NewReader(strings.Repeat("x", 200)), 200); err == nil {
+		t.Errorf("Expected an error for a file that is not an SQLite database")
+	}
+}
+
+func TestParquetSchema(t *testing.T) {
+	schema := readSchema(t, "testdata/users.parquet", func(f *os.File, size int64) (string, error) {
+		return parquetSchema(f, size)
+	})
+	expected := `Parquet file, 3 rows in 1 row groups, created by git2llm test fixture
+message schema
+  required int64 id
+  optional binary name (UTF8)
+  optional group tags (LIST)
+    repeated group list
+      optional binary element (UTF8)
+  required fixed_len_byte_array(32) hash
+`
+	if schema != expected {
+		t.Errorf("Expected schema:\n%s\ngot:\n%s", expected, schema)
+	}
+}
+
+func TestGit2LLMDataSchemas(t *testing.T) {
+	tempDir := t.TempDir()
+	data, err := os.ReadFile("testdata/users.parquet")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "users.parquet"), data, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	// Binary files that are not data files are skipped as usual
+	if err := os.WriteFile(filepath.Join(tempDir, "broken.db"), []byte("not\x00sqlite"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithDataSchemas(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	if !strings.Contains(result, "File: users.parquet (Data file - schema only)") || !strings.Contains(result, "  required int64 id\n") {
+		t.Errorf("Expected the schema of users.parquet, got:\n%s", result)
+	}
+	if !strings.Contains(result, "File: broken.db (Binary - skipped content)") {
+		t.Errorf("Expected broken.db to be skipped as binary, got:\n%s", result)
+	}
+}
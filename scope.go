@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WithScope restricts the scan to the files and directories at paths,
+// relative to the start path, e.g. "src/api" and "README.md". The tree only
+// shows them and the directories leading to them. No paths means everything.
+func WithScope(paths ...string) Option {
+	return func(g *Git2LLM) {
+		g.scope = paths
+	}
+}
+
+// inScope reports whether relPath is part of the scope: one of its paths or
+// below one, or, for a directory, on the way to one.
+func (g *Git2LLM) inScope(relPath string, isDir bool) bool {
+	if len(g.scope) == 0 {
+		return true
+	}
+	for _, p := range g.scope {
+		if relPath == p || strings.HasPrefix(relPath, p+"/") {
+			return true
+		}
+		if isDir && strings.HasPrefix(p, relPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanScope returns the scope paths given after "--" as clean slash separated
+// paths relative to the start path. "." stands for everything, so it empties
+// the scope.
+func cleanScope(paths []string) ([]string, error) {
+	var scope []string
+	for _, p := range paths {
+		clean := path.Clean(filepath.ToSlash(p))
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("invalid path %s after --: must be inside the start path", p)
+		}
+		if clean == "." {
+			return nil, nil
+		}
+		scope = append(scope, clean)
+	}
+	return scope, nil
+}
+
+// cutScope splits the positional arguments at "--" into the arguments and the
+// scope paths after it.
+func cutScope(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanScope(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"README.md", "go.mod", "src/api/handler.go", "src/api/v2/routes.go", "src/apiclient/client.go", "src/db/db.go", "internal/auth/auth.go", "internal/cache/cache.go"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("// "+name+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithScope("src/api", "internal/auth", "README.md"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	var files []string
+	for _, f := range result.Files {
+		files = append(files, f.Path)
+	}
+	expected := []string{"README.md", "internal/auth/auth.go", "src/api/handler.go", "src/api/v2/routes.go"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %q, got %q", expected, files)
+	}
+	for _, unexpected := range []string{"go.mod", "apiclient", "db/", "cache"} {
+		if strings.Contains(result.Tree, unexpected) {
+			t.Errorf("Expected %s to be left out of the tree:\n%s", unexpected, result.Tree)
+		}
+	}
+	if !strings.Contains(result.Tree, "auth/") || !strings.Contains(result.Tree, "routes.go") {
+		t.Errorf("Expected the tree to show the scope:\n%s", result.Tree)
+	}
+}
+
+func TestCutScope(t *testing.T) {
+	args, paths := cutScope([]string{".", ".go", "--", "./src/api/", "README.md"})
+	if !reflect.DeepEqual(args, []string{".", ".go"}) || !reflect.DeepEqual(paths, []string{"./src/api/", "README.md"}) {
+		t.Errorf("Unexpected split %q and %q", args, paths)
+	}
+	scope, err := cleanScope(paths)
+	if err != nil || !reflect.DeepEqual(scope, []string{"src/api", "README.md"}) {
+		t.Errorf("Expected clean paths, got %q, %v", scope, err)
+	}
+	if scope, err := cleanScope([]string{"src", "."}); err != nil || scope != nil {
+		t.Errorf("Expected . to mean everything, got %q, %v", scope, err)
+	}
+	for _, p := range []string{"../other", "/etc"} {
+		if _, err := cleanScope([]string{p}); err == nil {
+			t.Errorf("Expected an error for %s", p)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+)
+
+// shebangExtensions map interpreters to the extension of their scripts.
+var shebangExtensions = map[string]string{
+	"python":  ".py",
+	"python2": ".py",
+	"python3": ".py",
+	"sh":      ".sh",
+	"bash":    ".sh",
+	"zsh":     ".sh",
+	"dash":    ".sh",
+	"ksh":     ".sh",
+	"fish":    ".fish",
+	"node":    ".js",
+	"deno":    ".ts",
+	"bun":     ".ts",
+	"ts-node": ".ts",
+	"ruby":    ".rb",
+	"perl":    ".pl",
+	"php":     ".php",
+	"lua":     ".lua",
+	"Rscript": ".r",
+	"awk":     ".awk",
+	"tclsh":   ".tcl",
+	"pwsh":    ".ps1",
+}
+
+// shebangExtension returns the extension of scripts of the interpreter named in
+// the shebang line of the file at filePath, or "" if it has none or it is unknown.
+func (g *Git2LLM) shebangExtension(filePath string) string {
+	if g.specialFile(filePath) != "" {
+		return "" // Opening a named pipe would block
+	}
+	file, err := g.fs.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	head := make([]byte, 128)
+	n, _ := io.ReadFull(file, head)
+	return shebangInterpreterExtension(head[:n])
+}
+
+// shebangInterpreterExtension returns the extension for the shebang line at the
+// start of content, e.g. ".py" for "#!/usr/bin/env python3".
+func shebangInterpreterExtension(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// #!/usr/bin/env [-S] [VAR=value] interpreter
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = path.Base(f)
+				break
+			}
+		}
+	}
+	if ext, ok := shebangExtensions[interpreter]; ok {
+		return ext
+	}
+	// Versioned interpreters such as python3.12 or ruby3.2
+	return shebangExtensions[strings.TrimRight(interpreter, "0123456789.")]
+}
+
+// scriptAnnotation returns the annotation of the header of a file without an
+// extension: whether it is executable and the script type from its shebang
+// line, e.g. "executable sh script", and the type for syntax highlighting.
+// Both are empty for other files.
+func (g *Git2LLM) scriptAnnotation(filePath, name string) (annotation, lang string) {
+	if strings.Contains(name, ".") {
+		return "", ""
+	}
+	lang = strings.TrimPrefix(g.shebangExtension(filePath), ".")
+	executable := false
+	if info, err := g.fs.Stat(filePath); err == nil {
+		executable = info.Mode()&0111 != 0
+	}
+	switch {
+	case executable && lang != "":
+		annotation = "executable " + lang + " script"
+	case executable:
+		annotation = "executable"
+	case lang != "":
+		annotation = lang + " script"
+	}
+	return annotation, lang
+}
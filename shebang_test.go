@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestShebangInterpreterExtension(t *testing.T) {
+	testCases := []struct {
+		content string
+		expect  string
+	}{
+		{"#!/usr/bin/env python3\nprint()\n", ".py"},
+		{"#!/bin/bash -e\n", ".sh"},
+		{"#! /usr/bin/perl -w\n", ".pl"},
+		{"#!/usr/bin/env -S deno run --allow-net\n", ".ts"},
+		{"#!/usr/bin/env NODE_ENV=production node\n", ".js"},
+		{"#!/usr/local/bin/python3.12\n", ".py"},
+		{"#!/usr/bin/make -f\n", ""},
+		{"echo hello\n", ""},
+		{"#!", ""},
+	}
+	for _, tc := range testCases {
+		if ext := shebangInterpreterExtension([]byte(tc.content)); ext != tc.expect {
+			t.Errorf("For %q, expected %q, got %q", tc.content, tc.expect, ext)
+		}
+	}
+}
+
+func TestGit2LLMFileTypeNamesAndShebangs(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":        "package main\n",
+		"Dockerfile":     "FROM scratch\n",
+		"makefile":       "all:\n",
+		"bin/deploy":     "#!/usr/bin/env python3\nprint('deploy')\n",
+		"bin/setup":      "#!/bin/sh\necho setup\n",
+		"bin/notes":      "no shebang\n",
+		"bin/tool.rb":    "#!/usr/bin/env python3\n",
+		"docs/Makefile2": "all:\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, []string{".go", "Dockerfile", "Makefile", ".py"}, nil, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, included := range []string{"main.go", "Dockerfile", "makefile", "bin/deploy"} {
+		if !strings.Contains(result, "Content of "+included+":") {
+			t.Errorf("Expected %s to be included", included)
+		}
+	}
+	for _, excluded := range []string{"bin/setup", "bin/notes", "bin/tool.rb", "docs/Makefile2"} {
+		if strings.Contains(result, excluded) {
+			t.Errorf("Did not expect %s to be included", excluded)
+		}
+	}
+}
+
+func TestGit2LLMScriptAnnotation(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{"deploy", "#!/usr/bin/env bash\necho deploy\n", 0755},
+		{"tool", "\x7fELF-ish but text\n", 0755},
+		{"migrate", "#!/usr/bin/env python3\n", 0644},
+		{"LICENSE", "MIT\n", 0644},
+		{"run.sh", "#!/bin/sh\n", 0755},
+	}
+	for _, f := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, f.name), []byte(f.content), f.mode); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", f.name, err)
+		}
+	}
+
+	var output, md strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, withEmitters(emitFormats["md"](&md)))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	expected := []string{"File: migrate (py script)\n", "File: LICENSE\n", "File: run.sh\n"}
+	if runtime.GOOS != "windows" {
+		expected = append(expected, "File: deploy (executable sh script)\n", "File: tool (executable)\n")
+	}
+	for _, e := range expected {
+		if !strings.Contains(output.String(), e) {
+			t.Errorf("Expected %q in the output. Result:\n%s", e, output.String())
+		}
+	}
+	if !strings.Contains(md.String(), "## migrate\n\n```python\n") {
+		t.Errorf("Expected the shebang language in the markdown fence. Result:\n%s", md.String())
+	}
+}
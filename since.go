@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sinceLayouts are the layouts of the dates --since accepts, in local time
+// unless they have a zone.
+var sinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseSince parses the value of --since: a date such as 2024-05-01 or
+// 2024-05-01 14:30, or a duration before now such as 7d, 2w or 36h.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	var d time.Duration
+	if n, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && strings.HasSuffix(value, "d") {
+		d = time.Duration(n) * 24 * time.Hour
+	} else if n, err := strconv.Atoi(strings.TrimSuffix(value, "w")); err == nil && strings.HasSuffix(value, "w") {
+		d = time.Duration(n) * 7 * 24 * time.Hour
+	} else if d, err = time.ParseDuration(value); err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: expected a date such as 2024-05-01 or a duration such as 7d, 2w or 36h", value)
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: the duration is negative", value)
+	}
+	return now.Add(-d), nil
+}
+
+// WithSince restricts the file contents to the files modified after t, by
+// their modification time. The directory tree still shows everything.
+func WithSince(t time.Time) Option {
+	return func(g *Git2LLM) {
+		g.since = t
+	}
+}
+
+// filterSince drops the files not modified after the time set with WithSince.
+// Files whose modification time is unknown are dropped as well.
+func (g *Git2LLM) filterSince(files []manifestEntry) []manifestEntry {
+	if g.since.IsZero() {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		info, err := g.fs.Stat(f.path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(g.since) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// filesCommittedSince returns the files below dir changed by the commits of
+// ref after t, by commit date, relative to dir. Without a ref, the history of
+// HEAD is used and the uncommitted changes, including new untracked files,
+// count as changed now. Deleted files are left out.
+func filesCommittedSince(dir, ref string, t time.Time) ([]string, error) {
+	rev := ref
+	if rev == "" {
+		rev = "HEAD"
+	}
+	committed, err := runGit(dir, "log", "--since="+t.Format(time.RFC3339), "--name-only", "--format=", "--relative", "--diff-filter=d", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	var uncommitted []string
+	if ref == "" {
+		if uncommitted, err = changedFiles(dir, "HEAD"); err != nil {
+			return nil, err
+		}
+	}
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range append(committed, uncommitted...) {
+		if !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{"2024-05-01 14:30", time.Date(2024, 5, 1, 14, 30, 0, 0, time.Local)},
+		{"2024-05-01T14:30:00Z", time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)},
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+		{"36h", now.Add(-36 * time.Hour)},
+	}
+	for _, tc := range testCases {
+		got, err := parseSince(tc.value, now)
+		if err != nil {
+			t.Errorf("parseSince(%q) failed: %v", tc.value, err)
+			continue
+		}
+		if !got.Equal(tc.expected) {
+			t.Errorf("parseSince(%q): expected %v, got %v", tc.value, tc.expected, got)
+		}
+	}
+	for _, value := range []string{"yesterday", "7 days", "-3d", "-1h", "2024-13-01"} {
+		if _, err := parseSince(value, now); err == nil {
+			t.Errorf("parseSince(%q): expected an error", value)
+		}
+	}
+}
+
+func TestGit2LLMSince(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	fsys := IOFS{FS: fstest.MapFS{
+		"old.go":     {Data: []byte("package main\n"), ModTime: since.Add(-time.Hour)},
+		"new.go":     {Data: []byte("package main\n"), ModTime: since.Add(time.Hour)},
+		"pkg/new.go": {Data: []byte("package pkg\n"), ModTime: since.Add(time.Minute)},
+	}}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", []string{".go"}, fsys, &output, false, false, false, nil, "", false, WithSince(since))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{"File: new.go", "File: pkg/new.go", "└── old.go"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "File: old.go") {
+		t.Errorf("Expected the contents of old.go to be left out, got:\n%s", result)
+	}
+}
+
+func TestFilesCommittedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	git("2024-01-01T00:00:00Z", "init", "-q")
+	write("old.go", "package main\n")
+	write("deleted.go", "package main\n")
+	write("recent.go", "package main\n")
+	git("2024-01-01T00:00:00Z", "add", ".")
+	git("2024-01-01T00:00:00Z", "commit", "-q", "-m", "initial")
+	write("recent.go", "package main\n\nfunc main() {}\n")
+	write("pkg/added.go", "package pkg\n")
+	git("2024-06-01T00:00:00Z", "add", ".")
+	git("2024-06-01T00:00:00Z", "rm", "-q", "deleted.go")
+	git("2024-06-01T00:00:00Z", "commit", "-q", "-m", "recent")
+	write("untracked.go", "package main\n")
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	files, err := filesCommittedSince(tempDir, "", since)
+	if err != nil {
+		t.Fatalf("filesCommittedSince failed: %v", err)
+	}
+	sort.Strings(files)
+	if expected := []string{"pkg/added.go", "recent.go", "untracked.go"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	// A ref only has its history
+	files, err = filesCommittedSince(tempDir, "HEAD~1", since)
+	if err != nil {
+		t.Fatalf("filesCommittedSince failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no files changed in HEAD~1 since %v, got %v", since, files)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SkipReason explains why a file was left out of the output.
+type SkipReason string
+
+const (
+	SkipBinary      SkipReason = "binary"
+	SkipSecret      SkipReason = "secret"
+	SkipTooLarge    SkipReason = "too-large"
+	SkipSymlink     SkipReason = "symlink"
+	SkipExcluded    SkipReason = "excluded"
+	SkipUnreadable  SkipReason = "unreadable"
+	SkipFiltered    SkipReason = "filtered"
+	SkipDuplicate   SkipReason = "duplicate"
+	SkipSpecial     SkipReason = "special"
+	SkipPII         SkipReason = "pii"
+	SkipOverLimit   SkipReason = "over-limit"
+	SkipInterrupted SkipReason = "interrupted"
+)
+
+// SkippedFile records a file whose content is not part of the output.
+type SkippedFile struct {
+	Path   string     `json:"path"`
+	Reason SkipReason `json:"reason"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+// WithSkipPlaceholders writes a placeholder stanza for every file whose
+// content is left out, such as "(Binary - skipped content)". It is enabled by
+// default; without it such files only show up in the tree and the skip
+// summary.
+func WithSkipPlaceholders(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.noSkipPlaceholders = !enabled
+	}
+}
+
+// writePlaceholder writes the stanza of a file whose content is left out, with
+// label in the file header, e.g. "Too large", and detail in the content
+// header, unless placeholders are disabled.
+func (g *Git2LLM) writePlaceholder(relPath, label, detail string) error {
+	if g.noSkipPlaceholders {
+		return nil
+	}
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (%s - skipped content)\n", g.fileHeader(relPath), label); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if err := g.writeSeparator(g.outputWriter); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(g.outputWriter, "%s (Skipped - %s)\n\n\n", g.contentHeader(relPath), detail); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// skip records that the file at relPath was left out of the output.
+func (g *Git2LLM) skip(relPath string, reason SkipReason, detail string) {
+	g.skipped = append(g.skipped, SkippedFile{Path: relPath, Reason: reason, Detail: detail})
+}
+
+// Skipped returns the files left out of the output so far, in scan order.
+func (g *Git2LLM) Skipped() []SkippedFile {
+	return g.skipped
+}
+
+// skippedFiles returns the skipped files of all roots.
+func skippedFiles(roots []*Git2LLM) []SkippedFile {
+	var skipped []SkippedFile
+	for _, g := range roots {
+		skipped = append(skipped, g.skipped...)
+	}
+	return skipped
+}
+
+// forbiddenReason maps the result of isForbiddenFile to a skip reason.
+func forbiddenReason(reason string) SkipReason {
+	switch reason {
+	case "binary":
+		return SkipBinary
+	case "private key":
+		return SkipSecret
+	}
+	return SkipUnreadable
+}
+
+// logSkipSummary logs the number of skipped files per reason, and every skipped
+// file at debug level. Nothing is logged if no files were skipped.
+func logSkipSummary(logger *slog.Logger, skipped []SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+	counts := make(map[SkipReason]int)
+	for _, s := range skipped {
+		counts[s.Reason]++
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, string(reason))
+	}
+	sort.Strings(reasons)
+	parts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[SkipReason(reason)], reason))
+	}
+	logger.Info(fmt.Sprintf("Skipped %d files (%s)", len(skipped), strings.Join(parts, ", ")))
+	for _, s := range skipped {
+		if s.Detail != "" {
+			logger.Debug("Skipped file", "path", s.Path, "reason", s.Reason, "detail", s.Detail)
+		} else {
+			logger.Debug("Skipped file", "path", s.Path, "reason", s.Reason)
+		}
+	}
+}
+
+// writeSkipReport writes the skipped files as a JSON array to the file at path.
+func writeSkipReport(path string, skipped []SkippedFile) error {
+	if skipped == nil {
+		skipped = []SkippedFile{}
+	}
+	data, err := json.MarshalIndent(skipped, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+	return nil
+}
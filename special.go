@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+)
+
+// specialFileKind describes the file type of mode if it is neither a regular
+// file, a directory nor a symlink, e.g. "named pipe". Reading such files may
+// block forever or never end, so they are skipped before being opened.
+func specialFileKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	case mode&os.ModeIrregular != 0:
+		return "irregular file"
+	}
+	return ""
+}
+
+// specialFile returns the kind of special file at filePath, or "" for regular
+// files, directories, symlinks and files that can't be examined.
+func (g *Git2LLM) specialFile(filePath string) string {
+	info, err := g.fs.Lstat(filePath)
+	if err != nil {
+		return ""
+	}
+	return specialFileKind(info.Mode())
+}
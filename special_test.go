@@ -0,0 +1,69 @@
+//go:build unix
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestGit2LLMSkipsNamedPipes(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	for _, name := range []string{"pipe", "pipe.go"} {
+		if err := syscall.Mkfifo(filepath.Join(tempDir, name), 0644); err != nil {
+			t.Skipf("Can't create a named pipe: %v", err)
+		}
+	}
+
+	for _, fileTypes := range [][]string{nil, {".go", ".sh"}} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, fileTypes, nil, &output, false, false, false, nil, "", false)
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- git2llm.ScanRepository() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("ScanRepository failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ScanRepository blocked on a named pipe")
+		}
+
+		result := output.String()
+		if !strings.Contains(result, "File: main.go") || strings.Contains(result, "File: pipe") {
+			t.Errorf("Expected only main.go in the contents. Result:\n%s", result)
+		}
+		var special []string
+		for _, s := range git2llm.Skipped() {
+			if s.Reason == SkipSpecial && s.Detail == "named pipe" {
+				special = append(special, s.Path)
+			}
+		}
+		expected := 1 // Only pipe.go passes the file type filter
+		if fileTypes == nil {
+			expected = 2
+		}
+		if len(special) != expected {
+			t.Errorf("Expected the named pipes matching %v to be skipped, got %v", fileTypes, git2llm.Skipped())
+		}
+	}
+
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, io.Discard, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if reasons := git2llm.explain("pipe"); len(reasons) != 1 || reasons[0] != "being a named pipe" {
+		t.Errorf("Expected the named pipe to be explained, got %q", reasons)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spool buffers output in a temporary file, so that something learned while
+// writing it, such as line numbers or the token total, can be written before it.
+type spool struct {
+	file *os.File
+	buf  *bufio.Writer
+}
+
+func newSpool() (*spool, error) {
+	file, err := os.CreateTemp("", "git2llm-*")
+	if err != nil {
+		return nil, fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	return &spool{file: file, buf: bufio.NewWriterSize(file, 64*1024)}, nil
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+// copyTo writes everything spooled so far to w.
+func (s *spool) copyTo(w io.Writer) error {
+	if err := s.buf.Flush(); err != nil {
+		return fmt.Errorf("error writing contents: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error reading contents: %w", err)
+	}
+	if _, err := io.Copy(w, s.file); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	return nil
+}
+
+// close removes the spool file.
+func (s *spool) close() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
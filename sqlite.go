@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const sqliteMagic = "SQLite format 3\x00"
+
+// sqliteFile reads the b-trees of an SQLite database file, following the
+// file format at https://www.sqlite.org/fileformat.html. Only what is needed
+// for the schema and row counts is implemented.
+type sqliteFile struct {
+	r        io.ReaderAt
+	pageSize int
+	usable   int
+	pages    int
+}
+
+// sqliteSchema describes the tables, indexes and views of an SQLite database
+// with their CREATE statements and the number of rows of every table.
+func sqliteSchema(r io.ReaderAt, size int64) (string, error) {
+	header := make([]byte, 100)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return "", fmt.Errorf("read header: %w", err)
+	}
+	if string(header[:16]) != sqliteMagic {
+		return "", errors.New("not an SQLite database")
+	}
+	if encoding := binary.BigEndian.Uint32(header[56:]); encoding > 1 {
+		return "", errors.New("only UTF-8 databases are supported")
+	}
+	db := &sqliteFile{r: r, pageSize: int(binary.BigEndian.Uint16(header[16:]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	if db.pageSize < 512 {
+		return "", fmt.Errorf("invalid page size %d", db.pageSize)
+	}
+	db.usable = db.pageSize - int(header[20])
+	db.pages = int(size / int64(db.pageSize))
+
+	// The schema table sqlite_master has the columns type, name, tbl_name, rootpage and sql
+	var objects [][]any
+	err := db.walk(1, func(payload []byte) error {
+		values, err := sqliteRecord(payload)
+		if err != nil {
+			return err
+		}
+		if len(values) == 5 {
+			objects = append(objects, values)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "SQLite database, %d pages of %d bytes\n", db.pages, db.pageSize)
+	for _, object := range objects {
+		kind, _ := object[0].(string)
+		name, _ := object[1].(string)
+		sql, _ := object[4].(string)
+		if sql == "" || strings.HasPrefix(name, "sqlite_") {
+			continue // Internal tables and automatic indexes
+		}
+		out.WriteString(sql + ";\n")
+		if kind != "table" {
+			continue
+		}
+		rootPage, _ := object[3].(int64)
+		rows := 0
+		if err := db.walk(int(rootPage), func([]byte) error { rows++; return nil }); err != nil {
+			fmt.Fprintf(&out, "-- %s: rows could not be counted: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(&out, "-- %s: %d rows\n", name, rows)
+	}
+	return out.String(), nil
+}
+
+func (db *sqliteFile) page(n int) ([]byte, error) {
+	if n < 1 || n > db.pages {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	page := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(page, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("read page %d: %w", n, err)
+	}
+	return page, nil
+}
+
+// walk calls fn with the payload of every entry of the b-tree rooted at page root.
+func (db *sqliteFile) walk(root int, fn func(payload []byte) error) error {
+	visited := make(map[int]bool)
+	var visit func(n int) error
+	visit = func(n int) error {
+		if visited[n] {
+			return fmt.Errorf("page %d is referenced twice", n)
+		}
+		visited[n] = true
+		page, err := db.page(n)
+		if err != nil {
+			return err
+		}
+		offset := 0
+		if n == 1 {
+			offset = 100 // The database header
+		}
+		kind := page[offset]
+		cells := int(binary.BigEndian.Uint16(page[offset+3:]))
+		headerSize := 8
+		if kind == 2 || kind == 5 {
+			headerSize = 12
+		}
+		pointers := offset + headerSize
+		if pointers+2*cells > len(page) {
+			return fmt.Errorf("page %d: invalid cell count", n)
+		}
+		for i := 0; i < cells; i++ {
+			cell := int(binary.BigEndian.Uint16(page[pointers+2*i:]))
+			if cell+4 > len(page) {
+				return fmt.Errorf("page %d: invalid cell offset", n)
+			}
+			switch kind {
+			case 5: // Interior table page: child page and rowid
+				if err := visit(int(binary.BigEndian.Uint32(page[cell:]))); err != nil {
+					return err
+				}
+			case 13: // Leaf table page: payload size, rowid and payload
+				size, k := sqliteVarint(page[cell:])
+				_, l := sqliteVarint(page[cell+k:])
+				payload, err := db.payload(page, cell+k+l, int(size), db.usable-35)
+				if err != nil {
+					return err
+				}
+				if err := fn(payload); err != nil {
+					return err
+				}
+			case 2: // Interior index page: child page and a key, used by WITHOUT ROWID tables
+				if err := visit(int(binary.BigEndian.Uint32(page[cell:]))); err != nil {
+					return err
+				}
+				if err := db.indexCell(page, cell+4, fn); err != nil {
+					return err
+				}
+			case 10: // Leaf index page
+				if err := db.indexCell(page, cell, fn); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("page %d: unknown page type %d", n, kind)
+			}
+		}
+		if kind == 2 || kind == 5 {
+			return visit(int(binary.BigEndian.Uint32(page[offset+8:])))
+		}
+		return nil
+	}
+	return visit(root)
+}
+
+func (db *sqliteFile) indexCell(page []byte, cell int, fn func(payload []byte) error) error {
+	size, k := sqliteVarint(page[cell:])
+	payload, err := db.payload(page, cell+k, int(size), (db.usable-12)*64/255-23)
+	if err != nil {
+		return err
+	}
+	return fn(payload)
+}
+
+// payload returns the payload of size bytes starting at offset in page,
+// following overflow pages if it is larger than maxLocal.
+func (db *sqliteFile) payload(page []byte, offset, size, maxLocal int) ([]byte, error) {
+	local := size
+	if size > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if offset+local > len(page) || (local < size && offset+local+4 > len(page)) {
+		return nil, errors.New("cell extends beyond its page")
+	}
+	payload := bytes.Clone(page[offset : offset+local])
+	if local == size {
+		return payload, nil
+	}
+	next := int(binary.BigEndian.Uint32(page[offset+local:]))
+	for len(payload) < size {
+		if next == 0 || len(payload) > db.pages*db.usable {
+			return nil, errors.New("truncated overflow chain")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := min(size-len(payload), db.usable-4)
+		payload = append(payload, overflow[4:4+n]...)
+		next = int(binary.BigEndian.Uint32(overflow))
+	}
+	return payload, nil
+}
+
+// sqliteVarint decodes a big-endian variable length integer of up to 9 bytes.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+// sqliteRecord decodes a record into integers, floats, strings and blobs.
+func sqliteRecord(payload []byte) ([]any, error) {
+	headerSize, n := sqliteVarint(payload)
+	if int(headerSize) > len(payload) {
+		return nil, errors.New("invalid record header")
+	}
+	var types []uint64
+	for n < int(headerSize) {
+		t, k := sqliteVarint(payload[n:headerSize])
+		types = append(types, t)
+		n += k
+	}
+	body := payload[headerSize:]
+	values := make([]any, 0, len(types))
+	for _, t := range types {
+		var size int
+		switch {
+		case t == 0 || t == 8 || t == 9:
+			size = 0
+		case t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		default:
+			return nil, fmt.Errorf("invalid serial type %d", t)
+		}
+		if size > len(body) {
+			return nil, errors.New("record extends beyond its payload")
+		}
+		value := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t == 8 || t == 9:
+			values = append(values, int64(t-8))
+		case t <= 6:
+			// Big-endian two's complement integers of 1 to 8 bytes
+			v := int64(int8(value[0]))
+			for _, b := range value[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(value)))
+		case t%2 == 1:
+			values = append(values, string(value))
+		default:
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// stdinArg is the start path that reads a single file from stdin.
+const stdinArg = "-"
+
+// newStdinFS reads r into a file system holding a single file called name, so
+// that piped content goes through the same formatting, redaction and token
+// counting as files in a directory.
+func newStdinFS(name string, r io.Reader) (*treeFS, error) {
+	name, err := stdinPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stdin: %w", err)
+	}
+	entries := []*treeEntry{{path: name, mode: "100644", size: int64(len(data))}}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		entries = append(entries, &treeEntry{path: dir, isDir: true})
+	}
+	return newTreeFS(entries, func(*treeEntry) ([]byte, error) {
+		return data, nil
+	}), nil
+}
+
+// stdinPath returns the path of the file read from stdin, relative to the root.
+func stdinPath(name string) (string, error) {
+	name = path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid --stdin-name %q", name)
+	}
+	return name, nil
+}
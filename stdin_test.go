@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStdinFS(t *testing.T) {
+	stdinFS, err := newStdinFS("config/.env", strings.NewReader("PASSWORD=hunter2\n"))
+	if err != nil {
+		t.Fatalf("newStdinFS failed: %v", err)
+	}
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, stdinFS, &output, false, false, false, nil, "", false, WithDotfileIncludes("config/.env"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	if !strings.Contains(result, "File: config/.env (Values redacted)\n") || !strings.Contains(result, "PASSWORD=<redacted>\n") {
+		t.Errorf("Expected the redacted content of config/.env, got:\n%s", result)
+	}
+	if strings.Contains(result, "hunter2") {
+		t.Errorf("Expected the value to be redacted, got:\n%s", result)
+	}
+
+	for _, name := range []string{"", ".", "../outside"} {
+		if _, err := newStdinFS(name, strings.NewReader("")); err == nil {
+			t.Errorf("Expected an error for --stdin-name %q", name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/perbu/git2llm/tokens"
+)
+
+// summaryTimeout limits how long a single file summary may take.
+const summaryTimeout = 2 * time.Minute
+
+// answerStreamer is the part of llm.Client used for summaries.
+type answerStreamer interface {
+	Stream(ctx context.Context, prompt string, w io.Writer) error
+	Model() string
+}
+
+// summarizer is a Transformer replacing files above a token threshold with a
+// summary written by an LLM. The summary is marked as such in the output.
+type summarizer struct {
+	client    answerStreamer
+	counter   tokens.Counter
+	threshold int
+}
+
+func newSummarizer(client answerStreamer, counter tokens.Counter, threshold int) Transformer {
+	return summarizer{client: client, counter: counter, threshold: threshold}
+}
+
+func (s summarizer) Transform(path string, content []byte) ([]byte, bool, error) {
+	n, err := s.counter.Count(string(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("counter.Count: %w", err)
+	}
+	if n <= s.threshold {
+		return content, true, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	defer cancel()
+	var summary bytes.Buffer
+	if err := s.client.Stream(ctx, summaryPrompt(path, content), &summary); err != nil {
+		return nil, false, fmt.Errorf("error summarizing %s: %w", path, err)
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[Summary of %s (%d tokens) written by %s; the original content is not included]\n", path, n, s.client.Model())
+	out.WriteString(strings.TrimSpace(summary.String()))
+	out.WriteByte('\n')
+	return out.Bytes(), true, nil
+}
+
+// summaryPrompt asks for a short summary of the file at path.
+func summaryPrompt(path string, content []byte) string {
+	return "Summarize the following file from a software repository in a few short paragraphs. " +
+		"Describe its purpose, its main types and functions and anything a developer must know to work with it. " +
+		"Answer with the summary only.\n\nFile: " + path + "\n\n" + string(content)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/perbu/git2llm/tokens"
+)
+
+// exitOverTokens is the exit status when the output exceeds --fail-over-tokens.
+const exitOverTokens = 3
+
+// ScanSummary describes the result of a scan for machines, e.g. CI jobs.
+type ScanSummary struct {
+	Files         int     `json:"files"`
+	Skipped       int     `json:"skipped"`
+	Tokens        int     `json:"tokens"`
+	TokenLimit    int     `json:"token_limit,omitempty"`
+	OverLimit     bool    `json:"over_limit"`
+	Model         string  `json:"model,omitempty"`
+	ContextWindow int     `json:"context_window,omitempty"`
+	EstimatedCost float64 `json:"estimated_cost_usd,omitempty"`
+	Interrupted   bool    `json:"interrupted,omitempty"`
+}
+
+// Tokens returns the number of tokens counted so far, including the directory tree.
+func (g *Git2LLM) Tokens() int {
+	return int(g.tokens.Load())
+}
+
+// summarize returns the summary of all roots, checking the token count against limit if it is positive.
+func summarize(roots []*Git2LLM, limit int) ScanSummary {
+	var s ScanSummary
+	for _, g := range roots {
+		s.Files += g.files
+		s.Skipped += len(g.skipped)
+		s.Tokens += g.Tokens()
+		s.Interrupted = s.Interrupted || g.interrupted > 0
+	}
+	// The context window and cost are known for models in the registry
+	if len(roots) > 0 && roots[0].countTokens {
+		if info, ok := tokens.Lookup(roots[0].model); ok {
+			s.Model = info.Name
+			s.ContextWindow = info.ContextWindow
+			s.EstimatedCost = info.Cost(s.Tokens)
+		}
+	}
+	if limit > 0 {
+		s.TokenLimit = limit
+		s.OverLimit = s.Tokens > limit
+	}
+	return s
+}
+
+// writeSummary writes the summary as JSON to the file at path.
+func writeSummary(path string, s ScanSummary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("os.WriteFile: %w", err)
+	}
+	return nil
+}
+
+// maxBreakdownTypes is the number of file types listed in the token breakdown,
+// the remaining types are added up as "other".
+const maxBreakdownTypes = 10
+
+// typeTokens is the number of tokens in the files of a type.
+type typeTokens struct {
+	fileType string
+	tokens   int
+}
+
+// fileType returns the type of the file at relPath for the token breakdown:
+// its extension in lower case, or its name if it has none, e.g. Makefile.
+func fileType(relPath string) string {
+	name := path.Base(relPath)
+	if ext := path.Ext(name); ext != "" && ext != name {
+		return strings.ToLower(ext)
+	}
+	return name
+}
+
+// tokensByType adds up the tokens of files per file type as returned by
+// typeOf, most tokens first. Types beyond the first maxBreakdownTypes are added
+// up as "other".
+func tokensByType(files []FileResult, typeOf func(relPath string) string) []typeTokens {
+	counts := make(map[string]int)
+	for _, f := range files {
+		counts[typeOf(f.Path)] += f.Tokens
+	}
+	breakdown := make([]typeTokens, 0, len(counts))
+	for t, n := range counts {
+		if n > 0 {
+			breakdown = append(breakdown, typeTokens{t, n})
+		}
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].tokens != breakdown[j].tokens {
+			return breakdown[i].tokens > breakdown[j].tokens
+		}
+		return breakdown[i].fileType < breakdown[j].fileType
+	})
+	if len(breakdown) > maxBreakdownTypes {
+		other := typeTokens{fileType: "other"}
+		for _, t := range breakdown[maxBreakdownTypes:] {
+			other.tokens += t.tokens
+		}
+		breakdown = append(breakdown[:maxBreakdownTypes], other)
+	}
+	return breakdown
+}
+
+// logTokenBreakdown logs the tokens per file type, so it is visible where
+// leaving files out would save the most.
+func logTokenBreakdown(logger *slog.Logger, files []FileResult, typeOf func(relPath string) string) {
+	breakdown := tokensByType(files, typeOf)
+	if len(breakdown) == 0 {
+		return
+	}
+	attrs := make([]any, 0, 2*len(breakdown))
+	for _, t := range breakdown {
+		attrs = append(attrs, t.fileType, t.tokens)
+	}
+	logger.Info("Tokens by file type", attrs...)
+}
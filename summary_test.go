@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	a := &Git2LLM{files: 2, skipped: []SkippedFile{{Path: "logo.png", Reason: SkipBinary}}}
+	a.tokens.Add(600)
+	b := &Git2LLM{files: 1}
+	b.tokens.Add(500)
+
+	summary := summarize([]*Git2LLM{a, b}, 1000)
+	expected := ScanSummary{Files: 3, Skipped: 1, Tokens: 1100, TokenLimit: 1000, OverLimit: true}
+	if summary != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary)
+	}
+	if summary := summarize([]*Git2LLM{a, b}, 0); summary.OverLimit || summary.TokenLimit != 0 {
+		t.Errorf("Expected no limit check without a limit, got %+v", summary)
+	}
+
+	// Models from the registry add their context window and the cost
+	a.countTokens, a.model = true, "gpt-4o"
+	summary = summarize([]*Git2LLM{a, b}, 0)
+	if summary.Model != "gpt-4o" || summary.ContextWindow != 128000 || summary.EstimatedCost != 1100*2.50/1e6 {
+		t.Errorf("Expected the context window and cost of gpt-4o, got %+v", summary)
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := writeSummary(path, expected); err != nil {
+		t.Fatalf("writeSummary failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	var decoded ScanSummary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Summary is not valid JSON: %v", err)
+	}
+	if decoded != expected {
+		t.Errorf("Expected %+v after round trip, got %+v", expected, decoded)
+	}
+}
+
+func TestTokensByType(t *testing.T) {
+	var files []FileResult
+	for i, p := range []string{"a.go", "b.GO", "c.yaml", "Makefile", ".env", "empty.txt"} {
+		files = append(files, FileResult{Path: p, Tokens: 10 * (i + 1)})
+	}
+	files[5].Tokens = 0
+	g := &Git2LLM{}
+	got := tokensByType(files, g.typeOf)
+	expected := []typeTokens{{"dotenv", 50}, {"makefile", 40}, {"go", 30}, {"yaml", 30}}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, got)
+			break
+		}
+	}
+
+	files = nil
+	for i := range maxBreakdownTypes + 2 {
+		files = append(files, FileResult{Path: fmt.Sprintf("f.x%d", i), Tokens: 100 - i})
+	}
+	got = tokensByType(files, g.typeOf)
+	if len(got) != maxBreakdownTypes+1 || got[maxBreakdownTypes] != (typeTokens{"other", 90 + 89}) {
+		t.Errorf("Expected the smallest types as other, got %v", got)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// goSymbol is a symbol to extract: a function, type, variable or constant, or
+// a method written as Type.Method, declared in the package in dir.
+type goSymbol struct {
+	dir  string // Slash separated, relative to the start path; "." is the start path
+	name string
+}
+
+// symbolExtractor is a Transformer reducing Go files to the declarations of the
+// requested symbols, after the package clause and imports of the file. Files
+// declaring none of them are dropped, and so are all other files.
+type symbolExtractor struct {
+	specs   []string
+	symbols map[string][]goSymbol // spec -> the symbols it may mean
+	found   map[string]bool       // spec -> found in a file
+}
+
+// newSymbolExtractor parses specs such as pkg/server.Handler or
+// pkg/server.Server.Start. Specs without a slash name a symbol in the start
+// directory (Handler, Server.Start) or, if it has one, in a top-level directory
+// (server.Handler); both are looked for.
+func newSymbolExtractor(specs []string) (*symbolExtractor, error) {
+	e := &symbolExtractor{specs: specs, symbols: make(map[string][]goSymbol), found: make(map[string]bool)}
+	for _, spec := range specs {
+		slash := strings.LastIndex(spec, "/")
+		dot := strings.Index(spec[slash+1:], ".")
+		var candidates []goSymbol
+		switch {
+		case slash >= 0 && dot > 0:
+			candidates = append(candidates, goSymbol{dir: spec[:slash+1+dot], name: spec[slash+2+dot:]})
+		case slash < 0:
+			candidates = append(candidates, goSymbol{dir: ".", name: spec})
+			if dot > 0 {
+				candidates = append(candidates, goSymbol{dir: spec[:dot], name: spec[dot+1:]})
+			}
+		}
+		if len(candidates) == 0 || spec == "" || strings.HasSuffix(spec, ".") {
+			return nil, fmt.Errorf("invalid symbol %q, expected e.g. pkg/server.Handler or pkg/server.Server.Start", spec)
+		}
+		e.symbols[spec] = candidates
+	}
+	return e, nil
+}
+
+func (e *symbolExtractor) Transform(filePath string, content []byte) ([]byte, bool, error) {
+	if !strings.HasSuffix(filePath, ".go") {
+		return nil, false, nil
+	}
+	dir := path.Dir(filePath)
+	names := make(map[string]string) // name -> spec
+	for spec, candidates := range e.symbols {
+		for _, s := range candidates {
+			if s.dir == dir {
+				names[s.name] = spec
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, false, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil, false, nil // Files that don't parse can't declare the symbol
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var decls [][]byte
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			if spec, ok := names[name]; ok {
+				e.found[spec] = true
+				decls = append(decls, content[offset(startWithDoc(d.Doc, d.Pos())):offset(d.End())])
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if !specDeclares(spec, names, e.found) {
+					continue
+				}
+				if !d.Lparen.IsValid() {
+					decls = append(decls, content[offset(startWithDoc(d.Doc, d.Pos())):offset(d.End())])
+					break
+				}
+				// A declaration from a group, written as a declaration of its own
+				doc := specDoc(spec)
+				decl := d.Tok.String() + " " + string(content[offset(spec.Pos()):offset(spec.End())])
+				if doc != nil {
+					decl = string(content[offset(doc.Pos()):offset(doc.End())]) + "\n" + decl
+				}
+				decls = append(decls, []byte(decl))
+			}
+		}
+	}
+	if len(decls) == 0 {
+		return nil, false, nil
+	}
+
+	// The file header: the package clause and the imports
+	end := file.Name.End()
+	for _, imp := range file.Imports {
+		end = max(end, imp.End())
+	}
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			end = max(end, d.End())
+		}
+	}
+	var out bytes.Buffer
+	out.Write(content[offset(file.Package):offset(end)])
+	for _, decl := range decls {
+		out.WriteString("\n\n")
+		out.Write(decl)
+	}
+	out.WriteString("\n")
+	return out.Bytes(), true, nil
+}
+
+// missing returns the specs that were not found in any file.
+func (e *symbolExtractor) missing() []string {
+	var missing []string
+	for _, spec := range e.specs {
+		if !e.found[spec] {
+			missing = append(missing, spec)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// specDeclares reports whether a value or type spec declares one of names,
+// marking the spec as found.
+func specDeclares(spec ast.Spec, names map[string]string, found map[string]bool) bool {
+	var idents []*ast.Ident
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		idents = []*ast.Ident{s.Name}
+	case *ast.ValueSpec:
+		idents = s.Names
+	}
+	for _, ident := range idents {
+		if spec, ok := names[ident.Name]; ok {
+			found[spec] = true
+			return true
+		}
+	}
+	return false
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
+
+func startWithDoc(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+// receiverType returns the name of the type of a method receiver, without
+// pointer and type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const serverSource = `// Package server serves requests.
+package server
+
+import (
+	"net/http"
+)
+
+// Server handles requests.
+type Server struct {
+	mux *http.ServeMux
+}
+
+// Start starts the server.
+func (s *Server) Start() error {
+	return nil
+}
+
+const (
+	// DefaultPort is used without configuration.
+	DefaultPort = 8080
+	otherPort   = 8081
+)
+
+// Handler is the main handler.
+func Handler(w http.ResponseWriter, r *http.Request) {}
+`
+
+func TestSymbolExtractor(t *testing.T) {
+	e, err := newSymbolExtractor([]string{"pkg/server.Handler", "pkg/server.Server.Start", "pkg/server.DefaultPort", "pkg/server.Missing"})
+	if err != nil {
+		t.Fatalf("newSymbolExtractor failed: %v", err)
+	}
+	content, keep, err := e.Transform("pkg/server/server.go", []byte(serverSource))
+	if err != nil || !keep {
+		t.Fatalf("Expected the file to be kept, got keep %v, err %v", keep, err)
+	}
+	expected := `package server
+
+import (
+	"net/http"
+)
+
+// Start starts the server.
+func (s *Server) Start() error {
+	return nil
+}
+
+// DefaultPort is used without configuration.
+const DefaultPort = 8080
+
+// Handler is the main handler.
+func Handler(w http.ResponseWriter, r *http.Request) {}
+`
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	for _, path := range []string{"pkg/other/server.go", "pkg/server/README.md"} {
+		if _, keep, _ := e.Transform(path, []byte(serverSource)); keep {
+			t.Errorf("Expected %s to be dropped", path)
+		}
+	}
+	if missing := e.missing(); !reflect.DeepEqual(missing, []string{"pkg/server.Missing"}) {
+		t.Errorf("Expected pkg/server.Missing to be missing, got %v", missing)
+	}
+}
+
+func TestSymbolExtractorStartDirectory(t *testing.T) {
+	e, err := newSymbolExtractor([]string{"Server"})
+	if err != nil {
+		t.Fatalf("newSymbolExtractor failed: %v", err)
+	}
+	content, keep, err := e.Transform("server.go", []byte(serverSource))
+	if err != nil || !keep || !strings.Contains(string(content), "type Server struct {") || strings.Contains(string(content), "func Handler") {
+		t.Errorf("Expected only the Server type, got %q (keep %v, err %v)", content, keep, err)
+	}
+	for _, spec := range []string{"", "pkg/server", "pkg/server."} {
+		if _, err := newSymbolExtractor([]string{spec}); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
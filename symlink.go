@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// readlinkFS is implemented by file systems that can read the target of a
+// symlink. The tree shows the targets of symlinks if the FS implements it.
+type readlinkFS interface {
+	Readlink(name string) (string, error)
+}
+
+func (OSFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// Readlink returns the target of a symlink, which git stores as its content.
+func (t *treeFS) Readlink(name string) (string, error) {
+	e, err := t.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if e.mode != "120000" {
+		return "", fmt.Errorf("readlink %s: not a symlink", name)
+	}
+	target, err := t.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return string(target), nil
+}
+
+// symlinkTarget returns the target of the symlink at filePath as written in
+// the link, quoted if it contains control characters, or "" if it isn't a
+// symlink or its target can't be read.
+func (g *Git2LLM) symlinkTarget(filePath string) string {
+	fsys, ok := g.fs.(readlinkFS)
+	if !ok || !g.isSymlink(filePath) {
+		return ""
+	}
+	target, err := fsys.Readlink(filePath)
+	if err != nil {
+		g.logger.Debug("Error reading symlink", "path", filePath, "error", err)
+		return ""
+	}
+	if strings.ContainsFunc(target, unicode.IsControl) {
+		target = strconv.Quote(target)
+	}
+	return target
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMSymlinkTargets(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "shared", "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	for link, target := range map[string]string{"current.go": "main.go", "docs": "shared/docs"} {
+		if err := os.Symlink(target, filepath.Join(tempDir, link)); err != nil {
+			t.Skipf("Symlinks are not supported: %v", err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := Scan(git2llm)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	text := output.String()
+	for _, expected := range []string{
+		"├── current.go -> main.go\n",
+		"├── docs -> shared/docs\n",
+		"File: current.go (Symlink to main.go - skipped content)\n",
+		"Content of current.go: (Skipped - Symlink to main.go)\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q. Result:\n%s", expected, text)
+		}
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0].Path != "current.go" || result.Skipped[0].Reason != SkipSymlink || result.Skipped[0].Detail != "main.go" {
+		t.Errorf("Expected the symlinks to be skipped with their targets, got %+v", result.Skipped)
+	}
+}
+
+func TestTreeFSReadlink(t *testing.T) {
+	fsys := newTreeFS([]*treeEntry{
+		{path: "main.go", mode: "100644"},
+		{path: "link.go", mode: "120000"},
+	}, func(e *treeEntry) ([]byte, error) {
+		if e.path == "link.go" {
+			return []byte("main.go"), nil
+		}
+		return []byte("package main\n"), nil
+	})
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, fsys, &output, false, false, false, nil, "", false)
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "├── link.go -> main.go\n") {
+		t.Errorf("Expected the target of link.go in the tree. Result:\n%s", output.String())
+	}
+	if _, err := fsys.Readlink("main.go"); err == nil {
+		t.Error("Expected an error reading a file as a symlink")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// builtinTestPatterns are the patterns of test files by language, from the
+// embedded test-patterns.txt.
+var builtinTestPatterns = parseTestPatterns(testPatterns)
+
+// parseTestPatterns parses a list of test file patterns in a [section] per
+// language. Text after # is a comment; patterns outside a section are ignored.
+func parseTestPatterns(content string) map[string][]string {
+	sets := make(map[string][]string)
+	language := ""
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			language = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+		case line != "" && language != "":
+			sets[language] = append(sets[language], line)
+		}
+	}
+	return sets
+}
+
+// WithTestPatterns adds patterns of test files by language, e.g. conftest.py
+// for python, to the built-in ones used by -t and WithOnlyTests. Patterns of a
+// language without built-in ones add the language.
+func WithTestPatterns(patterns map[string][]string) Option {
+	return func(g *Git2LLM) {
+		sets := maps.Clone(g.testPatternsPerLanguage)
+		for language, set := range patterns {
+			language = strings.ToLower(language)
+			sets[language] = slices.Concat(sets[language], set)
+		}
+		g.testPatternsPerLanguage = sets
+	}
+}
+
+// WithTestLanguages only applies the test patterns of the given languages,
+// e.g. only go in a Go repository whose test_vectors.c holds data rather than
+// C tests. No languages means all of them.
+func WithTestLanguages(languages ...string) Option {
+	return func(g *Git2LLM) {
+		g.testLanguages = nil
+		for _, language := range languages {
+			g.testLanguages = append(g.testLanguages, strings.ToLower(language))
+		}
+	}
+}
+
+// testPatterns returns the patterns of test files of the selected languages.
+func (g *Git2LLM) testPatterns() map[string]bool {
+	languages := g.testLanguages
+	if len(languages) == 0 {
+		for language := range g.testPatternsPerLanguage {
+			languages = append(languages, language)
+		}
+	}
+	patterns := make(map[string]bool)
+	for _, language := range languages {
+		for _, pattern := range g.testPatternsPerLanguage[language] {
+			patterns[pattern] = true
+		}
+	}
+	return patterns
+}
+
+// parseTestPatternFlags parses the values of --test-pattern: comma separated
+// LANGUAGE=PATTERN pairs.
+func parseTestPatternFlags(values []string) (map[string][]string, error) {
+	patterns := make(map[string][]string)
+	for _, value := range values {
+		for _, spec := range strings.Split(value, ",") {
+			language, pattern, ok := strings.Cut(strings.TrimSpace(spec), "=")
+			if !ok || language == "" || pattern == "" {
+				return nil, fmt.Errorf("invalid --test-pattern %q: expected LANGUAGE=PATTERN", spec)
+			}
+			patterns[language] = append(patterns[language], pattern)
+		}
+	}
+	return patterns, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTestPatterns(t *testing.T) {
+	sets := parseTestPatterns("# Tests\nignored.txt\n[Go]\n*_test.go # Go tests\n\n[python]\ntest_*.py\nconftest.py\n")
+	expected := map[string][]string{"go": {"*_test.go"}, "python": {"test_*.py", "conftest.py"}}
+	if !reflect.DeepEqual(sets, expected) {
+		t.Errorf("Expected %v, got %v", expected, sets)
+	}
+	if len(builtinTestPatterns["go"]) == 0 || len(builtinTestPatterns["java"]) == 0 {
+		t.Errorf("Expected the built-in patterns by language, got %v", builtinTestPatterns)
+	}
+
+	if _, err := parseTestPatternFlags([]string{"python=conftest.py,elixir"}); err == nil {
+		t.Error("Expected an error for a pattern without a language")
+	}
+}
+
+func TestGit2LLMTestPatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"main.go", "main_test.go", "check.py", "db_check.py", "test_vectors.c"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content\n"), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		included []string
+	}{
+		{"built-in", nil, []string{"check.py", "db_check.py", "main.go"}},
+		{"added pattern", []Option{WithTestPatterns(map[string][]string{"Python": {"*_check.py"}})}, []string{"check.py", "main.go"}},
+		{"languages", []Option{WithTestLanguages("go")}, []string{"check.py", "db_check.py", "main.go", "test_vectors.c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output strings.Builder
+			git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, true, false, nil, "", false, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewGit2LLM failed: %v", err)
+			}
+			result, err := Scan(git2llm)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			var included []string
+			for _, f := range result.Files {
+				included = append(included, f.Path)
+			}
+			if !reflect.DeepEqual(included, tt.included) {
+				t.Errorf("Expected %v, got %v", tt.included, included)
+			}
+		})
+	}
+
+	_, err := NewGit2LLM(tempDir, nil, nil, &strings.Builder{}, false, true, false, nil, "", false, WithTestLanguages("cobol"))
+	if err == nil || !strings.Contains(err.Error(), "unknown test language cobol") {
+		t.Errorf("Expected an error for an unknown language, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithTableOfContents emits a table of contents between the directory tree and
+// the file contents, listing the line every file starts at.
+func WithTableOfContents(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.tableOfContents = enabled
+	}
+}
+
+// tocEntry is a file in the table of contents.
+type tocEntry struct {
+	path string
+	line int // Lines written to the spool before the file
+}
+
+// tableOfContents spools the file contents to a temporary file while recording
+// where every file starts, so that the table can be written before them.
+type tableOfContents struct {
+	spool   *spool
+	lines   lineCounter
+	entries []tocEntry
+}
+
+func newTableOfContents() (*tableOfContents, error) {
+	spool, err := newSpool()
+	if err != nil {
+		return nil, err
+	}
+	return &tableOfContents{spool: spool}, nil
+}
+
+func (t *tableOfContents) Write(p []byte) (int, error) {
+	t.lines.Write(p)
+	return t.spool.Write(p)
+}
+
+// add records that the file at path starts at line start of the spool.
+func (t *tableOfContents) add(path string, start int) {
+	t.entries = append(t.entries, tocEntry{path: path, line: start})
+}
+
+// writeTo writes the table, the contents header and the spooled contents to w.
+// headLines is the number of lines already written to w.
+func (t *tableOfContents) writeTo(w io.Writer, headLines int) error {
+	if _, err := fmt.Fprintln(w, "\n\nTable of Contents:"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, "------------------"); err != nil {
+		return fmt.Errorf("error writing to output file: %w", err)
+	}
+	// The table and both headers take 8 lines besides the entries
+	base := headLines + 8 + len(t.entries)
+	for _, e := range t.entries {
+		if _, err := fmt.Fprintf(w, "%s (line %d)\n", e.path, base+e.line+1); err != nil {
+			return fmt.Errorf("error writing to output file: %w", err)
+		}
+	}
+	if err := writeContentsHeader(w); err != nil {
+		return err
+	}
+	return t.spool.copyTo(w)
+}
+
+// close removes the spool file.
+func (t *tableOfContents) close() {
+	t.spool.close()
+}
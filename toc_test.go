@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMTableOfContents(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"pkg/util.go": "package pkg\n",
+		"README.md":   "# Title\n\nText\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithTableOfContents(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	tocStart := strings.Index(result, "Table of Contents:")
+	contentsStart := strings.Index(result, "File Contents:")
+	if tocStart < 0 || contentsStart < tocStart {
+		t.Fatalf("Expected the table of contents before the file contents, got:\n%s", result)
+	}
+	lines := strings.Split(result, "\n")
+	entry := regexp.MustCompile(`(?m)^(\S+) \(line (\d+)\)$`)
+	matches := entry.FindAllStringSubmatch(result[tocStart:contentsStart], -1)
+	if len(matches) != len(testFiles) {
+		t.Fatalf("Expected %d entries, got %d:\n%s", len(testFiles), len(matches), result)
+	}
+	for _, m := range matches {
+		n, _ := strconv.Atoi(m[2])
+		if n < 1 || n > len(lines) || lines[n-1] != fmt.Sprintf("File: %s", m[1]) {
+			t.Errorf("Expected line %d to start %s, got:\n%s", n, m[1], result)
+		}
+	}
+	if git2llm.outputWriter != &output {
+		t.Errorf("Expected the output writer to be restored after the scan")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+)
+
+// rawCacheVariant keeps the token counts of files as they are on disk apart
+// from the counts of the content written, which processFile caches.
+const rawCacheVariant = "raw"
+
+// WithMaxFileTokens leaves out the content of files with more than n tokens,
+// such as a single generated file that would take up most of the budget. 0
+// means no limit.
+func WithMaxFileTokens(n int) Option {
+	return func(g *Git2LLM) {
+		g.maxFileTokens = n
+	}
+}
+
+// fileTokens returns the number of tokens in the file at filePath as it is on
+// disk, from the token cache if the file hasn't changed since it was counted.
+func (g *Git2LLM) fileTokens(filePath string) (int, error) {
+	info, err := g.fs.Stat(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("stat: %w", err)
+	}
+	if g.tokenCache != nil {
+		if n, ok := g.tokenCache.get(g.model, rawCacheVariant, filePath, info); ok {
+			return n, nil
+		}
+	}
+	data, err := g.fs.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("read: %w", err)
+	}
+	n, err := g.counter.Count(string(data))
+	if err != nil {
+		return 0, fmt.Errorf("counter.Count: %w", err)
+	}
+	if g.tokenCache != nil {
+		g.tokenCache.put(g.model, rawCacheVariant, filePath, info, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanMaxFileTokens(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":        "package main\n",
+		"gen/schema.go":  "package gen\n\n" + strings.Repeat("var generated = []string{\"alpha\", \"beta\", \"gamma\"}\n", 50),
+		"vendor.min.txt": strings.Repeat("token ", 10),
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	for _, countTokens := range []bool{false, true} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, countTokens, nil, "estimate", false, WithMaxFileTokens(100))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		result, err := Scan(git2llm)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(result.Skipped) != 1 || result.Skipped[0].Path != "gen/schema.go" || result.Skipped[0].Reason != SkipTooLarge ||
+			!strings.HasSuffix(result.Skipped[0].Detail, " tokens, over the limit of 100") {
+			t.Errorf("Expected gen/schema.go to be skipped for its tokens, got %+v", result.Skipped)
+		}
+		text := output.String()
+		if !strings.Contains(text, "File: gen/schema.go (Too many tokens - skipped content)\n") || strings.Contains(text, "generated") {
+			t.Errorf("Expected the content of gen/schema.go to be left out. Result:\n%s", text)
+		}
+		if !strings.Contains(text, "File: main.go\n") || !strings.Contains(text, "File: vendor.min.txt\n") {
+			t.Errorf("Expected the files under the limit in the output. Result:\n%s", text)
+		}
+	}
+}
+
+func TestFileTokensCache(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(filePath, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := loadTokenCache(filepath.Join(t.TempDir(), tokenCacheFile))
+	// The count of the written content, as processFile caches it
+	cache.put("estimate", "", filePath, info, 999)
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, nil, false, false, false, nil, "estimate", false, withTokenCache(cache), WithMaxFileTokens(100))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	n, err := git2llm.fileTokens(filePath)
+	if err != nil {
+		t.Fatalf("fileTokens failed: %v", err)
+	}
+	if n == 999 {
+		t.Error("Expected the file to be counted, got the count of the written content")
+	}
+	if processed, _ := cache.get("estimate", "", filePath, info); processed != 999 {
+		t.Errorf("Expected the count of the written content to be kept, got %d", processed)
+	}
+}
@@ -0,0 +1,340 @@
+package tokens
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filePrefix selects a tokenizer.json file as model, e.g. "file:./tokenizer.json".
+const filePrefix = "file:"
+
+// openModelPrefixes are model families whose tokenizer is loaded from
+// <tokenizer dir>/<model>.json, see TokenizerDir.
+var openModelPrefixes = []string{"llama", "mistral", "mixtral", "qwen", "deepseek", "phi"}
+
+// maxWordBytes is the length of the pieces a longer pre-token is counted in,
+// such as a minified line that SentencePiece style tokenizers don't split. Few
+// merges span the cuts, so the count stays close at a bounded cost per piece.
+const maxWordBytes = 1024
+
+// byteLevelSplit approximates the pre-tokenizer regex of Llama 3 and Qwen. Go's
+// regexp has no lookahead, so the trailing space rule is applied in preTokenize.
+var byteLevelSplit = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// bpe counts tokens with a byte pair encoding read from a Hugging Face
+// tokenizer.json. Both byte-level (Llama 3, Qwen) and SentencePiece style
+// (Llama 2, Mistral) tokenizers are supported. It is safe for concurrent use.
+type bpe struct {
+	vocab     map[string]int
+	ranks     map[[2]string]int
+	byteLevel bool
+
+	mu    sync.Mutex
+	cache map[string]int // pre-token -> number of tokens
+}
+
+// isOpenModel reports whether model names an open model family with a local tokenizer.
+func isOpenModel(model string) bool {
+	for _, prefix := range openModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenizerDir returns the directory where tokenizer.json files of open models
+// are looked up: $GIT2LLM_TOKENIZER_DIR, or git2llm/tokenizers in the user cache directory.
+func TokenizerDir() (string, error) {
+	if dir := os.Getenv("GIT2LLM_TOKENIZER_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("os.UserCacheDir: %w", err)
+	}
+	return filepath.Join(dir, "git2llm", "tokenizers"), nil
+}
+
+// tokenizerFile returns the tokenizer.json to load for a "file:" or open model name.
+func tokenizerFile(model string) (string, error) {
+	if path, ok := strings.CutPrefix(model, filePrefix); ok {
+		return path, nil
+	}
+	dir, err := TokenizerDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, model+".json")
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no tokenizer for %s: download its tokenizer.json to %s or use -m file:PATH", model, path)
+	}
+	return path, nil
+}
+
+// loadBPE reads a Hugging Face tokenizer.json.
+func loadBPE(path string) (*bpe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var file struct {
+		Normalizer   json.RawMessage `json:"normalizer"`
+		PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+		Model        struct {
+			Type   string            `json:"type"`
+			Vocab  map[string]int    `json:"vocab"`
+			Merges []json.RawMessage `json:"merges"`
+		} `json:"model"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if file.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer model %q in %s, only BPE is supported", file.Model.Type, path)
+	}
+
+	b := &bpe{
+		vocab: file.Model.Vocab,
+		ranks: make(map[[2]string]int, len(file.Model.Merges)),
+		cache: make(map[string]int),
+	}
+	for rank, raw := range file.Model.Merges {
+		// Merges are either "a b" strings or ["a", "b"] pairs
+		var pair [2]string
+		var merge string
+		if err := json.Unmarshal(raw, &merge); err == nil {
+			left, right, ok := strings.Cut(merge, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid merge %q in %s", merge, path)
+			}
+			pair = [2]string{left, right}
+		} else if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("invalid merge %s in %s", raw, path)
+		}
+		if _, ok := b.ranks[pair]; !ok {
+			b.ranks[pair] = rank
+		}
+	}
+
+	switch {
+	case strings.Contains(string(file.PreTokenizer), "ByteLevel"):
+		b.byteLevel = true
+	case strings.Contains(string(file.PreTokenizer), "Metaspace"), strings.Contains(string(file.Normalizer), "▁"):
+	default:
+		return nil, fmt.Errorf("unsupported pre-tokenizer in %s, expected ByteLevel or Metaspace", path)
+	}
+	return b, nil
+}
+
+// count returns the number of tokens in text.
+func (b *bpe) count(text string) int {
+	n := 0
+	for _, word := range b.preTokenize(text) {
+		n += b.countWord(word)
+	}
+	return n
+}
+
+// preTokenize splits text into the pieces BPE is applied to independently.
+func (b *bpe) preTokenize(text string) []string {
+	var words []string
+	if !b.byteLevel {
+		// SentencePiece style: spaces become ▁ and every word starts with one
+		text = "▁" + strings.ReplaceAll(text, " ", "▁")
+		for len(text) > 0 {
+			next := strings.Index(text[len("▁"):], "▁")
+			if next < 0 {
+				words = append(words, text)
+				break
+			}
+			words = append(words, text[:next+len("▁")])
+			text = text[next+len("▁"):]
+		}
+		return words
+	}
+	for len(text) > 0 {
+		loc := byteLevelSplit.FindStringIndex(text)
+		if loc == nil {
+			words = append(words, text)
+			break
+		}
+		end := loc[1]
+		// \s+(?!\S): a run of spaces leaves its last space to the following word
+		if isSpace(text[loc[0]:end]) && end < len(text) {
+			if _, size := utf8.DecodeLastRuneInString(text[loc[0]:end]); end-size > loc[0] {
+				end -= size
+			}
+		}
+		if loc[0] > 0 {
+			words = append(words, text[:loc[0]])
+		}
+		words = append(words, text[loc[0]:end])
+		text = text[end:]
+	}
+	return words
+}
+
+func isSpace(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// countWord returns the number of tokens of a single pre-token, caching the result.
+func (b *bpe) countWord(word string) int {
+	if len(word) > maxWordBytes {
+		return b.countLongWord(word)
+	}
+	b.mu.Lock()
+	n, ok := b.cache[word]
+	b.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	var symbols []string
+	if b.byteLevel {
+		for i := 0; i < len(word); i++ {
+			symbols = append(symbols, byteLevelChars[word[i]])
+		}
+	} else {
+		for _, r := range word {
+			symbols = append(symbols, string(r))
+		}
+	}
+	symbols = b.merge(symbols)
+	for _, s := range symbols {
+		if _, ok := b.vocab[s]; ok || b.byteLevel {
+			n++
+		} else {
+			n += len(s) // Byte fallback: one token per byte
+		}
+	}
+
+	b.mu.Lock()
+	if len(b.cache) < 1<<16 {
+		b.cache[word] = n
+	}
+	b.mu.Unlock()
+	return n
+}
+
+// countLongWord counts a pre-token longer than maxWordBytes in pieces of at
+// most maxWordBytes, cut at rune boundaries.
+func (b *bpe) countLongWord(word string) int {
+	n := 0
+	for len(word) > maxWordBytes {
+		cut := maxWordBytes
+		for cut > 0 && !utf8.RuneStart(word[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxWordBytes
+		}
+		n += b.countWord(word[:cut])
+		word = word[cut:]
+	}
+	return n + b.countWord(word)
+}
+
+// merge repeatedly merges the adjacent pair of symbols with the lowest rank,
+// the leftmost one first. As in Hugging Face tokenizers, the symbols form a
+// linked list and the pairs that can be merged wait in a heap ordered by rank,
+// so a word of n symbols takes O(n log n) instead of a scan per merge.
+func (b *bpe) merge(symbols []string) []string {
+	if len(symbols) < 2 {
+		return symbols
+	}
+	prev := make([]int, len(symbols))
+	next := make([]int, len(symbols)) // -1 after the last symbol
+	for i := range symbols {
+		prev[i], next[i] = i-1, i+1
+	}
+	next[len(symbols)-1] = -1
+	var pairs mergeQueue
+	push := func(i int) {
+		if j := next[i]; j >= 0 {
+			if rank, ok := b.ranks[[2]string{symbols[i], symbols[j]}]; ok {
+				heap.Push(&pairs, mergePair{rank: rank, pos: i, left: symbols[i], right: symbols[j]})
+			}
+		}
+	}
+	for i := 0; i < len(symbols)-1; i++ {
+		push(i)
+	}
+	for pairs.Len() > 0 {
+		pair := heap.Pop(&pairs).(mergePair)
+		j := next[pair.pos]
+		if symbols[pair.pos] != pair.left || j < 0 || symbols[j] != pair.right {
+			continue // One of the symbols was merged since
+		}
+		symbols[pair.pos] += symbols[j]
+		symbols[j] = ""
+		next[pair.pos] = next[j]
+		if next[j] >= 0 {
+			prev[next[j]] = pair.pos
+		}
+		if prev[pair.pos] >= 0 {
+			push(prev[pair.pos])
+		}
+		push(pair.pos)
+	}
+	merged := symbols[:0]
+	for i := 0; i >= 0; i = next[i] {
+		merged = append(merged, symbols[i])
+	}
+	return merged
+}
+
+// mergePair is a pair of adjacent symbols that can be merged, starting at pos.
+type mergePair struct {
+	rank, pos   int
+	left, right string // To tell if the pair is still there when it is popped
+}
+
+// mergeQueue is a heap of pairs, lowest rank and then leftmost first.
+type mergeQueue []mergePair
+
+func (q mergeQueue) Len() int { return len(q) }
+func (q mergeQueue) Less(i, j int) bool {
+	if q[i].rank != q[j].rank {
+		return q[i].rank < q[j].rank
+	}
+	return q[i].pos < q[j].pos
+}
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *mergeQueue) Push(x any)   { *q = append(*q, x.(mergePair)) }
+func (q *mergeQueue) Pop() any {
+	old := *q
+	pair := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return pair
+}
+
+// byteLevelChars maps every byte to the printable character used for it by
+// byte-level BPE vocabularies (as introduced by GPT-2).
+var byteLevelChars = func() [256]string {
+	var chars [256]string
+	n := 0
+	for i := 0; i < 256; i++ {
+		if (i >= '!' && i <= '~') || (i >= 0xA1 && i <= 0xAC) || (i >= 0xAE && i <= 0xFF) {
+			chars[i] = string(rune(i))
+		} else {
+			chars[i] = string(rune(256 + n))
+			n++
+		}
+	}
+	return chars
+}()
@@ -0,0 +1,155 @@
+package tokens
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const byteLevelTokenizer = `{
+	"pre_tokenizer": {"type": "Sequence", "pretokenizers": [{"type": "Split"}, {"type": "ByteLevel"}]},
+	"model": {
+		"type": "BPE",
+		"vocab": {"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "w": 5, "r": 6, "d": 7, "he": 8, "ll": 9, "hell": 10, "hello": 11, "Ġw": 12},
+		"merges": [["h", "e"], ["l", "l"], ["he", "ll"], ["hell", "o"], ["Ġ", "w"]]
+	}
+}`
+
+const metaspaceTokenizer = `{
+	"normalizer": {"type": "Sequence", "normalizers": [{"type": "Prepend", "prepend": "▁"}, {"type": "Replace", "content": "▁"}]},
+	"pre_tokenizer": null,
+	"model": {
+		"type": "BPE",
+		"vocab": {"▁": 0, "h": 1, "i": 2, "▁h": 3, "▁hi": 4},
+		"merges": ["▁ h", "▁h i"]
+	}
+}`
+
+func writeTokenizer(t testing.TB, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokenizer.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write tokenizer: %v", err)
+	}
+	return path
+}
+
+func TestByteLevelBPE(t *testing.T) {
+	counter, err := New("file:" + writeTokenizer(t, byteLevelTokenizer))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// "hello" is a single token, " world" is Ġw o r l d
+	if n, err := counter.Count("hello world"); err != nil || n != 6 {
+		t.Errorf("Expected 6 tokens, got %d (err: %v)", n, err)
+	}
+
+	b := counter.tok.bpe // Loaded by Count
+	if words := b.preTokenize("a  b"); !reflect.DeepEqual(words, []string{"a", " ", " b"}) {
+		t.Errorf("Expected the last space to stay with the next word, got %q", words)
+	}
+}
+
+func TestMetaspaceBPE(t *testing.T) {
+	counter, err := New("file:" + writeTokenizer(t, metaspaceTokenizer))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if n, err := counter.Count("hi hi"); err != nil || n != 2 {
+		t.Errorf("Expected 2 tokens, got %d (err: %v)", n, err)
+	}
+	// é is not in the vocabulary and falls back to its two bytes
+	if n, err := counter.Count("hi é"); err != nil || n != 4 {
+		t.Errorf("Expected 4 tokens with byte fallback, got %d (err: %v)", n, err)
+	}
+}
+
+func TestBPEMerge(t *testing.T) {
+	counter, err := New("file:" + writeTokenizer(t, byteLevelTokenizer))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := counter.Count("hello"); err != nil { // Loads the tokenizer
+		t.Fatalf("Count failed: %v", err)
+	}
+	b := counter.tok.bpe
+	testCases := []struct {
+		symbols []string
+		want    []string
+	}{
+		{[]string{"h", "e", "l", "l", "o", "h", "e", "l", "l", "o"}, []string{"hello", "hello"}},
+		{[]string{"l", "l", "l"}, []string{"ll", "l"}}, // Ties go to the leftmost pair
+		{[]string{"h", "e", "l", "l", "l", "o"}, []string{"hell", "l", "o"}},
+		{[]string{"w", "o"}, []string{"w", "o"}},
+		{[]string{"h"}, []string{"h"}},
+	}
+	for _, tc := range testCases {
+		if got := b.merge(append([]string(nil), tc.symbols...)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("merge(%q) = %q, want %q", tc.symbols, got, tc.want)
+		}
+	}
+}
+
+func TestMetaspaceBPELongWord(t *testing.T) {
+	counter, err := New("file:" + writeTokenizer(t, metaspaceTokenizer))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// A minified line without spaces is a single word, counted in pieces: ▁hi and then h and i
+	text := strings.Repeat("hi", 20000)
+	if n, err := counter.Count(text); err != nil || n != 39999 {
+		t.Errorf("Expected 39999 tokens, got %d (err: %v)", n, err)
+	}
+}
+
+func TestOpenModelTokenizerDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GIT2LLM_TOKENIZER_DIR", dir)
+	if _, err := New("llama3"); err == nil {
+		t.Fatalf("Expected an error without a tokenizer for llama3")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "llama3.json"), []byte(byteLevelTokenizer), 0644); err != nil {
+		t.Fatalf("Failed to write tokenizer: %v", err)
+	}
+	counter, err := New("llama3")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if counter.Model() != "llama3" {
+		t.Errorf("Expected model llama3, got %s", counter.Model())
+	}
+}
+
+func BenchmarkBPE(b *testing.B) {
+	counter, err := New("file:" + writeTokenizer(b, byteLevelTokenizer))
+	if err != nil {
+		b.Fatalf("New failed: %v", err)
+	}
+	text := strings.Repeat("hello world, hello everyone\n", 200)
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		counter.Count(text)
+	}
+}
+
+func BenchmarkBPELongLine(b *testing.B) {
+	// Every run of a doubles in length with each merge, as in a minified line
+	counter, err := New("file:" + writeTokenizer(b, `{
+	"pre_tokenizer": {"type": "Metaspace"},
+	"model": {
+		"type": "BPE",
+		"vocab": {"▁": 0, "a": 1, "aa": 2, "aaaa": 3, "aaaaaaaa": 4},
+		"merges": ["a a", "aa aa", "aaaa aaaa"]
+	}
+}`))
+	if err != nil {
+		b.Fatalf("New failed: %v", err)
+	}
+	text := strings.Repeat("a", 40000)
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		counter.Count(text)
+	}
+}
@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"hello world", 4},           // hel|lo, wor|ld
+		{"x := 1\n", 4},              // x, :=, 1, newline
+		{"\t\treturn nil\n", 5},      // indentation, ret|urn, nil, newline
+		{"func main() {}", 4},        // func, main, (), {}
+		{"日本語", 3},                   // one token per CJK character
+		{"====================", 10}, // punctuation runs cost one token per two characters
+	}
+	for _, tc := range testCases {
+		if n := estimate(tc.text); n != tc.expected {
+			t.Errorf("estimate(%q) = %d, expected %d", tc.text, n, tc.expected)
+		}
+	}
+}
+
+func TestEstimateCounter(t *testing.T) {
+	counter, err := New(EstimateModel)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	text := strings.Repeat(`{"key": "value"}`+"\n", 100)
+	plain, err := counter.Count(text)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	calibrated, err := counter.ForFile("data.json").Count(text)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if calibrated <= plain {
+		t.Errorf("Expected JSON to be calibrated upwards, got %d for %d", calibrated, plain)
+	}
+	if n, _ := counter.ForFile("main.unknown").Count(text); n != plain {
+		t.Errorf("Expected unknown file types to be uncalibrated, got %d instead of %d", n, plain)
+	}
+}
+
+func BenchmarkEstimate(b *testing.B) {
+	text := strings.Repeat("func f(x int) int {\n\treturn x * 2 // double\n}\n\n", 200)
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		estimate(text)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	counter, err := New("cl100k_base")
+	if err != nil {
+		b.Fatalf("New failed: %v", err)
+	}
+	text := strings.Repeat("func f(x int) int {\n\treturn x * 2 // double\n}\n\n", 200)
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		counter.Count(text)
+	}
+}
@@ -0,0 +1,78 @@
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	genaitok "cloud.google.com/go/vertexai/genai/tokenizer"
+)
+
+const (
+	// gemmaModelURL is the tokenizer model of Gemini, downloaded by the
+	// tokenizer of the genai package on first use.
+	gemmaModelURL = "https://raw.githubusercontent.com/google/gemma_pytorch/33b652c465537c6158f9a472ea5700e5e770ad3f/tokenizer/tokenizer.model"
+	// gemmaModelHash is the SHA-256 of the model file.
+	gemmaModelHash = "61a7b147390c64585d6c3543dd6fc636906c9af3865a5548f27f31aee1d4c8e2"
+	// gemmaModelFile is the name of the copy kept in TokenizerDir.
+	gemmaModelFile = "gemma-tokenizer.model"
+)
+
+// genaiModelCachePath returns the file the tokenizer package of vertexai/genai
+// caches the model downloaded from gemmaModelURL in: $TMPDIR/vertexai_tokenizer_model
+// and the SHA-256 of the URL. The layout is private to the package, so it is
+// pinned by TestGenaiModelCachePath against the version in go.mod.
+func genaiModelCachePath() string {
+	return filepath.Join(os.TempDir(), "vertexai_tokenizer_model", gemmaHash([]byte(gemmaModelURL)))
+}
+
+// loadGemini returns the tokenizer of a Gemini model. The genai package caches
+// the model file it downloads in the temporary directory, which is cleaned up
+// now and then, so a copy is kept in TokenizerDir and put back from there.
+// Offline, a model that is in neither place is an error instead of a download.
+func loadGemini(model string, offline bool) (*genaitok.Tokenizer, error) {
+	tmpPath := genaiModelCachePath()
+	dir, err := TokenizerDir()
+	if err != nil {
+		return nil, err
+	}
+	keptPath := filepath.Join(dir, gemmaModelFile)
+
+	if !validGemmaModel(tmpPath) {
+		if data, err := os.ReadFile(keptPath); err == nil && gemmaHash(data) == gemmaModelHash {
+			if err := os.MkdirAll(filepath.Dir(tmpPath), 0770); err != nil {
+				return nil, fmt.Errorf("os.MkdirAll: %w", err)
+			}
+			if err := os.WriteFile(tmpPath, data, 0660); err != nil {
+				return nil, fmt.Errorf("os.WriteFile: %w", err)
+			}
+		} else if offline {
+			return nil, fmt.Errorf("the tokenizer of %s is not cached in %s and downloading it is not allowed offline, run once online or use -m estimate", model, keptPath)
+		}
+	}
+
+	genc, err := genaitok.New(model)
+	if err != nil {
+		return nil, fmt.Errorf("vertexai/genai/tokenizer.New: %w", err)
+	}
+	if _, err := os.Stat(keptPath); err != nil {
+		// Keeping a copy is best effort; the tokenizer works without it
+		if data, err := os.ReadFile(tmpPath); err == nil && os.MkdirAll(dir, 0755) == nil {
+			os.WriteFile(keptPath, data, 0644)
+		}
+	}
+	return genc, nil
+}
+
+// validGemmaModel reports whether the file at path is the Gemini tokenizer model.
+func validGemmaModel(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && gemmaHash(data) == gemmaModelHash
+}
+
+func gemmaHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package tokens
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenaiModelCachePath checks that the tokenizer package of vertexai/genai,
+// in the version of go.mod, still caches the model where genaiModelCachePath
+// expects it.
+func TestGenaiModelCachePath(t *testing.T) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", "cloud.google.com/go/vertexai/genai/tokenizer").Output()
+	if err != nil {
+		t.Skipf("Can't locate the genai tokenizer package: %v", err)
+	}
+	source, err := os.ReadFile(filepath.Join(strings.TrimSpace(string(out)), "tokenizer.go"))
+	if err != nil {
+		t.Fatalf("Failed to read the genai tokenizer: %v", err)
+	}
+	for _, want := range []string{
+		`const gemmaModelURL = "` + gemmaModelURL + `"`,
+		`const gemmaModelHash = "` + gemmaModelHash + `"`,
+		`loadModelData(gemmaModelURL, gemmaModelHash)`,
+		`urlhash := hashString([]byte(url))`,
+		`cacheDir := filepath.Join(os.TempDir(), "vertexai_tokenizer_model")`,
+		`cachePath := filepath.Join(cacheDir, urlhash)`,
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("The genai tokenizer no longer contains %s, update genaiModelCachePath", want)
+		}
+	}
+
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	expected := filepath.Join(tmp, "vertexai_tokenizer_model", "586be7b5260185f98071b243a6c45118739cd8d22464c3f58ce3093142bd6b52")
+	if got := genaiModelCachePath(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
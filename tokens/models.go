@@ -0,0 +1,56 @@
+package tokens
+
+// ModelInfo describes a model that -m accepts by name.
+type ModelInfo struct {
+	Name          string
+	Encoding      string  // Encoding used to count tokens; "" if the name selects the tokenizer itself
+	ContextWindow int     // Input tokens the model accepts
+	InputPrice    float64 // USD per million input tokens, 0 if unknown
+}
+
+// models is the registry of known models. Prices are list prices at the time
+// of writing and only meant for estimates. Claude's tokenizer is not public,
+// so its models are counted with cl100k_base, which is close but not exact.
+var models = map[string]ModelInfo{
+	"gpt-4.1":       {Encoding: "o200k_base", ContextWindow: 1047576, InputPrice: 2.00},
+	"gpt-4.1-mini":  {Encoding: "o200k_base", ContextWindow: 1047576, InputPrice: 0.40},
+	"gpt-4.1-nano":  {Encoding: "o200k_base", ContextWindow: 1047576, InputPrice: 0.10},
+	"gpt-4o":        {Encoding: "o200k_base", ContextWindow: 128000, InputPrice: 2.50},
+	"gpt-4o-mini":   {Encoding: "o200k_base", ContextWindow: 128000, InputPrice: 0.15},
+	"o1":            {Encoding: "o200k_base", ContextWindow: 200000, InputPrice: 15.00},
+	"o3":            {Encoding: "o200k_base", ContextWindow: 200000, InputPrice: 2.00},
+	"o3-mini":       {Encoding: "o200k_base", ContextWindow: 200000, InputPrice: 1.10},
+	"o4-mini":       {Encoding: "o200k_base", ContextWindow: 200000, InputPrice: 1.10},
+	"gpt-4-turbo":   {Encoding: "cl100k_base", ContextWindow: 128000, InputPrice: 10.00},
+	"gpt-4":         {Encoding: "cl100k_base", ContextWindow: 8192, InputPrice: 30.00},
+	"gpt-3.5-turbo": {Encoding: "cl100k_base", ContextWindow: 16385, InputPrice: 0.50},
+
+	"claude-opus-4-20250514":   {Encoding: "cl100k_base", ContextWindow: 200000, InputPrice: 15.00},
+	"claude-sonnet-4-20250514": {Encoding: "cl100k_base", ContextWindow: 200000, InputPrice: 3.00},
+	"claude-3-7-sonnet-latest": {Encoding: "cl100k_base", ContextWindow: 200000, InputPrice: 3.00},
+	"claude-3-5-haiku-latest":  {Encoding: "cl100k_base", ContextWindow: 200000, InputPrice: 0.80},
+
+	"gemini-2.5-pro":        {ContextWindow: 1048576, InputPrice: 1.25},
+	"gemini-2.5-flash":      {ContextWindow: 1048576, InputPrice: 0.30},
+	"gemini-2.0-flash":      {ContextWindow: 1048576, InputPrice: 0.10},
+	"gemini-2.0-flash-lite": {ContextWindow: 1048576, InputPrice: 0.075},
+	"gemini-1.5-pro":        {ContextWindow: 2097152, InputPrice: 1.25},
+	"gemini-1.5-flash":      {ContextWindow: 1048576, InputPrice: 0.075},
+
+	"llama3":   {ContextWindow: 8192},
+	"llama3.1": {ContextWindow: 131072},
+	"mistral":  {ContextWindow: 32768},
+	"qwen2.5":  {ContextWindow: 131072},
+}
+
+// Lookup returns the registry entry of model.
+func Lookup(model string) (ModelInfo, bool) {
+	info, ok := models[model]
+	info.Name = model
+	return info, ok
+}
+
+// Cost returns the estimated price in USD of sending n input tokens to the model.
+func (m ModelInfo) Cost(n int) float64 {
+	return float64(n) * m.InputPrice / 1e6
+}
@@ -0,0 +1,66 @@
+package tokens
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestModelRegistry(t *testing.T) {
+	counter, err := New("gpt-4o")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	tok, err := counter.tok.get()
+	if err != nil {
+		t.Fatalf("Loading the tokenizer failed: %v", err)
+	}
+	if tok.encoding == nil || tok.encoding.GetName() != "o200k_base" {
+		t.Errorf("Expected gpt-4o to be counted with o200k_base")
+	}
+	if counter.Model() != "gpt-4o" {
+		t.Errorf("Expected model gpt-4o, got %s", counter.Model())
+	}
+
+	info, ok := Lookup("gpt-4o")
+	if !ok || info.Name != "gpt-4o" || info.ContextWindow != 128000 {
+		t.Errorf("Unexpected registry entry %+v", info)
+	}
+	if cost := info.Cost(2_000_000); cost != 5.0 {
+		t.Errorf("Expected 2M tokens to cost $5, got %v", cost)
+	}
+	if _, ok := Lookup("cl100k_base"); ok {
+		t.Errorf("Expected encodings not to be in the registry")
+	}
+}
+
+func TestNewIsLazy(t *testing.T) {
+	path := writeTokenizer(t, byteLevelTokenizer)
+	counter, err := New("file:" + path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// The file is only read on first use
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	counter.Warm()
+	if _, err := counter.Count("hello"); err == nil || !strings.Contains(err.Error(), "error loading the tokenizer") {
+		t.Errorf("Expected the loading error from Count, got %v", err)
+	}
+	if _, err := New("no_such_encoding"); err == nil {
+		t.Error("Expected an error for an unknown encoding")
+	}
+}
+
+func TestGeminiOffline(t *testing.T) {
+	t.Setenv("GIT2LLM_TOKENIZER_DIR", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	counter, err := New("gemini-1.5-pro", WithOffline(true))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := counter.Count("hello"); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Errorf("Expected an error for the uncached tokenizer, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package tokens
+
+import (
+	"sync"
+	"time"
+)
+
+// Retries of tokenizers that can fail, such as Gemini's. The backoff doubles after every attempt.
+var (
+	countRetries = 3
+	countBackoff = 200 * time.Millisecond
+)
+
+// fallbackStats records the text that was estimated because the tokenizer failed.
+// It is shared by all copies of a Counter.
+type fallbackStats struct {
+	mu     sync.Mutex
+	chunks int
+	err    error
+}
+
+// countWithRetry counts text with count, retrying with backoff. If all attempts
+// fail, the text is estimated instead and the failure is recorded, so one
+// error doesn't abort the scan; see Fallbacks.
+func (c Counter) countWithRetry(count func(string) (int, error), text string) int {
+	backoff := countBackoff
+	var err error
+	for attempt := 0; attempt <= countRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		var n int
+		if n, err = count(text); err == nil {
+			return n
+		}
+	}
+	c.fallback.mu.Lock()
+	c.fallback.chunks++
+	c.fallback.err = err
+	c.fallback.mu.Unlock()
+	return c.estimate(text)
+}
+
+// Fallbacks returns the number of chunks of text that were estimated because
+// the tokenizer kept failing, and the last error. Only the Gemini tokenizer falls back.
+func (c Counter) Fallbacks() (int, error) {
+	if c.fallback == nil {
+		return 0, nil
+	}
+	c.fallback.mu.Lock()
+	defer c.fallback.mu.Unlock()
+	return c.fallback.chunks, c.fallback.err
+}
@@ -0,0 +1,43 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCountWithRetry(t *testing.T) {
+	defer func(backoff time.Duration) { countBackoff = backoff }(countBackoff)
+	countBackoff = time.Millisecond
+
+	c := Counter{model: "gemini-test", fallback: &fallbackStats{}}
+	calls := 0
+	flaky := func(text string) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("unavailable")
+		}
+		return 42, nil
+	}
+	if n := c.countWithRetry(flaky, "some text"); n != 42 || calls != 3 {
+		t.Errorf("Expected 42 after 3 calls, got %d after %d calls", n, calls)
+	}
+	if chunks, _ := c.Fallbacks(); chunks != 0 {
+		t.Errorf("Expected no fallback, got %d", chunks)
+	}
+
+	// A copy shares the statistics, as the writers of a pool do
+	copied := c
+	failing := func(text string) (int, error) { return 0, errors.New("quota exceeded") }
+	text := "func main() { fmt.Println(\"hello\") }"
+	if n := copied.countWithRetry(failing, text); n != estimate(text) {
+		t.Errorf("Expected the estimate %d, got %d", estimate(text), n)
+	}
+	chunks, err := c.Fallbacks()
+	if chunks != 1 || err == nil || err.Error() != "quota exceeded" {
+		t.Errorf("Expected one fallback with the last error, got %d, %v", chunks, err)
+	}
+	if chunks, err := (Counter{}).Fallbacks(); chunks != 0 || err != nil {
+		t.Errorf("Expected no fallbacks for other counters, got %d, %v", chunks, err)
+	}
+}
@@ -7,45 +7,150 @@ import (
 	"fmt"
 	"github.com/tiktoken-go/tokenizer"
 	"strings"
+	"sync"
 )
 
 type Counter struct {
+	model    string
+	factor   float64 // calibration of the estimator, see ForFile
+	fallback *fallbackStats
+	tok      *lazyTokenizer
+}
+
+// lazyTokenizer loads a tokenizer on first use, so a scan that fails before
+// counting anything doesn't pay for reading a vocabulary or downloading a
+// model. It is shared by all copies of a Counter.
+type lazyTokenizer struct {
+	once      sync.Once
+	load      func(t *lazyTokenizer) error
+	err       error
 	encoding  tokenizer.Codec
-	model     string
 	gencoding *genaitok.Tokenizer
+	bpe       *bpe
 }
 
-func New(model string) (*Counter, error) {
-	if strings.HasPrefix(model, "gemini") {
-		genc, err := genaitok.New(model)
+// get returns the tokenizer, loading it if that didn't happen yet.
+func (t *lazyTokenizer) get() (*lazyTokenizer, error) {
+	t.once.Do(func() {
+		t.err = t.load(t)
+	})
+	return t, t.err
+}
+
+// Option configures a Counter, see New.
+type Option func(*options)
+
+type options struct {
+	offline bool
+}
+
+// WithOffline forbids downloading tokenizers, so counting with a model whose
+// tokenizer isn't cached yet fails instead.
+func WithOffline(enabled bool) Option {
+	return func(o *options) {
+		o.offline = enabled
+	}
+}
+
+// encodings are the OpenAI encodings tokenizer.Get supports.
+var encodings = map[string]bool{
+	string(tokenizer.O200kBase):  true,
+	string(tokenizer.Cl100kBase): true,
+	string(tokenizer.R50kBase):   true,
+	string(tokenizer.P50kBase):   true,
+	string(tokenizer.P50kEdit):   true,
+}
+
+// New returns a counter for model: an OpenAI encoding such as cl100k_base, a
+// model from the registry such as gpt-4o, which selects its encoding, a
+// Gemini model, an open model such as llama3 whose tokenizer.json is in
+// TokenizerDir, "file:" followed by the path of a tokenizer.json, or "estimate"
+// for a fast heuristic that needs no tokenizer at all. The name is checked
+// right away, but the tokenizer is only loaded when it is first used or
+// warmed up, see Warm.
+func New(model string, opts ...Option) (*Counter, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if model == EstimateModel {
+		return &Counter{model: model}, nil
+	}
+	encoding := model
+	if info, ok := models[model]; ok && info.Encoding != "" {
+		encoding = info.Encoding
+	}
+	if encodings[encoding] {
+		return &Counter{model: model, tok: &lazyTokenizer{load: func(t *lazyTokenizer) error {
+			enc, err := tokenizer.Get(tokenizer.Encoding(encoding))
+			if err != nil {
+				return fmt.Errorf("tokenizer.Get: %w", err)
+			}
+			t.encoding = enc
+			return nil
+		}}}, nil
+	}
+	if strings.HasPrefix(model, filePrefix) || isOpenModel(model) {
+		path, err := tokenizerFile(model)
 		if err != nil {
-			return nil, fmt.Errorf("vertexai/genai/tokenizer.New: %w", err)
+			return nil, err
 		}
-		return &Counter{
-			gencoding: genc,
-			model:     model,
-		}, nil
+		return &Counter{model: model, tok: &lazyTokenizer{load: func(t *lazyTokenizer) error {
+			b, err := loadBPE(path)
+			if err != nil {
+				return fmt.Errorf("loadBPE: %w", err)
+			}
+			t.bpe = b
+			return nil
+		}}}, nil
 	}
 
-	enc, err := tokenizer.Get(tokenizer.Encoding(model))
-	if err != nil {
-		return nil, fmt.Errorf("tokenizer.Get: %w", err)
+	if strings.HasPrefix(model, "gemini") {
+		return &Counter{model: model, fallback: &fallbackStats{}, tok: &lazyTokenizer{load: func(t *lazyTokenizer) error {
+			genc, err := loadGemini(model, o.offline)
+			if err != nil {
+				return err
+			}
+			t.gencoding = genc
+			return nil
+		}}}, nil
+	}
+	return nil, fmt.Errorf("tokenizer.Get: %w", tokenizer.ErrEncodingNotSupported)
+}
+
+// Warm starts loading the tokenizer in the background, so it is ready by the
+// time the first text is counted. Errors are returned by Count.
+func (c Counter) Warm() {
+	if c.tok != nil {
+		go c.tok.get()
 	}
-	return &Counter{
-		encoding: enc,
-		model:    model,
-	}, nil
 }
 
 func (c Counter) Count(text string) (int, error) {
-	if c.gencoding != nil {
-		resp, err := c.gencoding.CountTokens(genai.Text(text))
-		if err != nil {
-			return 0, fmt.Errorf("vertexai/genai/tokenizer.CountTokens: %w", err)
-		}
-		return int(resp.TotalTokens), nil
+	if c.model == EstimateModel {
+		return c.estimate(text), nil
+	}
+	t, err := c.tok.get()
+	if err != nil {
+		return 0, fmt.Errorf("error loading the tokenizer of %s: %w", c.model, err)
+	}
+	if t.bpe != nil {
+		return t.bpe.count(text), nil
+	}
+	if t.gencoding != nil {
+		return c.countWithRetry(func(text string) (int, error) {
+			return countGemini(t.gencoding, text)
+		}, text), nil
+	}
+	return t.encoding.Count(text)
+}
+
+func countGemini(genc *genaitok.Tokenizer, text string) (int, error) {
+	resp, err := genc.CountTokens(genai.Text(text))
+	if err != nil {
+		return 0, fmt.Errorf("vertexai/genai/tokenizer.CountTokens: %w", err)
 	}
-	return c.encoding.Count(text)
+	return int(resp.TotalTokens), nil
 }
 
 func (c Counter) Model() string {
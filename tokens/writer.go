@@ -0,0 +1,167 @@
+package tokens
+
+import (
+	"bytes"
+	"sync"
+	"unicode/utf8"
+)
+
+// chunkSize is the amount of text buffered before it is tokenized.
+const chunkSize = 64 * 1024
+
+// Writer counts the tokens of everything written to it. Text is tokenized in
+// chunks cut at line boundaries, so memory stays bounded regardless of input size.
+// A Writer created by a Pool counts its chunks on the pool's workers.
+type Writer struct {
+	counter Counter
+	pool    *Pool
+	buf     []byte
+	mu      sync.Mutex
+	tokens  int
+	err     error
+	pending sync.WaitGroup
+}
+
+// NewWriter returns a Writer that counts tokens synchronously using c.
+func (c Counter) NewWriter() *Writer {
+	return &Writer{counter: c}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if err := w.firstErr(); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= chunkSize {
+		cut := bytes.LastIndexByte(w.buf[:chunkSize], '\n') + 1
+		if cut == 0 {
+			// No newline in the chunk, cut at a rune boundary instead.
+			cut = chunkSize
+			for cut > 0 && !utf8.RuneStart(w.buf[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				cut = chunkSize
+			}
+		}
+		w.count(string(w.buf[:cut]))
+		w.buf = w.buf[:copy(w.buf, w.buf[cut:])]
+	}
+	return len(p), w.firstErr()
+}
+
+// Flush counts any buffered text that has not been tokenized yet.
+func (w *Writer) Flush() error {
+	if len(w.buf) > 0 {
+		w.count(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+	return w.firstErr()
+}
+
+// Wait flushes the writer, waits for all chunks to be counted and returns the total.
+func (w *Writer) Wait() (int, error) {
+	w.Flush()
+	w.pending.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tokens, w.err
+}
+
+// Finish flushes the writer and calls fn with the total once all chunks are
+// counted, without blocking the caller if the writer belongs to a pool.
+func (w *Writer) Finish(fn func(tokens int, err error)) {
+	if w.pool == nil {
+		fn(w.Wait())
+		return
+	}
+	w.Flush()
+	w.pool.callbacks.Add(1)
+	go func() {
+		defer w.pool.callbacks.Done()
+		fn(w.Wait())
+	}()
+}
+
+// SetFile calibrates the counter for the file the text comes from, see Counter.ForFile.
+// It must be called before the first Write.
+func (w *Writer) SetFile(name string) {
+	w.counter = w.counter.ForFile(name)
+}
+
+// Tokens returns the number of tokens counted so far. Call Wait to include pending chunks.
+func (w *Writer) Tokens() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tokens
+}
+
+func (w *Writer) count(chunk string) {
+	if w.pool == nil {
+		w.add(w.counter.Count(chunk))
+		return
+	}
+	w.pending.Add(1)
+	w.pool.jobs <- func() {
+		defer w.pending.Done()
+		w.add(w.counter.Count(chunk))
+	}
+}
+
+func (w *Writer) add(n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.tokens += n
+}
+
+func (w *Writer) firstErr() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Pool tokenizes text on a fixed number of worker goroutines, so counting can
+// proceed in parallel with reading and writing.
+type Pool struct {
+	counter   Counter
+	jobs      chan func()
+	workers   sync.WaitGroup
+	callbacks sync.WaitGroup
+}
+
+// NewPool starts a pool with the given number of workers counting with c.
+func (c Counter) NewPool(workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{counter: c, jobs: make(chan func(), workers*2)}
+	for i := 0; i < workers; i++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// NewWriter returns a Writer whose chunks are counted by the pool.
+func (p *Pool) NewWriter() *Writer {
+	return &Writer{counter: p.counter, pool: p}
+}
+
+// Close waits for all pending counts and Finish callbacks, then stops the workers.
+// Writers of the pool must not be used after Close.
+func (p *Pool) Close() {
+	p.callbacks.Wait()
+	close(p.jobs)
+	p.workers.Wait()
+}
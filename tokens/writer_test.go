@@ -0,0 +1,174 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// sourceText returns about size bytes of code-like lines.
+func sourceText(size int) string {
+	var b strings.Builder
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "func handler%d(w http.ResponseWriter, r *http.Request) { fmt.Fprintln(w, %d) }\n", i, i)
+	}
+	return b.String()
+}
+
+// writeAll writes text to w in pieces of n bytes, as io.Copy would.
+func writeAll(t *testing.T, w *Writer, text string, n int) {
+	t.Helper()
+	for len(text) > 0 {
+		piece := text[:min(n, len(text))]
+		if _, err := w.Write([]byte(piece)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		text = text[len(piece):]
+	}
+}
+
+func newCounter(t *testing.T) *Counter {
+	t.Helper()
+	counter, err := New("cl100k_base")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return counter
+}
+
+func TestWriterPoolMatchesSync(t *testing.T) {
+	counter := newCounter(t)
+	text := sourceText(5*chunkSize + 123)
+	expected, err := counter.Count(text)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+
+	w := counter.NewWriter()
+	writeAll(t, w, text, 4096)
+	if w.Tokens() == 0 {
+		t.Errorf("Expected the full chunks to be counted while writing")
+	}
+	if n, err := w.Wait(); err != nil || n != expected {
+		t.Errorf("Expected %d tokens from the writer, got %d (err: %v)", expected, n, err)
+	}
+
+	pool := counter.NewPool(4)
+	results := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		w := pool.NewWriter()
+		writeAll(t, w, text, 32*1024)
+		w.Finish(func(n int, err error) {
+			if err != nil {
+				t.Errorf("Finish failed: %v", err)
+			}
+			results <- n
+		})
+	}
+	pool.Close()
+	close(results)
+	for n := range results {
+		if n != expected {
+			t.Errorf("Expected %d tokens from the pool, got %d", expected, n)
+		}
+	}
+}
+
+func TestWriterChunks(t *testing.T) {
+	counter := newCounter(t)
+	countChunks := func(chunks ...string) int {
+		total := 0
+		for _, c := range chunks {
+			n, err := counter.Count(c)
+			if err != nil {
+				t.Fatalf("Count failed: %v", err)
+			}
+			total += n
+		}
+		return total
+	}
+	noNewlines := strings.Repeat("abcdefg ", chunkSize/8+1000)
+	// A 3 byte rune straddles chunkSize
+	multibyte := strings.Repeat("日本語 ", chunkSize/10+1000)
+	for utf8.RuneStart(multibyte[chunkSize]) {
+		multibyte = "x" + multibyte
+	}
+	cut := chunkSize
+	for !utf8.RuneStart(multibyte[cut]) {
+		cut--
+	}
+	lines := sourceText(2 * chunkSize)
+	var lineChunks []string
+	for rest := lines; len(rest) > 0; {
+		cut := len(rest)
+		if cut >= chunkSize {
+			cut = strings.LastIndexByte(rest[:chunkSize], '\n') + 1
+		}
+		lineChunks = append(lineChunks, rest[:cut])
+		rest = rest[cut:]
+	}
+
+	testCases := []struct {
+		name   string
+		text   string
+		chunks []string
+	}{
+		{"no newlines", noNewlines, []string{noNewlines[:chunkSize], noNewlines[chunkSize:]}},
+		{"multibyte", multibyte, []string{multibyte[:cut], multibyte[cut:]}},
+		{"newlines", lines, lineChunks},
+	}
+	for _, tc := range testCases {
+		for _, c := range tc.chunks {
+			if !utf8.ValidString(c) {
+				t.Fatalf("%s: invalid test chunk", tc.name)
+			}
+		}
+		expected := countChunks(tc.chunks...)
+		w := counter.NewWriter()
+		writeAll(t, w, tc.text, len(tc.text)) // One write, cut by the writer
+		if n, err := w.Wait(); err != nil || n != expected {
+			t.Errorf("%s: expected %d tokens, got %d (err: %v)", tc.name, expected, n, err)
+		}
+	}
+}
+
+func TestWriterError(t *testing.T) {
+	broken := Counter{model: "broken", tok: &lazyTokenizer{load: func(*lazyTokenizer) error {
+		return errors.New("no vocabulary")
+	}}}
+	text := sourceText(2 * chunkSize)
+
+	w := broken.NewWriter()
+	if _, err := w.Write([]byte(text)); err == nil {
+		t.Errorf("Expected Write to fail once a chunk failed")
+	}
+	if _, err := w.Write([]byte("more")); err == nil {
+		t.Errorf("Expected Write to keep failing")
+	}
+	if _, err := w.Wait(); err == nil || !strings.Contains(err.Error(), "no vocabulary") {
+		t.Errorf("Expected Wait to return the error, got %v", err)
+	}
+
+	// Small input is only counted by Wait
+	w = broken.NewWriter()
+	if _, err := w.Write([]byte("short")); err != nil {
+		t.Errorf("Expected a buffered Write to succeed, got %v", err)
+	}
+	w.Finish(func(n int, err error) {
+		if err == nil {
+			t.Errorf("Expected Finish to return the error")
+		}
+	})
+
+	pool := broken.NewPool(2)
+	var finishErr error
+	w = pool.NewWriter()
+	writeAll(t, w, "short", 5)
+	w.Finish(func(n int, err error) { finishErr = err })
+	pool.Close()
+	if finishErr == nil || !strings.Contains(finishErr.Error(), "no vocabulary") {
+		t.Errorf("Expected Finish of a pool writer to return the error, got %v", finishErr)
+	}
+}
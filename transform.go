@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Transformer rewrites the content of a file before it is written to the output.
+// path is the path as shown in the output. Returning false as the second value
+// drops the file from the output.
+type Transformer interface {
+	Transform(path string, content []byte) ([]byte, bool, error)
+}
+
+// TransformerFunc adapts a function to the Transformer interface.
+type TransformerFunc func(path string, content []byte) ([]byte, bool, error)
+
+func (f TransformerFunc) Transform(path string, content []byte) ([]byte, bool, error) {
+	return f(path, content)
+}
+
+// scopedTransformer is a Transformer that only applies to some files. Files it
+// doesn't apply to are streamed as if it wasn't set, and their token counts
+// can be cached.
+type scopedTransformer interface {
+	Transformer
+	appliesTo(path string) bool
+}
+
+// WithTransformers adds transformers that are applied to every file in order.
+// Files are read into memory instead of streamed when a transformer applies to
+// them, which is every file unless it is a scopedTransformer.
+func WithTransformers(transformers ...Transformer) Option {
+	return func(g *Git2LLM) {
+		g.transformers = append(g.transformers, transformers...)
+	}
+}
+
+// transformersFor returns the transformers that apply to the file at relPath.
+func (g *Git2LLM) transformersFor(relPath string) []Transformer {
+	var transformers []Transformer
+	for _, t := range g.transformers {
+		if scoped, ok := t.(scopedTransformer); !ok || scoped.appliesTo(relPath) {
+			transformers = append(transformers, t)
+		}
+	}
+	return transformers
+}
+
+// transform runs content through transformers and reports whether the file is kept.
+func transform(transformers []Transformer, relPath string, content []byte) ([]byte, bool, error) {
+	for _, t := range transformers {
+		var keep bool
+		var err error
+		content, keep, err = t.Transform(relPath, content)
+		if err != nil || !keep {
+			return nil, false, err
+		}
+	}
+	return content, true, nil
+}
+
+// execFilter is a Transformer running a shell command per file. The content is
+// passed on stdin and the path in the GIT2LLM_PATH environment variable; the
+// output of the command replaces the content. A command that prints nothing drops the file.
+type execFilter struct {
+	command string
+}
+
+func newExecFilter(command string) Transformer {
+	return execFilter{command: command}
+}
+
+func (f execFilter) Transform(path string, content []byte) ([]byte, bool, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", f.command)
+	} else {
+		cmd = exec.Command("sh", "-c", f.command)
+	}
+	cmd.Env = append(os.Environ(), "GIT2LLM_PATH="+path)
+	cmd.Stdin = bytes.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, false, fmt.Errorf("exec filter %q: %w: %s", f.command, err, msg)
+		}
+		return nil, false, fmt.Errorf("exec filter %q: %w", f.command, err)
+	}
+	if stdout.Len() == 0 {
+		return nil, false, nil
+	}
+	return stdout.Bytes(), true, nil
+}
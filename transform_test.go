@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMTransformers(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":  "package main\n",
+		"notes.md": "drop me\n",
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	upper := TransformerFunc(func(path string, content []byte) ([]byte, bool, error) {
+		return bytes.ToUpper(content), true, nil
+	})
+	dropMarkdown := TransformerFunc(func(path string, content []byte) ([]byte, bool, error) {
+		return content, !strings.HasSuffix(path, ".md"), nil
+	})
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithTransformers(dropMarkdown, upper))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+
+	result := output.String()
+	if !strings.Contains(result, "Content of main.go:\nPACKAGE MAIN\n") {
+		t.Errorf("Expected transformed content of main.go, got:\n%s", result)
+	}
+	if strings.Contains(result, "File: notes.md") {
+		t.Errorf("Expected notes.md to be dropped, got:\n%s", result)
+	}
+	skipped := git2llm.Skipped()
+	if len(skipped) != 1 || skipped[0].Path != "notes.md" || skipped[0].Reason != SkipFiltered {
+		t.Errorf("Expected notes.md to be recorded as filtered, got %+v", skipped)
+	}
+}
+
+func TestExecFilter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec filter test uses a POSIX shell")
+	}
+	filter := newExecFilter(`tr a-z A-Z; echo "# $GIT2LLM_PATH"`)
+	content, keep, err := filter.Transform("src/main.go", []byte("package main\n"))
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if !keep || string(content) != "PACKAGE MAIN\n# src/main.go\n" {
+		t.Errorf("Unexpected result %q (keep: %v)", content, keep)
+	}
+
+	if _, keep, err := newExecFilter("cat >/dev/null").Transform("a.go", []byte("x")); err != nil || keep {
+		t.Errorf("Expected a filter without output to drop the file, got keep %v, err %v", keep, err)
+	}
+	if _, _, err := newExecFilter("echo oops >&2; exit 1").Transform("a.go", []byte("x")); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Expected a failing filter to return its stderr, got %v", err)
+	}
+}
+
+func TestScopedTransformers(t *testing.T) {
+	dir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":           "package main\n",
+		"package-lock.json": `{"packages": {"node_modules/react": {"version": "18.2.0"}}}`,
+	}
+	for fileName, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+	cache := loadTokenCache(filepath.Join(t.TempDir(), tokenCacheFile))
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(dir, nil, OSFS{}, &output, false, false, true, nil, "estimate", false, WithTransformers(lockfileCondenser{}), withTokenCache(cache))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if got := git2llm.transformersFor("main.go"); len(got) != 0 {
+		t.Errorf("Expected no transformers for main.go, got %v", got)
+	}
+	if got := git2llm.transformersFor("web/package-lock.json"); len(got) != 1 {
+		t.Errorf("Expected the condenser for package-lock.json, got %v", got)
+	}
+	if _, err := Scan(git2llm); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "react 18.2.0\n") {
+		t.Errorf("Expected the lockfile to be condensed, got:\n%s", output.String())
+	}
+
+	// Only the count of the file no transformer applies to is cached
+	for fileName, expected := range map[string]bool{"main.go": true, "package-lock.json": false} {
+		path := filepath.Join(dir, fileName)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := cache.get("estimate", "", path, info); ok != expected {
+			t.Errorf("For %s, expected cached %v, got %v", fileName, expected, ok)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// treeFS implements FS on top of a listing of a repository tree, as returned by
+// the GitHub API or git ls-tree. File contents are fetched on first access and
+// kept in memory, unless fetching is cheap, see localFetch. Paths are relative
+// to the root of the tree, which is ".".
+type treeFS struct {
+	entries    map[string]*treeEntry   // path -> entry
+	children   map[string][]*treeEntry // directory path -> sorted entries
+	contents   map[string][]byte
+	fetch      func(e *treeEntry) ([]byte, error)
+	localFetch bool // fetch reads a local repository, so contents of a known size are fetched again instead of kept
+}
+
+type treeEntry struct {
+	path  string
+	mode  string // git file mode, e.g. 100644
+	sha   string
+	size  int64 // -1 if the listing doesn't have it, see treeFileInfo.Size
+	isDir bool
+}
+
+// newTreeFS builds a tree from entries; fetch downloads the content of a file.
+func newTreeFS(entries []*treeEntry, fetch func(e *treeEntry) ([]byte, error)) *treeFS {
+	t := &treeFS{
+		entries:  map[string]*treeEntry{".": {path: ".", isDir: true}},
+		children: make(map[string][]*treeEntry),
+		contents: make(map[string][]byte),
+		fetch:    fetch,
+	}
+	for _, e := range entries {
+		t.entries[e.path] = e
+		parent := path.Dir(e.path)
+		t.children[parent] = append(t.children[parent], e)
+	}
+	for _, children := range t.children {
+		sort.Slice(children, func(i, j int) bool { return children[i].path < children[j].path })
+	}
+	return t
+}
+
+// clean turns a path as used by Git2LLM into a key of the tree.
+func (t *treeFS) clean(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func (t *treeFS) lookup(op, name string) (*treeEntry, error) {
+	e, ok := t.entries[t.clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+func (t *treeFS) Open(name string) (File, error) {
+	content, err := t.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (t *treeFS) ReadDir(name string) ([]os.DirEntry, error) {
+	e, err := t.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+	children := t.children[e.path]
+	entries := make([]os.DirEntry, 0, len(children))
+	for _, child := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(treeFileInfo{t, child}))
+	}
+	return entries, nil
+}
+
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	e, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.isDir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	if content, ok := t.contents[e.path]; ok {
+		return content, nil
+	}
+	content, err := t.fetch(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	if !t.localFetch || e.size < 0 {
+		t.contents[e.path] = content
+	}
+	return content, nil
+}
+
+func (t *treeFS) Stat(name string) (os.FileInfo, error) {
+	e, err := t.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return treeFileInfo{t, e}, nil
+}
+
+func (t *treeFS) Lstat(name string) (os.FileInfo, error) {
+	return t.Stat(name)
+}
+
+// treeFileInfo implements os.FileInfo for a tree entry.
+type treeFileInfo struct {
+	t *treeFS
+	e *treeEntry
+}
+
+func (i treeFileInfo) Name() string { return path.Base(i.e.path) }
+
+// Size returns the size of the file. If the listing of the tree doesn't have
+// it, the file is fetched to learn it; 0 is returned if that fails.
+func (i treeFileInfo) Size() int64 {
+	if i.e.size < 0 && !i.e.isDir {
+		content, err := i.t.ReadFile(i.e.path)
+		if err != nil {
+			return 0
+		}
+		i.e.size = int64(len(content))
+	}
+	return max(i.e.size, 0)
+}
+func (i treeFileInfo) Mode() os.FileMode {
+	switch {
+	case i.e.isDir:
+		return os.ModeDir | 0755
+	case i.e.mode == "120000":
+		return os.ModeSymlink | 0777
+	case i.e.mode == "100755":
+		return 0755
+	}
+	return 0644
+}
+func (i treeFileInfo) ModTime() time.Time { return time.Time{} }
+func (i treeFileInfo) IsDir() bool        { return i.e.isDir }
+func (i treeFileInfo) Sys() interface{}   { return nil }
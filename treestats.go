@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// treeStatsLanguages is the number of languages listed on the root line of
+// the tree; the rest are summed up as other.
+const treeStatsLanguages = 5
+
+// WithTreeStats annotates the directories of the tree with the number of files
+// below them whose contents are written and their total number of lines, and
+// the root with the share of lines of each language, showing where the code
+// mass lives.
+func WithTreeStats(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.treeStats = enabled
+	}
+}
+
+// dirStats are the aggregate counts of the files below a directory.
+type dirStats struct {
+	files     int
+	lines     int
+	languages map[string]int // Lines by language, for the root
+}
+
+// collectTreeStats counts the files and lines below every directory, by path
+// relative to the start path; the root is "". Binary files count as files
+// without lines.
+func (g *Git2LLM) collectTreeStats() (map[string]*dirStats, error) {
+	files, err := g.contentFiles()
+	if err != nil {
+		return nil, err
+	}
+	stats := map[string]*dirStats{"": {languages: make(map[string]int)}}
+	for _, f := range files {
+		content, err := g.fs.ReadFile(f.path)
+		if err != nil {
+			continue // Reported when the content is written
+		}
+		lines := 0
+		if bytes.IndexByte(content, 0) == -1 {
+			lines = bytes.Count(content, []byte("\n"))
+			if len(content) > 0 && content[len(content)-1] != '\n' {
+				lines++
+			}
+		}
+		stats[""].languages[g.typeOf(f.relPath)] += lines
+		for dir := path.Dir(f.relPath); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			s := stats[dir]
+			if s == nil {
+				s = &dirStats{}
+				stats[dir] = s
+			}
+			s.files++
+			s.lines += lines
+			if dir == "" {
+				break
+			}
+		}
+	}
+	return stats, nil
+}
+
+// annotation returns the annotation of a directory line, such as
+// " (12 files, 3456 lines)", or "" for a directory without files. With
+// languages, the largest languages by lines are added.
+func (s *dirStats) annotation(languages bool) string {
+	if s == nil || s.files == 0 {
+		return ""
+	}
+	files := "files"
+	if s.files == 1 {
+		files = "file"
+	}
+	lines := "lines"
+	if s.lines == 1 {
+		lines = "line"
+	}
+	annotation := fmt.Sprintf(" (%d %s, %d %s", s.files, files, s.lines, lines)
+	if languages && s.lines > 0 {
+		annotation += "; " + s.languageShares()
+	}
+	return annotation + ")"
+}
+
+// languageShares lists the largest languages by their share of the lines,
+// such as "go 80%, markdown 15%, other 5%". Languages below 1% are left out.
+func (s *dirStats) languageShares() string {
+	names := make([]string, 0, len(s.languages))
+	for name, lines := range s.languages {
+		if lines > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.languages[names[i]] != s.languages[names[j]] {
+			return s.languages[names[i]] > s.languages[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	var shares []string
+	other := 0
+	for i, name := range names {
+		share := s.languages[name] * 100 / s.lines
+		if i >= treeStatsLanguages || share == 0 {
+			other += s.languages[name]
+			continue
+		}
+		shares = append(shares, fmt.Sprintf("%s %d%%", name, share))
+	}
+	if other*100/s.lines > 0 {
+		shares = append(shares, fmt.Sprintf("other %d%%", other*100/s.lines))
+	}
+	return strings.Join(shares, ", ")
+}
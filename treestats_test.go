@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGit2LLMTreeStats(t *testing.T) {
+	mockFS := &MockFS{
+		DirStructure: map[string][]string{
+			".":        {"cmd", "README.md", "main.go"},
+			"cmd":      {"tool"},
+			"cmd/tool": {"tool.go"},
+		},
+		FileContentMap: map[string]string{
+			"README.md":        "# Tool\n",
+			"main.go":          "package main\n\nfunc main() {}\n",
+			"cmd/tool/tool.go": "package main\n\nfunc main() {\n}",
+		},
+	}
+	g, err := NewGit2LLM(".", nil, mockFS, nil, false, false, false, nil, "", false, WithTreeStats(true))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	result, err := g.generateDirectoryStructureString()
+	if err != nil {
+		t.Fatalf("generateDirectoryStructureString failed: %v", err)
+	}
+	expected := "/ (3 files, 8 lines; go 87%, markdown 12%)\n" +
+		"├── cmd/ (1 file, 4 lines)\n" +
+		"│   └── tool/ (1 file, 4 lines)\n" +
+		"│       └── tool.go\n" +
+		"├── main.go\n" +
+		"└── README.md\n"
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestDirStatsLanguageShares(t *testing.T) {
+	s := &dirStats{lines: 1000, languages: map[string]int{
+		"go": 500, "python": 200, "markdown": 100, "yaml": 80, "json": 60, "shell": 55, "text": 5,
+	}}
+	if got, expected := s.languageShares(), "go 50%, python 20%, markdown 10%, yaml 8%, json 6%, other 6%"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
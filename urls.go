@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// urlRootName is the root the files downloaded with --from-urls are shown under.
+	urlRootName = "urls"
+	// urlMaxSize is the largest file downloaded with --from-urls; larger files are left out.
+	urlMaxSize = 5 << 20
+	// urlTimeout limits the download of a single URL.
+	urlTimeout = 30 * time.Second
+)
+
+// readURLList reads the URLs in the file at path, one per line. Empty lines and
+// lines starting with # are ignored.
+func readURLList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening URL list: %w", err)
+	}
+	defer file.Close()
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%s:%d: invalid URL %q, expected http or https", path, n, line)
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading URL list: %w", err)
+	}
+	return urls, nil
+}
+
+// urlFilePath returns the path a downloaded file is shown at: the path of the
+// URL, or the host for URLs without a path.
+func urlFilePath(u *url.URL) string {
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		return u.Host
+	}
+	return strings.TrimPrefix(p, "/")
+}
+
+// newURLFS downloads the files at urls into a file system, named by their URL
+// path, prefixed with the host if two URLs share a path. Files that fail to
+// download or are larger than maxSize are left out with a warning.
+func newURLFS(urls []string, client *http.Client, maxSize int64, logger *slog.Logger) (*treeFS, error) {
+	paths := make(map[string]int)
+	parsed := make([]*url.URL, len(urls))
+	for i, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+		}
+		parsed[i] = u
+		paths[urlFilePath(u)]++
+	}
+
+	contents := make(map[string][]byte)
+	var entries []*treeEntry
+	dirs := make(map[string]bool)
+	for i, u := range parsed {
+		name := urlFilePath(u)
+		if paths[name] > 1 {
+			name = path.Join(u.Host, name)
+		}
+		if _, ok := contents[name]; ok {
+			return nil, fmt.Errorf("URL %s is listed twice", urls[i])
+		}
+		data, err := download(client, urls[i], maxSize)
+		if err != nil {
+			logger.Warn("Skipping URL", "url", urls[i], "error", err)
+			continue
+		}
+		contents[name] = data
+		entries = append(entries, &treeEntry{path: name, mode: "100644", size: int64(len(data))})
+		for dir := path.Dir(name); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+			entries = append(entries, &treeEntry{path: dir, isDir: true})
+		}
+	}
+	return newTreeFS(entries, func(e *treeEntry) ([]byte, error) {
+		return contents[e.path], nil
+	}), nil
+}
+
+// download returns the body of a GET request to rawURL, failing if it is larger than maxSize.
+func download(client *http.Client, rawURL string, maxSize int64) ([]byte, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("file of %d bytes is larger than the limit of %d bytes", resp.ContentLength, maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than the limit of %d bytes", maxSize)
+	}
+	return data, nil
+}
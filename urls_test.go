@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadURLList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	if err := os.WriteFile(path, []byte("# Configs\nhttps://example.com/a.yaml\n\n  http://example.com/b/c.py  \n"), 0644); err != nil {
+		t.Fatalf("Failed to write URL list: %v", err)
+	}
+	urls, err := readURLList(path)
+	if err != nil {
+		t.Fatalf("readURLList failed: %v", err)
+	}
+	if len(urls) != 2 || urls[0] != "https://example.com/a.yaml" || urls[1] != "http://example.com/b/c.py" {
+		t.Errorf("Unexpected URLs %q", urls)
+	}
+
+	if err := os.WriteFile(path, []byte("https://example.com/a\nfile:///etc/passwd\n"), 0644); err != nil {
+		t.Fatalf("Failed to write URL list: %v", err)
+	}
+	if _, err := readURLList(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected an error for the file URL on line 2, got %v", err)
+	}
+}
+
+func TestURLFS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/1234/raw/deploy.sh":
+			io.WriteString(w, "#!/bin/sh\necho deploy\n")
+		case "/config.yaml":
+			io.WriteString(w, "key: value\n")
+		case "/big.txt":
+			io.WriteString(w, strings.Repeat("x", 100))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	urls := []string{
+		server.URL + "/user/1234/raw/deploy.sh",
+		server.URL + "/config.yaml",
+		server.URL + "/big.txt",
+		server.URL + "/missing.txt",
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	urlFS, err := newURLFS(urls, server.Client(), 50, logger)
+	if err != nil {
+		t.Fatalf("newURLFS failed: %v", err)
+	}
+
+	var output strings.Builder
+	git2llm, err := NewGit2LLM(".", nil, urlFS, &output, false, false, false, nil, "", false, WithPathPrefix(urlRootName))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := git2llm.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	for _, expected := range []string{"Content of urls/user/1234/raw/deploy.sh:\n#!/bin/sh\n", "Content of urls/config.yaml:\nkey: value\n"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected %q in the output. Result:\n%s", expected, result)
+		}
+	}
+	if strings.Contains(result, "big.txt") || strings.Contains(result, "missing.txt") {
+		t.Errorf("Expected the large and the missing file to be left out. Result:\n%s", result)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+)
+
+// vendoredDirs are directories holding third-party code, dependencies or build
+// output, excluded wherever they appear unless vendored code is included.
+var vendoredDirs = map[string]bool{
+	"vendor":           true,
+	"node_modules":     true,
+	"bower_components": true,
+	"jspm_packages":    true,
+	"third_party":      true,
+	"third-party":      true,
+	"thirdparty":       true,
+	"external":         true,
+	"extern":           true,
+	"dist":             true,
+	"build":            true,
+	"target":           true,
+	"out":              true,
+	".venv":            true,
+	"venv":             true,
+	"site-packages":    true,
+	"__pycache__":      true,
+	".tox":             true,
+	"Pods":             true,
+	"Carthage":         true,
+	".gradle":          true,
+	".next":            true,
+	".nuxt":            true,
+	".terraform":       true,
+}
+
+// vendoredFiles match the names of bundled or minified third-party files, like
+// the vendored file heuristics of GitHub's linguist.
+var vendoredFiles = []string{
+	"*.min.js",
+	"*.min.css",
+	"*.min.mjs",
+	"*-min.js",
+	"*.bundle.js",
+	"*.chunk.js",
+	"jquery-*.js",
+	"jquery.*.js",
+	"bootstrap.*.js",
+	"bootstrap.*.css",
+	"*.js.map",
+	"*.css.map",
+	"*.pb.go",
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+}
+
+// WithVendored includes vendored and third-party code, dependency directories
+// and build output, which are excluded by default.
+func WithVendored(include bool) Option {
+	return func(g *Git2LLM) {
+		g.includeVendored = include
+	}
+}
+
+// isVendored reports whether relPath, whose elements are parts, is vendored
+// code: below a vendored directory, or a bundled or minified file.
+func (g *Git2LLM) isVendored(parts []string) bool {
+	if g.includeVendored {
+		return false
+	}
+	for _, dir := range parts[:len(parts)-1] {
+		if vendoredDirs[dir] {
+			return true
+		}
+	}
+	return isVendoredFile(parts[len(parts)-1])
+}
+
+// isVendoredDir reports whether the directory name holds vendored code, so it
+// is not walked at all.
+func (g *Git2LLM) isVendoredDir(name string) bool {
+	return !g.includeVendored && vendoredDirs[name]
+}
+
+// vendoredFileMatcher is vendoredFiles, compiled.
+var vendoredFileMatcher = func() *patternMatcher {
+	patterns := make(map[string]bool, len(vendoredFiles))
+	for _, pattern := range vendoredFiles {
+		patterns[pattern] = true
+	}
+	return compilePatterns(patterns)
+}()
+
+// isVendoredFile reports whether the file name is bundled or minified.
+func isVendoredFile(name string) bool {
+	name = strings.ToLower(name)
+	return vendoredFileMatcher.match(name, []string{name})
+}
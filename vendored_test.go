@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGit2LLMVendored(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":                         "package main\n",
+		"build":                           "#!/bin/sh\ngo build\n",
+		"vendor/github.com/x/y/y.go":      "package y\n",
+		"web/node_modules/react/index.js": "module.exports = {}\n",
+		"web/dist/app.js":                 "bundle\n",
+		"web/src/app.js":                  "app\n",
+		"web/static/jquery-3.7.1.js":      "jquery\n",
+		"web/static/app.min.js":           "min\n",
+		"api/api.pb.go":                   "package api\n",
+	}
+	for fileName, content := range testFiles {
+		path := filepath.Join(tempDir, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", fileName, err)
+		}
+	}
+
+	for _, include := range []bool{false, true} {
+		var output strings.Builder
+		git2llm, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false, WithVendored(include))
+		if err != nil {
+			t.Fatalf("NewGit2LLM failed: %v", err)
+		}
+		if err := git2llm.ScanRepository(); err != nil {
+			t.Fatalf("ScanRepository failed: %v", err)
+		}
+		result := output.String()
+		for _, always := range []string{"main.go", "build", "web/src/app.js"} {
+			if !strings.Contains(result, "Content of "+always+":") {
+				t.Errorf("Expected %s to be included", always)
+			}
+		}
+		for _, vendored := range []string{"vendor/github.com/x/y/y.go", "web/node_modules/react/index.js", "web/dist/app.js", "web/static/jquery-3.7.1.js", "web/static/app.min.js", "api/api.pb.go"} {
+			if included := strings.Contains(result, "Content of "+vendored+":"); included != include {
+				t.Errorf("With include %v, expected %s to be included: %v", include, vendored, include)
+			}
+		}
+		// Vendored directories are not even shown in the tree
+		if tree := result[:strings.Index(result, "File Contents:")]; strings.Contains(tree, "node_modules") != include {
+			t.Errorf("With include %v, unexpected tree:\n%s", include, tree)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// versionInfo returns the version for --version: the embedded version and,
+// from the build info, the commit it was built from and the Go version.
+func versionInfo() string {
+	info, _ := debug.ReadBuildInfo() // nil if unavailable
+	return formatVersion(strings.TrimSpace(embeddedVersion), info)
+}
+
+// formatVersion formats version and the build info, which may be nil.
+func formatVersion(version string, info *debug.BuildInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "git2llm %s\n", version)
+	if info == nil {
+		return b.String()
+	}
+	settings := make(map[string]string)
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	if revision := settings["vcs.revision"]; revision != "" {
+		if settings["vcs.modified"] == "true" {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(&b, "commit: %s\n", revision)
+		if t := settings["vcs.time"]; t != "" {
+			fmt.Fprintf(&b, "commit time: %s\n", t)
+		}
+	} else if v := info.Main.Version; v != "" && v != "(devel)" {
+		fmt.Fprintf(&b, "module: %s %s\n", info.Main.Path, v) // Installed with go install, without VCS information
+	}
+	fmt.Fprintf(&b, "go: %s %s/%s\n", info.GoVersion, runtime.GOOS, runtime.GOARCH)
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestFormatVersion(t *testing.T) {
+	if got := formatVersion("v1.2.3", nil); got != "git2llm v1.2.3\n" {
+		t.Errorf("Expected only the version without build info, got %q", got)
+	}
+
+	info := &debug.BuildInfo{
+		GoVersion: "go1.23.5",
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abcd"},
+			{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	expected := "git2llm v1.2.3\ncommit: 0123abcd (modified)\ncommit time: 2025-01-02T03:04:05Z\ngo: go1.23.5 " + runtime.GOOS + "/" + runtime.GOARCH + "\n"
+	if got := formatVersion("v1.2.3", info); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	installed := &debug.BuildInfo{GoVersion: "go1.23.5", Main: debug.Module{Path: "github.com/perbu/git2llm", Version: "v1.2.3"}}
+	if got := formatVersion("v1.2.3", installed); !strings.Contains(got, "module: github.com/perbu/git2llm v1.2.3\n") {
+		t.Errorf("Expected the module version, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// FileInfo describes a file passed to the callback of Walk.
+type FileInfo struct {
+	Path     string // As shown in the output
+	Size     int64  // Size on disk in bytes
+	Language string // Syntax hint such as go or python, empty if unknown
+}
+
+// Walk calls fn for every file whose content would be part of the output, in
+// output order, with the content as it would be written: filtered,
+// transformed, redacted and sanitized. It is meant for consumers storing the
+// files themselves, e.g. in a search index, instead of parsing a rendered
+// format. Nothing is written to the output writer, and skipped files are
+// recorded as in a scan. An error returned by fn stops the walk and is
+// returned, and so is the error of ctx once it is done.
+func (g *Git2LLM) Walk(ctx context.Context, fn func(f FileInfo, r io.Reader) error) error {
+	files, err := g.contentFiles()
+	if err != nil {
+		return err
+	}
+	w := &walkEmitter{fn: fn}
+	defer func(out io.Writer, emitters []emitter) {
+		g.outputWriter, g.emitters = out, emitters
+		g.contents = nil // The next scan collects the files again
+	}(g.outputWriter, g.emitters)
+	g.outputWriter, g.emitters = io.Discard, []emitter{w}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w.size = f.size
+		if err := g.processFile(f.path, f.relPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkEmitter passes the content of every file to the callback of Walk once
+// it is complete.
+type walkEmitter struct {
+	fn      func(f FileInfo, r io.Reader) error
+	size    int64 // Of the file being processed
+	file    FileInfo
+	content bytes.Buffer
+}
+
+func (w *walkEmitter) start(bool) error       { return nil }
+func (w *walkEmitter) writeTree(string) error { return nil }
+
+func (w *walkEmitter) beginFile(relPath, lang string) (io.Writer, error) {
+	w.file = FileInfo{Path: relPath, Size: w.size, Language: lang}
+	w.content.Reset()
+	return &w.content, nil
+}
+
+func (w *walkEmitter) endFile() error {
+	return w.fn(w.file, bytes.NewReader(w.content.Bytes()))
+}
+
+func (w *walkEmitter) finish(*ScanResult, *Metadata) error { return nil }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":      "package main\r\n",
+		"app.env":      "TOKEN=secret\n",
+		"data.bin":     "\x00\x01\x02",
+		"docs/note.md": "# Note\n",
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file %s: %v", name, err)
+		}
+	}
+
+	var output strings.Builder
+	g, err := NewGit2LLM(tempDir, nil, nil, &output, false, false, false, nil, "", false,
+		WithRedactPatterns([]string{"*.env"}), WithPathPrefix("app"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	var files []FileInfo
+	contents := make(map[string]string)
+	err = g.Walk(context.Background(), func(f FileInfo, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		contents[f.Path] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	expected := []FileInfo{
+		{Path: "app/app.env", Size: 13, Language: "dotenv"},
+		{Path: "app/docs/note.md", Size: 7, Language: "markdown"},
+		{Path: "app/main.go", Size: 14, Language: "go"},
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %+v, got %+v", expected, files)
+	}
+	if contents["app/app.env"] != "TOKEN=<redacted>\n" || contents["app/main.go"] != "package main\n" {
+		t.Errorf("Expected redacted and normalized contents, got %q", contents)
+	}
+	if output.Len() != 0 {
+		t.Errorf("Expected nothing written to the output, got:\n%s", output.String())
+	}
+	if len(g.Skipped()) != 1 || g.Skipped()[0].Path != "app/data.bin" {
+		t.Errorf("Expected the binary file to be skipped, got %+v", g.Skipped())
+	}
+
+	// An error from the callback stops the walk
+	stop := errors.New("stop")
+	calls := 0
+	err = g.Walk(context.Background(), func(FileInfo, io.Reader) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected the walk to stop after the first file, got %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Walk(ctx, func(FileInfo, io.Reader) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the canceled context to stop the walk, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// emptyTree is the git object ID of the empty tree, the base of the diff in a
+// repository without commits.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// WithWorkingDiff appends the uncommitted changes of the start path, staged and
+// unstaged, as a unified diff against HEAD after the file contents. Only files
+// whose content is part of the output and deleted files passing the filters
+// are diffed, so nothing left out of the contents reaches the diff.
+func WithWorkingDiff(enabled bool) Option {
+	return func(g *Git2LLM) {
+		g.workingDiff = enabled
+	}
+}
+
+// writeWorkingDiff writes the working tree diff of every root that has one
+// enabled, after a heading. Nothing is written if there are no changes. It
+// returns the number of tokens written, 0 unless tokens are counted.
+func writeWorkingDiff(w io.Writer, roots []*Git2LLM) (int, error) {
+	var out bytes.Buffer
+	for _, g := range roots {
+		if !g.workingDiff {
+			continue
+		}
+		diff, err := g.diffWorkingTree()
+		if err != nil {
+			return 0, fmt.Errorf("error diffing %s: %w", g.startPath, err)
+		}
+		out.Write(diff)
+	}
+	if out.Len() == 0 {
+		roots[0].logger.Debug("No uncommitted changes to diff")
+		return 0, nil
+	}
+	const heading = "\n\nWorking Tree Diff:\n------------------\n"
+	if b := roots[0].budget; b != nil && !b.fits(int64(len(heading)+out.Len())) {
+		roots[0].logger.Warn("Output limit reached, leaving out the working tree diff", "limit", b.max, "bytes", out.Len())
+		return 0, nil
+	}
+	if _, err := fmt.Fprintf(w, "%s%s", heading, out.Bytes()); err != nil {
+		return 0, fmt.Errorf("error writing to output file: %w", err)
+	}
+	if !roots[0].countTokens || roots[0].counter == nil {
+		return 0, nil
+	}
+	n, err := roots[0].counter.Count(out.String())
+	if err != nil {
+		return 0, fmt.Errorf("counter.Count: %w", err)
+	}
+	return n, nil
+}
+
+// diffWorkingTree returns the diff of the working tree and the index against
+// HEAD for the files that may be shown, with the paths as shown in the output.
+func (g *Git2LLM) diffWorkingTree() ([]byte, error) {
+	base := "HEAD"
+	if _, err := gitOutput(g.startPath, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		base = emptyTree
+	}
+	changed, err := runGit(g.startPath, "diff", "--name-only", "--relative", "--no-renames", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := runGit(g.startPath, "diff", "--name-only", "--relative", "--no-renames", "--diff-filter=D", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	written := make(map[string]bool, len(g.results))
+	for _, f := range g.results {
+		written[f.Path] = true
+	}
+	isDeleted := make(map[string]bool, len(deleted))
+	for _, name := range deleted {
+		isDeleted[name] = true
+	}
+	var paths []string
+	for _, name := range changed {
+		if g.shouldRedact(name) {
+			continue
+		}
+		if written[g.displayPath(name)] || isDeleted[name] && g.inScope(name, false) && !g.isExcluded(name) &&
+			g.matchesFileType(filepath.Join(g.startPath, filepath.FromSlash(name)), path.Base(name)) {
+			paths = append(paths, name)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	prefix := ""
+	if g.pathPrefix != "" {
+		prefix = g.pathPrefix + "/"
+	}
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--relative", "--no-renames", "--src-prefix=a/" + prefix, "--dst-prefix=b/" + prefix, base, "--"}
+	diff, err := gitOutput(g.startPath, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Private keys and personal data are treated as in the contents, except
+	// that a file is left out of the diff where its content would be skipped
+	var out bytes.Buffer
+	for _, section := range splitDiff(diff) {
+		if privateKeyBlock.Match(section) {
+			switch g.policy[CategorySecrets] {
+			case PolicyWarn:
+			case PolicyRedact:
+				section = redactPrivateKeys(section)
+			default:
+				g.logger.Debug("Leaving a private key out of the working tree diff", "diff", firstLine(section))
+				continue
+			}
+		}
+		if g.pii != nil && g.policy[CategoryPII] != PolicyWarn {
+			if matches := findPII(section); len(matches) > 0 {
+				if g.policy[CategoryPII] != PolicyRedact {
+					g.logger.Debug("Leaving personal data out of the working tree diff", "diff", firstLine(section))
+					continue
+				}
+				section = g.pii.mask(section, matches)
+			}
+		}
+		out.Write(section)
+	}
+	return out.Bytes(), nil
+}
+
+// firstLine returns the first line of b, without the newline.
+func firstLine(b []byte) string {
+	line, _, _ := strings.Cut(string(b), "\n")
+	return line
+}
+
+// splitDiff splits a unified diff into the sections of its files, each
+// starting with its "diff --git" line.
+func splitDiff(diff []byte) [][]byte {
+	var sections [][]byte
+	start := 0
+	for i := 0; i < len(diff); {
+		end := bytes.IndexByte(diff[i:], '\n')
+		if end < 0 {
+			end = len(diff) - i - 1
+		}
+		if i > start && bytes.HasPrefix(diff[i:], []byte("diff --git ")) {
+			sections = append(sections, diff[start:i])
+			start = i
+		}
+		i += end + 1
+	}
+	if start < len(diff) {
+		sections = append(sections, diff[start:])
+	}
+	return sections
+}
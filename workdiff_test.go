@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkingDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tempDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	write("main.go", "package main\n")
+	write("staged.go", "package main\n")
+	write("deleted.go", "package main\n\nfunc gone() {}\n")
+	write("notes.txt", "notes\n")
+	write(".env", "TOKEN=one\n")
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("staged.go", "package main\n\nvar staged = true\n")
+	git("add", "staged.go")
+	write("notes.txt", "changed notes\n")
+	write(".env", "TOKEN=two\n")
+	if err := os.Remove(filepath.Join(tempDir, "deleted.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	var output strings.Builder
+	g, err := NewGit2LLM(tempDir, []string{".go"}, nil, &output, false, false, false, nil, "", false, WithWorkingDiff(true), WithPathPrefix("app"))
+	if err != nil {
+		t.Fatalf("NewGit2LLM failed: %v", err)
+	}
+	if err := g.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	result := output.String()
+	_, diff, ok := strings.Cut(result, "\n\nWorking Tree Diff:\n------------------\n")
+	if !ok {
+		t.Fatalf("Expected a working tree diff, got:\n%s", result)
+	}
+	for _, expected := range []string{"diff --git a/app/main.go b/app/main.go", "+func main() {}", "+var staged = true", "diff --git a/app/deleted.go b/app/deleted.go", "-func gone() {}"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expected %q in the diff, got:\n%s", expected, diff)
+		}
+	}
+	for _, unexpected := range []string{"notes", "TOKEN"} {
+		if strings.Contains(diff, unexpected) {
+			t.Errorf("Expected files left out of the contents to be left out of the diff, got:\n%s", diff)
+		}
+	}
+
+	// Without changes, nothing is written
+	git("add", "-A")
+	git("commit", "-q", "-m", "changes")
+	output.Reset()
+	if err := g.ScanRepository(); err != nil {
+		t.Fatalf("ScanRepository failed: %v", err)
+	}
+	if strings.Contains(output.String(), "Working Tree Diff") {
+		t.Errorf("Expected no diff without changes, got:\n%s", output.String())
+	}
+}
+
+func TestSplitDiff(t *testing.T) {
+	diff := "diff --git a/a b/a\n-x\n+diff --git y\ndiff --git a/b b/b\n+z"
+	sections := splitDiff([]byte(diff))
+	if len(sections) != 2 || string(sections[0]) != "diff --git a/a b/a\n-x\n+diff --git y\n" || string(sections[1]) != "diff --git a/b b/b\n+z" {
+		t.Errorf("Unexpected sections %q", sections)
+	}
+}